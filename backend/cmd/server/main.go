@@ -6,12 +6,17 @@ import (
         "io/fs"
         "log"
         "net/http"
+        "strconv"
         "time"
 
         "github.com/seedmanage/backend/internal/adapters"
+        "github.com/seedmanage/backend/internal/cache"
+        "github.com/seedmanage/backend/internal/coalesce"
         "github.com/seedmanage/backend/internal/collections"
         "github.com/seedmanage/backend/internal/config"
         "github.com/seedmanage/backend/internal/history"
+        "github.com/seedmanage/backend/internal/httpclient"
+        "github.com/seedmanage/backend/internal/localindex"
         "github.com/seedmanage/backend/internal/registry"
         "github.com/seedmanage/backend/internal/service"
         "github.com/seedmanage/backend/internal/utils"
@@ -135,11 +140,19 @@ func main() {
     nyaaEndpoint := utils.Getenv(config.NyaaEndpointEnv, "https://nyaaapi.onrender.com/nyaa")
             sukebeiEndpoint := utils.Getenv(config.SukebeiEndpointEnv, "https://nyaaapi.onrender.com/sukebei")
             htmlSukebeiEndpoint := utils.Getenv(config.HTMLSukebeiEndpointEnv, "https://sukebei.nyaa.si/")
+            nyaaRSSEndpoint := utils.Getenv(config.NyaaRSSEndpointEnv, "https://nyaa.si/")
+            sukebeiRSSEndpoint := utils.Getenv(config.SukebeiRSSEndpointEnv, "https://sukebei.nyaa.si/")
+            htmlScrapersDir := utils.ResolvePath(utils.Getenv(config.HTMLScrapersDirEnv, "data/scrapers"))
+            torznabConfigPath := utils.ResolvePath(utils.Getenv(config.TorznabConfigEnv, "data/torznab.json"))
+            networkConfigPath := utils.ResolvePath(utils.Getenv(config.NetworkConfigEnv, "data/network.json"))
+            pluginsDir := utils.ResolvePath(utils.Getenv(config.PluginsDirEnv, "data/plugins"))
+            adapterSettingsPath := utils.ResolvePath(utils.Getenv(config.AdapterSettingsEnv, "data/adapters.json"))
             sampleDataPath := utils.ResolvePath(utils.Getenv(config.SampleDataEnv, "data/sampleResults.json"))
     historyFilePath := utils.ResolvePath(utils.Getenv(config.SearchHistoryFileEnv, "data/searchHistory.json"))
     defaultAdapter := utils.Getenv(config.DefaultAdapterEnv, "apibay")
     fallbackAdapter := utils.Getenv(config.FallbackAdapterEnv, "sample")
     password := utils.Getenv(config.PasswordEnv, "")
+    torznabAPIKey := utils.Getenv(config.TorznabAPIKeyEnv, "")
 
     log.Printf("[backend] 磁力搜索服务启动中...")
 
@@ -164,6 +177,28 @@ func main() {
     // 注册 HTML Sukebei 适配器
     reg.Register(adapters.NewHTMLSukebei(htmlSukebeiEndpoint, config.BaseTrackers))
 
+    // 注册基于官方 RSS 订阅的 Nyaa / Sukebei 适配器
+    reg.Register(adapters.NewNyaaRSS(nyaaRSSEndpoint, config.BaseTrackers))
+    reg.Register(adapters.NewSukebeiRSS(sukebeiRSSEndpoint, config.BaseTrackers))
+
+    // 注册基于 CSS 选择器规则的通用 HTML 适配器
+    scrapers, err := adapters.LoadHTMLScrapers(htmlScrapersDir, config.BaseTrackers)
+    if err != nil {
+        log.Printf("[backend] 部分 HTML 规则适配器加载失败: %v", err)
+    }
+    for _, scraper := range scrapers {
+        reg.Register(scraper)
+    }
+
+    // 注册 Torznab 索引器，每个端点作为独立的适配器
+    indexers, err := adapters.LoadTorznabIndexers(torznabConfigPath, config.BaseTrackers)
+    if err != nil {
+        log.Printf("[backend] 部分 Torznab 索引器加载失败: %v", err)
+    }
+    for _, indexer := range indexers {
+        reg.Register(indexer)
+    }
+
     // 注册本地示例适配器（如果可用）
     if sampleAdapter, err := adapters.NewSample(sampleDataPath); err != nil {
         log.Printf("[backend] 本地示例适配器不可用: %v", err)
@@ -171,9 +206,69 @@ func main() {
         reg.Register(sampleAdapter)
     }
 
-    // 配置默认和备用适配器
-    if err := reg.Configure(defaultAdapter, fallbackAdapter); err != nil {
-        log.Printf("[backend] 适配器配置问题: %v", err)
+    // 注册插件目录中的外部进程适配器，插件 ID 与已注册的适配器冲突时跳过
+    pluginTimeout, err := time.ParseDuration(utils.Getenv(config.PluginTimeoutEnv, "30s"))
+    if err != nil {
+        log.Printf("[backend] %s 无效，使用默认值: %v", config.PluginTimeoutEnv, err)
+        pluginTimeout = adapters.DefaultPluginTimeout
+    }
+    plugins, err := adapters.LoadPlugins(pluginsDir, pluginTimeout, config.BaseTrackers)
+    if err != nil {
+        log.Printf("[backend] 部分插件加载失败: %v", err)
+    }
+    for _, plugin := range plugins {
+        if _, exists := reg.Get(plugin.ID()); exists {
+            log.Printf("[backend] 插件 %s 与已注册的适配器 ID 冲突，已跳过", plugin.Endpoint())
+            continue
+        }
+        reg.Register(plugin)
+        log.Printf("[backend] 已加载插件适配器 %s (%s)", plugin.ID(), plugin.Endpoint())
+    }
+
+    // 为通过 HTTP 访问上游的适配器应用网络配置（代理、超时、请求头、Cookie、TLS）
+    networkConfigs, err := httpclient.LoadConfigs(networkConfigPath)
+    if err != nil {
+        log.Printf("[backend] 网络配置加载失败: %v", err)
+    }
+    for _, id := range reg.IDs() {
+        adapter, _ := reg.Get(id)
+        configurable, ok := adapter.(adapters.NetworkConfigurable)
+        if !ok {
+            continue
+        }
+        if err := configurable.ConfigureNetwork(networkConfigs.For(id)); err != nil {
+            log.Printf("[backend] 适配器 %s 的网络配置无效: %v", id, err)
+        }
+    }
+
+    // 合并相同的并发搜索，缓存位于其外层，因此只有未命中缓存的请求才会被合并
+    for _, id := range reg.IDs() {
+        adapter, _ := reg.Get(id)
+        reg.Register(coalesce.Wrap(adapter))
+    }
+
+    // 在适配器外包装搜索结果缓存，SEARCH_CACHE_TTL 为 0 时禁用
+    cacheTTL, err := time.ParseDuration(utils.Getenv(config.SearchCacheTTLEnv, "5m"))
+    if err != nil {
+        log.Printf("[backend] %s 无效，使用默认值: %v", config.SearchCacheTTLEnv, err)
+        cacheTTL = cache.DefaultTTL
+    }
+    if cacheTTL > 0 {
+        cacheSize, _ := strconv.Atoi(utils.Getenv(config.SearchCacheSizeEnv, "500"))
+        cacheFile := utils.Getenv(config.SearchCacheFileEnv, "")
+        if cacheFile != "" {
+            cacheFile = utils.ResolvePath(cacheFile)
+        }
+        cacheStore, err := cache.NewStore(cacheTTL, cacheSize, cacheFile)
+        if err != nil {
+            log.Printf("[backend] 搜索缓存文件无法加载，已忽略: %v", err)
+            cacheStore, _ = cache.NewStore(cacheTTL, cacheSize, "")
+        }
+        for _, id := range reg.IDs() {
+            adapter, _ := reg.Get(id)
+            reg.Register(cache.Wrap(adapter, cacheStore))
+        }
+        log.Printf("[backend] 搜索结果缓存已启用，有效期 %s", cacheTTL)
     }
 
     historyStore, err := history.NewStore(historyFilePath, history.DefaultHistoryLimit, history.DefaultResultsPerEntry)
@@ -189,9 +284,38 @@ func main() {
     }
     log.Printf("[backend] 集合存储已初始化: %s", collectionsDir)
 
+    // 本地索引适配器直接读取内存索引，注册在合并和缓存之后以免返回过期结果
+    localAdapter, err := localindex.NewAdapter(collStore, historyStore)
+    if err != nil {
+        log.Printf("[backend] 本地索引部分条目加载失败: %v", err)
+    }
+    reg.Register(localAdapter)
+    log.Printf("[backend] 本地索引已建立，共 %d 条", localAdapter.Len())
+
+    // 配置熔断策略
+    circuitThreshold, _ := strconv.Atoi(utils.Getenv(config.CircuitThresholdEnv, "0"))
+    circuitCooldown, _ := time.ParseDuration(utils.Getenv(config.CircuitCooldownEnv, "0s"))
+    reg.SetBreakerPolicy(circuitThreshold, circuitCooldown)
+
+    // 配置默认适配器和备用适配器链（FALLBACK_ADAPTER 支持逗号分隔的有序列表）
+    if err := reg.Configure(defaultAdapter, utils.SplitList(fallbackAdapter)...); err != nil {
+        log.Printf("[backend] 适配器配置问题: %v", err)
+    }
+
+    // 通过管理接口在运行时修改的设置保存在单独的文件中，启动时覆盖环境变量中的配置
+    if err := reg.UseSettingsFile(adapterSettingsPath); err != nil {
+        log.Printf("[backend] 适配器运行时设置加载问题: %v", err)
+    }
+
     // 创建 API 服务
     api := service.New(reg, historyStore, collStore)
 
+    // 通过 ut_metadata 获取的种子元数据缓存在磁盘上，info 字典不会变化，缓存不过期
+    metadataCacheDir := utils.ResolvePath(utils.Getenv(config.MetadataCacheDirEnv, "data/metadata"))
+    if err := api.UseMetadataCache(metadataCacheDir); err != nil {
+        log.Printf("[backend] 元数据缓存目录不可用，仅缓存在内存: %v", err)
+    }
+
     // 从嵌入的文件系统中提取前端内容
     frontendContent, err := fs.Sub(frontendFS, "frontend")
     if err != nil {
@@ -204,6 +328,12 @@ func main() {
     // 将 API 路由挂载到 /api/ 路径
     mux.Handle("/api/", passwordMiddleware(password, api.Routes()))
 
+    // Torznab 索引器接口使用独立的 API Key 认证，不经过密码中间件
+    mux.Handle("/api/torznab", api.TorznabHandler(torznabAPIKey))
+    if torznabAPIKey == "" {
+        log.Printf("[backend] 未设置 %s，Torznab 接口已禁用", config.TorznabAPIKeyEnv)
+    }
+
     // 静态文件服务（所有其他请求）
     mux.Handle("/", passwordMiddleware(password, http.FileServer(http.FS(frontendContent))))
 
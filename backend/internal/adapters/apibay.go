@@ -17,62 +17,68 @@ import (
 
 // APIBay 实现通过 apibay.org 进行搜索的适配器
 type APIBay struct {
-    endpoint string
-    headers  http.Header
-    client   *http.Client
+    upstream
+    httpTransport
     trackers []string
 }
 
 // NewAPIBay 创建一个新的 APIBay 适配器
 func NewAPIBay(endpoint string, trackers []string) models.Adapter {
     return &APIBay{
-        endpoint: endpoint,
-        headers: http.Header{
-            "User-Agent": []string{"magnetsearch-backend/1.0"},
-        },
-        client:   &http.Client{Timeout: 8 * time.Second},
-        trackers: append([]string(nil), trackers...),
+        upstream:      upstream{mirrors: splitMirrors(endpoint)},
+        httpTransport: newHTTPTransport(8 * time.Second),
+        trackers:      append([]string(nil), trackers...),
     }
 }
 
 func (a *APIBay) ID() string          { return "apibay" }
 func (a *APIBay) Name() string        { return "The Pirate Bay (apibay.org)" }
 func (a *APIBay) Description() string { return "通过 apibay.org 提供的公开 API 检索资源" }
-func (a *APIBay) Endpoint() string    { return a.endpoint }
+
+// Capabilities 声明适配器支持的功能
+func (a *APIBay) Capabilities() models.Capabilities {
+    // apibay 一次返回全部匹配结果（最多 100 条），不支持分页和排序
+    return models.Capabilities{
+        PageSize:   100,
+        Categories: sortedKeys(apibayCategories),
+    }
+}
+
+// SupportsCategory 报告能否按分类过滤，支持通用分类和 apibay 的数字分类代码
+func (a *APIBay) SupportsCategory(category string) bool {
+    return supportsCategory(category, apibayCategories, numericCategoryPattern)
+}
 
 // Search 执行搜索
 func (a *APIBay) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
     return a.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
 }
 
-// SearchWithOptions 执行搜索，支持分页
+// SearchWithOptions 执行搜索，支持分页和分类；apibay 不支持排序和过滤，由服务层处理排序
 func (a *APIBay) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
-    u, err := url.Parse(a.endpoint)
-    if err != nil {
-        return nil, fmt.Errorf("invalid apibay endpoint: %w", err)
-    }
+    page, err := a.SearchPage(ctx, options)
+    return page.Results, err
+}
 
-    q := u.Query()
+// SearchPage 执行搜索并返回结果页，apibay 不报告总数，结果页只记录所用的镜像地址
+func (a *APIBay) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+    q := url.Values{}
     q.Set("q", options.Query)
     // APIBay doesn't support pagination, but we'll pass the page parameter anyway
     q.Set("page", strconv.Itoa(options.Page))
-    u.RawQuery = q.Encode()
-
-    req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-    if err != nil {
-        return nil, err
+    if category := translateCategory(options.Category, apibayCategories, numericCategoryPattern); category != "" {
+        q.Set("cat", category)
     }
-    req.Header = a.headers.Clone()
 
-    resp, err := a.client.Do(req)
+    resp, endpoint, err := a.get(ctx, &a.upstream, q)
     if err != nil {
-        return nil, err
+        return models.ResultPage{}, err
     }
     defer resp.Body.Close()
 
     if resp.StatusCode != http.StatusOK {
         body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-        return nil, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+        return models.ResultPage{}, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
     }
 
     var payload []struct {
@@ -86,7 +92,7 @@ func (a *APIBay) SearchWithOptions(ctx context.Context, options models.SearchOpt
     }
 
     if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-        return nil, err
+        return models.ResultPage{}, err
     }
 
     results := make([]models.SearchResult, 0, len(payload))
@@ -96,7 +102,7 @@ func (a *APIBay) SearchWithOptions(ctx context.Context, options models.SearchOpt
             continue
         }
 
-        magnet := utils.BuildMagnetLink(item.InfoHash, item.Name, a.trackers)
+        magnet := buildMagnetLink(item.InfoHash, item.Name, a.trackers)
 
         var seedersPtr *int
         if seeders, err := strconv.Atoi(item.Seeders); err == nil {
@@ -143,6 +149,6 @@ func (a *APIBay) SearchWithOptions(ctx context.Context, options models.SearchOpt
         })
     }
 
-    return results, nil
+    return models.ResultPage{Results: results, Endpoint: endpoint}, nil
 }
 
@@ -0,0 +1,457 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/selector"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+// HTMLScraperRules 描述通用 HTML 抓取适配器的配置
+// Query 中的值支持 {query}、{page}、{category}、{sort}、{order} 和 {filter} 占位符，
+// 替换后为空的参数不会发送。Categories、Sorts、Filters 将通用选项映射为站点参数，
+// 未映射的分类原样传递，未映射的排序和过滤器按空值处理。PageSize 为站点每页结果数，
+// Pagination 描述如何从页面中读取总页数和总结果数。Mirrors 是 Endpoint 不可用时按顺序尝试的镜像地址
+type HTMLScraperRules struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Endpoint    string              `json:"endpoint"`
+	Mirrors     []string            `json:"mirrors,omitempty"`
+	Query       map[string]string   `json:"query"`
+	Categories  map[string]string   `json:"categories,omitempty"`
+	Sorts       map[string]string   `json:"sorts,omitempty"`
+	Filters     map[string]string   `json:"filters,omitempty"`
+	PageSize    int                 `json:"pageSize,omitempty"`
+	Pagination  HTMLPaginationRules `json:"pagination,omitempty"`
+	Row         string              `json:"row"`
+	Fields      HTMLFieldRules      `json:"fields"`
+	DateLayouts []string            `json:"dateLayouts,omitempty"`
+}
+
+// HTMLPaginationRules 描述分页信息的提取规则，规则相对于整个文档
+// Pages 匹配的所有节点中最大的数字作为总页数（适用于分页栏），Total 提取总结果数
+type HTMLPaginationRules struct {
+	Pages HTMLFieldRule `json:"pages"`
+	Total HTMLFieldRule `json:"total"`
+}
+
+// HTMLFieldRules 描述每个结果字段在行内的提取规则
+type HTMLFieldRules struct {
+	Title    HTMLFieldRule `json:"title"`
+	Magnet   HTMLFieldRule `json:"magnet"`
+	InfoHash HTMLFieldRule `json:"infoHash"`
+	Size     HTMLFieldRule `json:"size"`
+	Seeders  HTMLFieldRule `json:"seeders"`
+	Leechers HTMLFieldRule `json:"leechers"`
+	Date     HTMLFieldRule `json:"date"`
+	Category HTMLFieldRule `json:"category"`
+}
+
+// HTMLFieldRule 描述单个字段的提取方式
+// Selector 为空时使用行节点本身；Attr 为空或属性值为空时取节点文本；Regex 有捕获组时取第一个捕获组
+type HTMLFieldRule struct {
+	Selector string `json:"selector,omitempty"`
+	Attr     string `json:"attr,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+}
+
+// defaultDateLayouts 是日期字段非 Unix 时间戳时尝试的格式
+var defaultDateLayouts = []string{"2006-01-02 15:04", "2006-01-02 15:04:05", time.RFC3339}
+
+// HTMLScraper 根据 CSS 选择器规则解析 HTML 搜索结果页面的通用适配器
+type HTMLScraper struct {
+	rules  HTMLScraperRules
+	row    *selector.Selector
+	fields map[string]*fieldExtractor
+	pages  *fieldExtractor
+	total  *fieldExtractor
+	upstream
+	httpTransport
+	trackers []string
+}
+
+type fieldExtractor struct {
+	sel  *selector.Selector
+	attr string
+	re   *regexp.Regexp
+}
+
+// NewHTMLScraper 根据规则创建一个新的通用 HTML 抓取适配器
+func NewHTMLScraper(rules HTMLScraperRules, trackers []string) (models.Adapter, error) {
+	if rules.ID == "" {
+		return nil, errors.New("html scraper: id is required")
+	}
+	if rules.Endpoint == "" {
+		return nil, fmt.Errorf("html scraper %s: endpoint is required", rules.ID)
+	}
+	if rules.Row == "" {
+		return nil, fmt.Errorf("html scraper %s: row selector is required", rules.ID)
+	}
+
+	row, err := selector.Compile(rules.Row)
+	if err != nil {
+		return nil, fmt.Errorf("html scraper %s: %w", rules.ID, err)
+	}
+
+	fields := make(map[string]*fieldExtractor)
+	for name, rule := range map[string]HTMLFieldRule{
+		"title":    rules.Fields.Title,
+		"magnet":   rules.Fields.Magnet,
+		"infoHash": rules.Fields.InfoHash,
+		"size":     rules.Fields.Size,
+		"seeders":  rules.Fields.Seeders,
+		"leechers": rules.Fields.Leechers,
+		"date":     rules.Fields.Date,
+		"category": rules.Fields.Category,
+	} {
+		extractor, err := compileField(rule)
+		if err != nil {
+			return nil, fmt.Errorf("html scraper %s: field %s: %w", rules.ID, name, err)
+		}
+		if extractor != nil {
+			fields[name] = extractor
+		}
+	}
+
+	if fields["title"] == nil || (fields["magnet"] == nil && fields["infoHash"] == nil) {
+		return nil, fmt.Errorf("html scraper %s: title and magnet or infoHash rules are required", rules.ID)
+	}
+
+	pages, err := compileField(rules.Pagination.Pages)
+	if err != nil {
+		return nil, fmt.Errorf("html scraper %s: pagination pages: %w", rules.ID, err)
+	}
+	total, err := compileField(rules.Pagination.Total)
+	if err != nil {
+		return nil, fmt.Errorf("html scraper %s: pagination total: %w", rules.ID, err)
+	}
+
+	if len(rules.Query) == 0 {
+		rules.Query = map[string]string{"q": "{query}", "p": "{page}"}
+	}
+	if len(rules.DateLayouts) == 0 {
+		rules.DateLayouts = defaultDateLayouts
+	}
+	if rules.Name == "" {
+		rules.Name = rules.ID
+	}
+
+	return &HTMLScraper{
+		rules:         rules,
+		row:           row,
+		fields:        fields,
+		pages:         pages,
+		total:         total,
+		upstream:      upstream{mirrors: trimSlashes(append([]string{rules.Endpoint}, rules.Mirrors...))},
+		httpTransport: newHTTPTransport(15 * time.Second),
+		trackers:      append([]string(nil), trackers...),
+	}, nil
+}
+
+// LoadHTMLScrapers 从目录加载所有 *.json 规则文件并创建适配器
+// 目录不存在时返回空列表；单个文件出错不影响其他文件
+func LoadHTMLScrapers(dir string, trackers []string) ([]models.Adapter, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var scrapers []models.Adapter
+	var errs []error
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var rules HTMLScraperRules
+		if err := json.Unmarshal(data, &rules); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+
+		scraper, err := NewHTMLScraper(rules, trackers)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		scrapers = append(scrapers, scraper)
+	}
+
+	return scrapers, errors.Join(errs...)
+}
+
+func compileField(rule HTMLFieldRule) (*fieldExtractor, error) {
+	if rule.Selector == "" && rule.Attr == "" && rule.Regex == "" {
+		return nil, nil
+	}
+
+	extractor := &fieldExtractor{attr: rule.Attr}
+	if rule.Selector != "" {
+		sel, err := selector.Compile(rule.Selector)
+		if err != nil {
+			return nil, err
+		}
+		extractor.sel = sel
+	}
+	if rule.Regex != "" {
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, err
+		}
+		extractor.re = re
+	}
+	return extractor, nil
+}
+
+func (h *HTMLScraper) ID() string          { return h.rules.ID }
+func (h *HTMLScraper) Name() string        { return h.rules.Name }
+func (h *HTMLScraper) Description() string { return h.rules.Description }
+
+// Capabilities 根据规则声明适配器支持的功能，查询参数中包含 {page} 时视为支持分页
+func (h *HTMLScraper) Capabilities() models.Capabilities {
+	pagination := false
+	for _, value := range h.rules.Query {
+		if strings.Contains(value, "{page}") {
+			pagination = true
+			break
+		}
+	}
+	return models.Capabilities{
+		Pagination: pagination,
+		PageSize:   h.rules.PageSize,
+		Categories: sortedKeys(h.rules.Categories),
+		Sort:       sortedKeys(h.rules.Sorts),
+		Filters:    sortedKeys(h.rules.Filters),
+	}
+}
+
+// SupportsCategory 报告能否按分类过滤：规则中映射了该分类，或查询参数中有原样传递分类的 {category} 占位符
+func (h *HTMLScraper) SupportsCategory(category string) bool {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return true
+	}
+	if _, ok := h.rules.Categories[strings.ToLower(category)]; ok {
+		return true
+	}
+	for _, value := range h.rules.Query {
+		if strings.Contains(value, "{category}") {
+			return true
+		}
+	}
+	return false
+}
+
+// Search 执行搜索
+func (h *HTMLScraper) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return h.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+// SearchWithOptions 执行搜索，支持分页以及规则中配置的分类、排序和过滤参数
+func (h *HTMLScraper) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	page, err := h.SearchPage(ctx, options)
+	return page.Results, err
+}
+
+// SearchPage 执行搜索并返回结果页，配置了分页规则时同时返回总页数和总结果数
+func (h *HTMLScraper) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	category := options.Category
+	if mapped, ok := h.rules.Categories[strings.ToLower(category)]; ok {
+		category = mapped
+	}
+	sort := h.rules.Sorts[options.Sort]
+	order := ""
+	if sort != "" {
+		order = utils.Coalesce(options.Order, models.OrderDesc)
+	}
+
+	replacer := strings.NewReplacer(
+		"{query}", options.Query,
+		"{page}", strconv.Itoa(options.Page),
+		"{category}", category,
+		"{sort}", sort,
+		"{order}", order,
+		"{filter}", h.rules.Filters[options.Filter],
+	)
+	q := url.Values{}
+	for key, value := range h.rules.Query {
+		if value = replacer.Replace(value); value != "" {
+			q.Set(key, value)
+		}
+	}
+
+	resp, endpoint, err := h.get(ctx, &h.upstream, q)
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return models.ResultPage{}, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+	}
+
+	page, err := h.parsePage(resp.Body)
+	page.Endpoint = endpoint
+	return page, err
+}
+
+// parsePage 按规则解析 HTML 页面，提取结果和分页信息
+func (h *HTMLScraper) parsePage(r io.Reader) (models.ResultPage, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+
+	page := models.ResultPage{Results: h.rows(doc)}
+	if h.pages != nil {
+		nodes := []*html.Node{doc}
+		if h.pages.sel != nil {
+			nodes = h.pages.sel.MatchAll(doc)
+		}
+		for _, node := range nodes {
+			if n, err := strconv.Atoi(h.pages.value(node)); err == nil && n > page.TotalPages {
+				page.TotalPages = n
+			}
+		}
+	}
+	if h.total != nil {
+		page.Total, _ = strconv.Atoi(h.total.extract(doc))
+	}
+	if page.TotalPages == 0 && page.Total > 0 && h.rules.PageSize > 0 {
+		page.TotalPages = (page.Total + h.rules.PageSize - 1) / h.rules.PageSize
+	}
+
+	return page, nil
+}
+
+// rows 提取文档中所有结果行
+func (h *HTMLScraper) rows(doc *html.Node) []models.SearchResult {
+	var results []models.SearchResult
+	for _, row := range h.row.MatchAll(doc) {
+		result := models.SearchResult{
+			Title: h.extract(row, "title"),
+		}
+
+		if magnet := h.extract(row, "magnet"); strings.HasPrefix(magnet, "magnet:") {
+			result.Magnet = magnet
+			result.InfoHash = extractInfoHashFromMagnet(magnet)
+		}
+		if result.InfoHash == "" {
+			result.InfoHash = strings.ToUpper(h.extract(row, "infoHash"))
+		}
+		if result.Magnet == "" && result.InfoHash != "" {
+			result.Magnet = buildMagnetLink(result.InfoHash, result.Title, h.trackers)
+		}
+		if result.Magnet == "" || result.Title == "" {
+			continue
+		}
+
+		if size := h.extract(row, "size"); size != "" {
+			result.SizeLabel = size
+			if sizeBytes := parseSizeString(size); sizeBytes > 0 {
+				result.Size = utils.PtrInt64(sizeBytes)
+			}
+		}
+		if seeders, err := strconv.Atoi(h.extract(row, "seeders")); err == nil {
+			result.Seeders = utils.PtrInt(seeders)
+		}
+		if leechers, err := strconv.Atoi(h.extract(row, "leechers")); err == nil {
+			result.Leechers = utils.PtrInt(leechers)
+		}
+		result.Uploaded = h.parseDate(h.extract(row, "date"))
+
+		result.Category = h.extract(row, "category")
+		if result.Category == "" {
+			result.Category = "未知"
+		}
+		result.Trackers = append([]string(nil), h.trackers...)
+		result.Source = h.ID()
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// extract 从行节点中提取字段值
+func (h *HTMLScraper) extract(row *html.Node, field string) string {
+	extractor, ok := h.fields[field]
+	if !ok {
+		return ""
+	}
+	return extractor.extract(row)
+}
+
+// extract 在 root 内按选择器找到第一个节点并提取值，没有选择器时使用 root 本身
+func (e *fieldExtractor) extract(root *html.Node) string {
+	node := root
+	if e.sel != nil {
+		if node = e.sel.MatchFirst(root); node == nil {
+			return ""
+		}
+	}
+	return e.value(node)
+}
+
+// value 从节点的属性或文本中取值，并应用正则表达式
+func (e *fieldExtractor) value(node *html.Node) string {
+	var value string
+	if e.attr != "" {
+		value, _ = selector.Attr(node, e.attr)
+	}
+	if value == "" {
+		value = selector.Text(node)
+	}
+
+	if e.re != nil {
+		match := e.re.FindStringSubmatch(value)
+		switch {
+		case match == nil:
+			return ""
+		case len(match) > 1:
+			value = match[1]
+		default:
+			value = match[0]
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// parseDate 解析 Unix 时间戳或配置的日期格式
+func (h *HTMLScraper) parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil && ts > 0 {
+		t := time.Unix(ts, 0).UTC()
+		return &t
+	}
+	for _, layout := range h.rules.DateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
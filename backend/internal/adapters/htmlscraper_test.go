@@ -0,0 +1,93 @@
+package adapters
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHTMLScraperMatchesHTMLSukebei(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "sukebei_search.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trackers := []string{"udp://tracker.example:1337/announce"}
+	scrapers, err := LoadHTMLScrapers(filepath.Join("..", "..", "..", "data", "scrapers"), trackers)
+	if err != nil {
+		t.Fatalf("load scrapers: %v", err)
+	}
+
+	var scraper *HTMLScraper
+	for _, s := range scrapers {
+		if s.ID() == "sukebei-rules" {
+			scraper = s.(*HTMLScraper)
+		}
+	}
+	if scraper == nil {
+		t.Fatal("sukebei-rules scraper not found")
+	}
+
+	want, err := parseHTMLSukebei(bytes.NewReader(page), trackers, scraper.ID())
+	if err != nil {
+		t.Fatalf("parseHTMLSukebei: %v", err)
+	}
+	result, err := scraper.parsePage(bytes.NewReader(page))
+	if err != nil {
+		t.Fatalf("scraper.parsePage: %v", err)
+	}
+
+	// trusted（success）和 remake（danger）行与普通行一样返回，没有磁力链接的行被跳过
+	var titles []string
+	for _, result := range want {
+		titles = append(titles, result.Title)
+	}
+	if wantTitles := []string{"[Group] Example Show - 01 [1080p]", "Trusted Upload", "Remake Upload", "Another Release v1.02"}; !reflect.DeepEqual(titles, wantTitles) {
+		t.Fatalf("reference titles = %q, want %q", titles, wantTitles)
+	}
+	if !reflect.DeepEqual(result.Results, want) {
+		t.Errorf("rule-based results differ from HTMLSukebei\n got: %+v\nwant: %+v", result.Results, want)
+	}
+
+	total, totalPages := parseNyaaPagination(bytes.NewReader(page))
+	if total != 1000 || totalPages != 14 {
+		t.Fatalf("parseNyaaPagination = %d, %d, want 1000, 14", total, totalPages)
+	}
+	if result.Total != total || result.TotalPages != totalPages {
+		t.Errorf("rule-based pagination = %d, %d, want %d, %d", result.Total, result.TotalPages, total, totalPages)
+	}
+}
+
+func TestHTMLScraperInfoHashField(t *testing.T) {
+	scraper, err := NewHTMLScraper(HTMLScraperRules{
+		ID:       "hash-only",
+		Endpoint: "https://example.invalid/search",
+		Row:      "li.item",
+		Fields: HTMLFieldRules{
+			Title:    HTMLFieldRule{Selector: "span.name"},
+			InfoHash: HTMLFieldRule{Attr: "data-link", Regex: `/hash/([0-9a-fA-F]{40})`},
+			Seeders:  HTMLFieldRule{Selector: "span.stats", Regex: `S:(\d+)`},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := `<ul><li class="item" data-link="/hash/f257af31a6204cd734d2baecb8331637850b7b44"><span class="name">Example</span><span class="stats">S:12 L:3</span></li></ul>`
+	parsed, err := scraper.(*HTMLScraper).parsePage(bytes.NewReader([]byte(page)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(parsed.Results))
+	}
+	result := parsed.Results[0]
+	if result.InfoHash != "F257AF31A6204CD734D2BAECB8331637850B7B44" || result.Magnet == "" {
+		t.Errorf("info hash / magnet not built: %+v", result)
+	}
+	if result.Seeders == nil || *result.Seeders != 12 {
+		t.Errorf("seeders = %v, want 12", result.Seeders)
+	}
+}
@@ -1,11 +1,13 @@
 package adapters
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -13,71 +15,125 @@ import (
 	"golang.org/x/net/html"
 
 	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/selector"
 	"github.com/seedmanage/backend/internal/utils"
 )
 
 // HTMLSukebei 实现通过解析 sukebei.nyaa.si 的 HTML 页面进行搜索的适配器
 type HTMLSukebei struct {
-	endpoint string
-	headers  http.Header
-	client   *http.Client
+	upstream
+	httpTransport
 	trackers []string
 }
 
 // NewHTMLSukebei 创建一个新的 HTMLSukebei 适配器
 func NewHTMLSukebei(endpoint string, trackers []string) models.Adapter {
 	return &HTMLSukebei{
-		endpoint: strings.TrimRight(endpoint, "/"),
-		headers: http.Header{
-			"User-Agent": []string{"magnetsearch-backend/1.0"},
-		},
-		client:   &http.Client{Timeout: 15 * time.Second},
-		trackers: append([]string(nil), trackers...),
+		upstream:      upstream{mirrors: trimSlashes(splitMirrors(endpoint))},
+		httpTransport: newHTTPTransport(15 * time.Second),
+		trackers:      append([]string(nil), trackers...),
 	}
 }
 
 func (h *HTMLSukebei) ID() string          { return "htmlsukebei" }
 func (h *HTMLSukebei) Name() string        { return "HTML Sukebei" }
 func (h *HTMLSukebei) Description() string { return "通过解析 sukebei.nyaa.si 的 HTML 页面检索资源" }
-func (h *HTMLSukebei) Endpoint() string    { return h.endpoint }
+
+// Capabilities 声明适配器支持的功能
+func (h *HTMLSukebei) Capabilities() models.Capabilities {
+	return models.Capabilities{
+		Pagination: true,
+		PageSize:   nyaaPageSize,
+		Categories: sortedKeys(sukebeiSiteCategories),
+		Sort:       nyaaSorts,
+		Filters:    nyaaFilters,
+	}
+}
+
+// SupportsCategory 报告能否按分类过滤，支持通用分类和站点的分类代码（如 "1_2"）
+func (h *HTMLSukebei) SupportsCategory(category string) bool {
+	return supportsCategory(category, sukebeiSiteCategories, nyaaCategoryPattern)
+}
 
 // Search 执行搜索
 func (h *HTMLSukebei) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
 	return h.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
 }
 
-// SearchWithOptions 执行搜索，支持分页
+// SearchWithOptions 执行搜索，支持分页、分类、排序和过滤
 func (h *HTMLSukebei) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
-	u, err := url.Parse(h.endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("invalid htmlsukebei endpoint: %w", err)
-	}
+	page, err := h.SearchPage(ctx, options)
+	return page.Results, err
+}
 
-	q := u.Query()
-	q.Set("f", "0")
-	q.Set("c", "0_0")
+// SearchPage 执行搜索并返回带总数的结果页，总数来自页面的分页栏和结果统计
+func (h *HTMLSukebei) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	q := url.Values{}
+	q.Set("f", nyaaFilter(options.Filter))
+	q.Set("c", utils.Coalesce(translateCategory(options.Category, sukebeiSiteCategories, nyaaCategoryPattern), "0_0"))
 	q.Set("q", options.Query)
 	q.Set("p", strconv.Itoa(options.Page))
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return nil, err
+	if sort := nyaaSort(options.Sort); sort != "" {
+		q.Set("s", sort)
+		q.Set("o", nyaaOrder(options.Order))
 	}
-	req.Header = h.headers.Clone()
 
-	resp, err := h.client.Do(req)
+	resp, endpoint, err := h.get(ctx, &h.upstream, q)
 	if err != nil {
-		return nil, err
+		return models.ResultPage{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return nil, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+		return models.ResultPage{}, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+
+	results, err := parseHTMLSukebei(bytes.NewReader(body), h.trackers, h.ID())
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+	page := models.ResultPage{Results: results, Endpoint: endpoint}
+	page.Total, page.TotalPages = parseNyaaPagination(bytes.NewReader(body))
+	return page, nil
+}
+
+var (
+	nyaaPageLinks    = selector.MustCompile("ul.pagination li a")
+	nyaaPageInfo     = selector.MustCompile(".pagination-page-info")
+	nyaaTotalPattern = regexp.MustCompile(`out of (\d+) results`)
+)
+
+// parseNyaaPagination 从 nyaa 系站点的页面中提取总结果数和总页数
+// 总页数取分页栏中最大的页码，总结果数来自 "Displaying results 1-75 out of 1000 results" 提示
+func parseNyaaPagination(r io.Reader) (total, totalPages int) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return 0, 0
 	}
 
-	return parseHTMLSukebei(resp.Body, h.trackers, h.ID())
+	for _, link := range nyaaPageLinks.MatchAll(doc) {
+		fields := strings.Fields(selector.Text(link))
+		if len(fields) == 0 {
+			continue
+		}
+		if n, err := strconv.Atoi(fields[0]); err == nil && n > totalPages {
+			totalPages = n
+		}
+	}
+
+	if info := nyaaPageInfo.MatchFirst(doc); info != nil {
+		if match := nyaaTotalPattern.FindStringSubmatch(selector.Text(info)); match != nil {
+			total, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	return total, totalPages
 }
 
 // parseHTMLSukebei 解析 sukebei.nyaa.si 的 HTML 表格并提取结果
@@ -95,6 +151,8 @@ func parseHTMLSukebei(r io.Reader, trackers []string, sourceID string) ([]models
 	state := searchTable
 	cellIndex := -1
 	depth := 0
+	// inBody 标记是否位于结果表格的 tbody 中；普通（default）、trusted（success）和 remake（danger）行都要解析
+	inBody := false
 
 	var results []models.SearchResult
 	var current models.SearchResult
@@ -140,8 +198,10 @@ func parseHTMLSukebei(r io.Reader, trackers []string, sourceID string) ([]models
 				switch tagName {
 				case "table":
 					depth++
+				case "tbody":
+					inBody = depth == 1
 				case "tr":
-					if hasClassAttr(z, "default") {
+					if inBody {
 						state = inRow
 						resetRow()
 					}
@@ -309,6 +369,10 @@ func parseHTMLSukebei(r io.Reader, trackers []string, sourceID string) ([]models
 
 			case inTable:
 				switch tagName {
+				case "tbody":
+					if depth == 1 {
+						inBody = false
+					}
 				case "table":
 					depth--
 					if depth == 0 {
@@ -11,67 +11,78 @@ import (
     "strings"
     "time"
 
+    "github.com/seedmanage/backend/internal/magnet"
     "github.com/seedmanage/backend/internal/models"
     "github.com/seedmanage/backend/internal/utils"
 )
 
 // Nyaa 实现通过 nyaaapi.onrender.com 进行搜索的适配器
 type Nyaa struct {
-    endpoint string
-    headers  http.Header
-    client   *http.Client
+    upstream
+    httpTransport
     trackers []string
 }
 
 // NewNyaa 创建一个新的 Nyaa 适配器
 func NewNyaa(endpoint string, trackers []string) models.Adapter {
     return &Nyaa{
-        endpoint: endpoint,
-        headers: http.Header{
-            "User-Agent": []string{"magnetsearch-backend/1.0"},
-        },
-        client:   &http.Client{Timeout: 10 * time.Second},
-        trackers: append([]string(nil), trackers...),
+        upstream:      upstream{mirrors: splitMirrors(endpoint)},
+        httpTransport: newHTTPTransport(10 * time.Second),
+        trackers:      append([]string(nil), trackers...),
     }
 }
 
 func (n *Nyaa) ID() string          { return "nyaa" }
 func (n *Nyaa) Name() string        { return "Nyaa" }
 func (n *Nyaa) Description() string { return "通过 nyaaapi.onrender.com 提供的 API 检索资源" }
-func (n *Nyaa) Endpoint() string    { return n.endpoint }
+
+// Capabilities 声明适配器支持的功能
+func (n *Nyaa) Capabilities() models.Capabilities {
+    return models.Capabilities{
+        Pagination: true,
+        PageSize:   nyaaPageSize,
+        Categories: sortedKeys(nyaaAPICategories),
+        Sort:       nyaaSorts,
+        Filters:    nyaaFilters,
+    }
+}
 
 // Search 执行搜索
 func (n *Nyaa) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
     return n.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
 }
 
-// SearchWithOptions 执行搜索，支持分页
+// SearchWithOptions 执行搜索，支持分页、分类、排序和过滤
 func (n *Nyaa) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
-    u, err := url.Parse(n.endpoint)
-    if err != nil {
-        return nil, fmt.Errorf("invalid nyaa endpoint: %w", err)
-    }
+    page, err := n.SearchPage(ctx, options)
+    return page.Results, err
+}
 
-    q := u.Query()
+// SearchPage 执行搜索并返回带总数的结果页，总数来自 API 响应中的 count 字段
+func (n *Nyaa) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+    q := url.Values{}
     q.Set("q", options.Query)
     q.Set("page", strconv.Itoa(options.Page))
-    u.RawQuery = q.Encode()
-
-    req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-    if err != nil {
-        return nil, err
+    if category := translateCategory(options.Category, nyaaAPICategories, nil); category != "" {
+        q.Set("category", category)
+    }
+    if sort := nyaaSort(options.Sort); sort != "" {
+        q.Set("sort", sort)
+        q.Set("order", nyaaOrder(options.Order))
+    }
+    if options.Filter != models.FilterNone {
+        q.Set("filter", nyaaFilter(options.Filter))
     }
-    req.Header = n.headers.Clone()
 
-    resp, err := n.client.Do(req)
+    resp, endpoint, err := n.get(ctx, &n.upstream, q)
     if err != nil {
-        return nil, err
+        return models.ResultPage{}, err
     }
     defer resp.Body.Close()
 
     if resp.StatusCode != http.StatusOK {
         body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-        return nil, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+        return models.ResultPage{}, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
     }
 
     var response struct {
@@ -88,7 +99,7 @@ func (n *Nyaa) SearchWithOptions(ctx context.Context, options models.SearchOptio
     }
 
     if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-        return nil, err
+        return models.ResultPage{}, err
     }
 
     payload := response.Data
@@ -150,25 +161,22 @@ func (n *Nyaa) SearchWithOptions(ctx context.Context, options models.SearchOptio
         })
     }
 
-    return results, nil
+    page := models.ResultPage{Results: results, Total: response.Count, Endpoint: endpoint}
+    if response.Count > 0 {
+        page.TotalPages = (response.Count + nyaaPageSize - 1) / nyaaPageSize
+    }
+    return page, nil
 }
 
+// extractInfoHashFromMagnet 返回磁力链接中规范化的 info hash，没有有效的 btih/btmh 时返回空字符串
+// 只看 xt 参数，xl、so 等无关参数格式错误时仍然能取到 hash
 func extractInfoHashFromMagnet(magnetLink string) string {
-    if !strings.HasPrefix(magnetLink, "magnet:?") {
-        return ""
-    }
+    return magnet.InfoHash(magnetLink)
+}
 
-    // Split by '&' and look for the xt=urn:btih: parameter
-    // Note: the first part will be like "magnet:?xt=urn:btih:...", so we use IndexOf instead of HasPrefix
-    parts := strings.Split(magnetLink, "&")
-    for _, part := range parts {
-        idx := strings.Index(part, "xt=urn:btih:")
-        if idx >= 0 {
-            hash := part[idx+len("xt=urn:btih:"):]
-            return strings.ToUpper(hash)
-        }
-    }
-    return ""
+// buildMagnetLink 用 info hash、标题和 tracker 列表构建磁力链接
+func buildMagnetLink(infoHash, title string, trackers []string) string {
+    return utils.BuildMagnetLink(magnet.Magnet{InfoHash: infoHash, Name: title, Trackers: trackers})
 }
 
 // parseSizeString 解析大小字符串（如 "2.0 GiB", "704.9 MiB"）到字节数
@@ -1,7 +1,12 @@
 package adapters
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/seedmanage/backend/internal/models"
 )
 
 func TestParseSizeString(t *testing.T) {
@@ -41,6 +46,16 @@ func TestExtractInfoHashFromMagnet(t *testing.T) {
 			magnet:   "magnet:?xt=urn:btih:f257af31a6204cd734d2baecb8331637850b7b44&dn=test",
 			expected: "F257AF31A6204CD734D2BAECB8331637850B7B44",
 		},
+		{
+			name:     "base32 btih after other params",
+			magnet:   "magnet:?dn=test&xt=urn:btih:6JL26MNGEBGNONGSXLWLQMYWG6CQW62E",
+			expected: "F257AF31A6204CD734D2BAECB8331637850B7B44",
+		},
+		{
+			name:     "malformed unrelated params",
+			magnet:   "magnet:?xt=urn:btih:f257af31a6204cd734d2baecb8331637850b7b44&xl=-1&so=a",
+			expected: "F257AF31A6204CD734D2BAECB8331637850B7B44",
+		},
 		{
 			name:     "empty string",
 			magnet:   "",
@@ -62,3 +77,19 @@ func TestExtractInfoHashFromMagnet(t *testing.T) {
 		})
 	}
 }
+
+func TestNyaaSearchPageCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 160, "data": [{"title": "Example", "magnet": "magnet:?xt=urn:btih:0123456789ABCDEF0123456789ABCDEF01234567", "seeders": 3, "leechers": 1, "size": "1.0 GiB", "category": "Anime"}]}`))
+	}))
+	defer server.Close()
+
+	page, err := NewNyaa(server.URL, nil).(*Nyaa).SearchPage(context.Background(), models.SearchOptions{Query: "example", Page: 1})
+	if err != nil {
+		t.Fatalf("search page: %v", err)
+	}
+	if len(page.Results) != 1 || page.Total != 160 || page.TotalPages != 3 {
+		t.Errorf("page = %d results, total %d, pages %d; want 1, 160, 3", len(page.Results), page.Total, page.TotalPages)
+	}
+}
@@ -0,0 +1,194 @@
+package adapters
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+// nyaaCategoryPattern 匹配 Nyaa 分类参数，例如 1_2、0_0
+var nyaaCategoryPattern = regexp.MustCompile(`^\d+_\d+$`)
+
+// nyaaPageSize 是 nyaa 系站点每页（以及 RSS 每次）返回的结果数
+const nyaaPageSize = 75
+
+// Nyaa 过滤器取值
+const (
+	NyaaFilterNone      = "0"
+	NyaaFilterNoRemakes = "1"
+	NyaaFilterTrusted   = "2"
+)
+
+// NyaaRSS 实现直接读取 nyaa.si / sukebei.nyaa.si RSS 订阅的适配器
+type NyaaRSS struct {
+	id          string
+	name        string
+	description string
+	upstream
+	categories map[string]string
+	httpTransport
+	trackers []string
+}
+
+// NewNyaaRSS 创建读取 nyaa.si RSS 的适配器
+func NewNyaaRSS(endpoint string, trackers []string) models.Adapter {
+	return newNyaaRSS("nyaa-rss", "Nyaa RSS", "通过 nyaa.si 的 RSS 订阅检索资源", endpoint, nyaaSiteCategories, trackers)
+}
+
+// NewSukebeiRSS 创建读取 sukebei.nyaa.si RSS 的适配器
+func NewSukebeiRSS(endpoint string, trackers []string) models.Adapter {
+	return newNyaaRSS("sukebei-rss", "Sukebei RSS", "通过 sukebei.nyaa.si 的 RSS 订阅检索资源", endpoint, sukebeiSiteCategories, trackers)
+}
+
+func newNyaaRSS(id, name, description, endpoint string, categories map[string]string, trackers []string) *NyaaRSS {
+	return &NyaaRSS{
+		id:            id,
+		name:          name,
+		description:   description,
+		upstream:      upstream{mirrors: splitMirrors(endpoint)},
+		categories:    categories,
+		httpTransport: newHTTPTransport(15 * time.Second),
+		trackers:      append([]string(nil), trackers...),
+	}
+}
+
+func (n *NyaaRSS) ID() string          { return n.id }
+func (n *NyaaRSS) Name() string        { return n.name }
+func (n *NyaaRSS) Description() string { return n.description }
+
+// Capabilities 声明适配器支持的功能
+func (n *NyaaRSS) Capabilities() models.Capabilities {
+	// RSS 订阅和网页一样支持 p 参数翻页，但不报告总数，返回满一页时认为还有下一页
+	return models.Capabilities{
+		Pagination: true,
+		PageSize:   nyaaPageSize,
+		Categories: sortedKeys(n.categories),
+		Sort:       nyaaSorts,
+		Filters:    nyaaFilters,
+	}
+}
+
+// SupportsCategory 报告能否按分类过滤，支持通用分类和站点的分类代码（如 "1_2"）
+func (n *NyaaRSS) SupportsCategory(category string) bool {
+	return supportsCategory(category, n.categories, nyaaCategoryPattern)
+}
+
+// Search 执行搜索
+func (n *NyaaRSS) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return n.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+// SearchWithOptions 执行搜索，支持分页、分类、排序和过滤
+func (n *NyaaRSS) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	page, err := n.SearchPage(ctx, options)
+	return page.Results, err
+}
+
+// SearchPage 执行搜索并返回结果页，RSS 不报告总数，结果页只记录所用的镜像地址
+func (n *NyaaRSS) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	q := url.Values{}
+	q.Set("page", "rss")
+	q.Set("q", options.Query)
+	if category := translateCategory(options.Category, n.categories, nyaaCategoryPattern); category != "" {
+		q.Set("c", category)
+	}
+	if options.Filter != models.FilterNone {
+		q.Set("f", nyaaFilter(options.Filter))
+	}
+	if sort := nyaaSort(options.Sort); sort != "" {
+		q.Set("s", sort)
+		q.Set("o", nyaaOrder(options.Order))
+	}
+	if options.Page > 1 {
+		q.Set("p", strconv.Itoa(options.Page))
+	}
+
+	resp, endpoint, err := n.get(ctx, &n.upstream, q)
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return models.ResultPage{}, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+	}
+
+	results, err := n.parse(resp.Body)
+	return models.ResultPage{Results: results, Endpoint: endpoint}, err
+}
+
+// nyaaRSSItem 对应 RSS 中的 item，nyaa:* 扩展元素按本地名称匹配，
+// 因为 nyaa 和 sukebei 声明的命名空间 URI 不同
+type nyaaRSSItem struct {
+	Title      string `xml:"title"`
+	Link       string `xml:"link"`
+	GUID       string `xml:"guid"`
+	PubDate    string `xml:"pubDate"`
+	Seeders    string `xml:"seeders"`
+	Leechers   string `xml:"leechers"`
+	Downloads  string `xml:"downloads"`
+	InfoHash   string `xml:"infoHash"`
+	CategoryID string `xml:"categoryId"`
+	Category   string `xml:"category"`
+	Size       string `xml:"size"`
+}
+
+// parse 解析 RSS 文档并转换为搜索结果
+func (n *NyaaRSS) parse(r io.Reader) ([]models.SearchResult, error) {
+	var feed struct {
+		Items []nyaaRSSItem `xml:"channel>item"`
+	}
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("invalid %s feed: %w", n.id, err)
+	}
+
+	results := make([]models.SearchResult, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		title := strings.TrimSpace(item.Title)
+		infoHash := utils.NormalizeInfoHash(strings.TrimSpace(item.InfoHash))
+		if title == "" || infoHash == "" {
+			continue
+		}
+
+		result := models.SearchResult{
+			Title:    title,
+			Magnet:   buildMagnetLink(infoHash, title, n.trackers),
+			InfoHash: infoHash,
+			Trackers: append([]string(nil), n.trackers...),
+			Category: utils.Coalesce(strings.TrimSpace(item.Category), "未知"),
+			Source:   n.ID(),
+		}
+
+		if seeders, err := strconv.Atoi(strings.TrimSpace(item.Seeders)); err == nil {
+			result.Seeders = utils.PtrInt(seeders)
+		}
+		if leechers, err := strconv.Atoi(strings.TrimSpace(item.Leechers)); err == nil {
+			result.Leechers = utils.PtrInt(leechers)
+		}
+		if size := strings.TrimSpace(item.Size); size != "" {
+			result.SizeLabel = size
+			if sizeBytes := parseSizeString(size); sizeBytes > 0 {
+				result.Size = utils.PtrInt64(sizeBytes)
+			}
+		}
+		if t, err := time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate)); err == nil {
+			uploaded := t.UTC()
+			result.Uploaded = &uploaded
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
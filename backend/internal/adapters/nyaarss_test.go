@@ -0,0 +1,132 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+func newRSSFixtureServer(t *testing.T, fixture string, check func(r *http.Request)) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			check(r)
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNyaaRSSSearch(t *testing.T) {
+	server := newRSSFixtureServer(t, "nyaa_rss.xml", func(r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("page") != "rss" || q.Get("q") != "frieren" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+	})
+
+	adapter := NewNyaaRSS(server.URL+"/", []string{"udp://tracker.example:1337/announce"})
+	results, err := adapter.Search(context.Background(), "frieren")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2 (entries without info hash are skipped)", len(results))
+	}
+
+	first := results[0]
+	if first.InfoHash != "9C3F1B3A6F0E2D4C5B6A7980AABBCCDDEEFF0011" {
+		t.Errorf("info hash = %q", first.InfoHash)
+	}
+	wantMagnet := "magnet:?xt=urn:btih:9C3F1B3A6F0E2D4C5B6A7980AABBCCDDEEFF0011&dn=%5BSubsPlease%5D+Sousou+no+Frieren+-+28+%281080p%29+%5BA1B2C3D4%5D.mkv&tr=udp%3A%2F%2Ftracker.example%3A1337%2Fannounce"
+	if first.Magnet != wantMagnet {
+		t.Errorf("magnet = %q", first.Magnet)
+	}
+	if first.Seeders == nil || *first.Seeders != 1523 || first.Leechers == nil || *first.Leechers != 87 {
+		t.Errorf("seeders/leechers = %v/%v", first.Seeders, first.Leechers)
+	}
+	if first.SizeLabel != "1.4 GiB" || first.Size == nil || *first.Size != parseSizeString("1.4 GiB") {
+		t.Errorf("size = %q (%v)", first.SizeLabel, first.Size)
+	}
+	if first.Category != "Anime - English-translated" || first.Source != "nyaa-rss" {
+		t.Errorf("category/source = %q/%q", first.Category, first.Source)
+	}
+	if first.Uploaded == nil || !first.Uploaded.Equal(time.Date(2024, 3, 22, 17, 2, 11, 0, time.UTC)) {
+		t.Errorf("uploaded = %v", first.Uploaded)
+	}
+
+	if results[1].Title != "Sousou no Frieren OST & Soundtrack Collection (FLAC)" {
+		t.Errorf("second title = %q", results[1].Title)
+	}
+}
+
+func TestNyaaRSSPagination(t *testing.T) {
+	server := newRSSFixtureServer(t, "nyaa_rss.xml", func(r *http.Request) {
+		if r.URL.Query().Get("p") != "3" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+	})
+
+	adapter := NewNyaaRSS(server.URL, nil).(*NyaaRSS)
+	if caps := adapter.Capabilities(); !caps.Pagination || caps.PageSize != nyaaPageSize {
+		t.Errorf("capabilities = %+v, want pagination with page size %d", caps, nyaaPageSize)
+	}
+	page, err := adapter.SearchPage(context.Background(), models.SearchOptions{Query: "frieren", Page: 3})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(page.Results) == 0 {
+		t.Error("page 3 returned no results")
+	}
+}
+
+func TestNyaaRSSCategoryAndFilter(t *testing.T) {
+	var got url.Values
+	server := newRSSFixtureServer(t, "sukebei_rss.xml", func(r *http.Request) {
+		got = r.URL.Query()
+	})
+
+	adapter := NewSukebeiRSS(server.URL, nil).(*NyaaRSS)
+	page, err := adapter.SearchPage(context.Background(), models.SearchOptions{
+		Query:    "sample",
+		Category: "1_4",
+		Filter:   models.FilterTrusted,
+		Sort:     models.SortSeeders,
+		Page:     2,
+	})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	want := url.Values{"page": {"rss"}, "q": {"sample"}, "c": {"1_4"}, "f": {NyaaFilterTrusted}, "s": {"seeders"}, "o": {"desc"}, "p": {"2"}}
+	if got.Encode() != want.Encode() {
+		t.Errorf("query = %s, want %s", got.Encode(), want.Encode())
+	}
+	results := page.Results
+	if len(results) != 1 || results[0].Source != "sukebei-rss" || results[0].Category != "Art - Pictures" {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[0].InfoHash != "FEDCBA9876543210FEDCBA9876543210FEDCBA98" {
+		t.Errorf("info hash = %q", results[0].InfoHash)
+	}
+
+	// 无法转换的分类不发送 c 参数，第一页不发送 p 参数
+	if _, err := adapter.SearchPage(context.Background(), models.SearchOptions{Query: "x", Category: "unknown", Page: 1}); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if want := (url.Values{"page": {"rss"}, "q": {"x"}}); got.Encode() != want.Encode() {
+		t.Errorf("query = %s, want %s", got.Encode(), want.Encode())
+	}
+}
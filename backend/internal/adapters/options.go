@@ -0,0 +1,145 @@
+package adapters
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// 本文件负责将通用的 models.SearchOptions 转换为各上游站点的原生参数
+// 无法转换的选项返回空字符串，调用方应省略对应参数
+
+var (
+	// numericCategoryPattern 匹配 apibay / Torznab 的数字分类代码（Torznab 支持逗号分隔）
+	numericCategoryPattern = regexp.MustCompile(`^\d+(,\d+)*$`)
+)
+
+// nyaaSiteCategories 和 sukebeiSiteCategories 是 nyaa.si / sukebei.nyaa.si 的分类代码（c 参数）
+var (
+	nyaaSiteCategories = map[string]string{
+		models.CategoryAnime:    "1_0",
+		models.CategoryAudio:    "2_0",
+		models.CategoryBooks:    "3_0",
+		models.CategoryMovies:   "4_0",
+		models.CategoryTV:       "4_0",
+		models.CategoryPictures: "5_0",
+		models.CategorySoftware: "6_1",
+		models.CategoryGames:    "6_2",
+	}
+	sukebeiSiteCategories = map[string]string{
+		models.CategoryAnime:    "1_1",
+		models.CategoryGames:    "1_3",
+		models.CategoryBooks:    "1_4",
+		models.CategoryPictures: "1_5",
+		models.CategoryMovies:   "2_2",
+		models.CategoryTV:       "2_2",
+	}
+)
+
+// nyaaAPICategories 和 sukebeiAPICategories 是 nyaaapi.onrender.com 的分类名称
+var (
+	nyaaAPICategories = map[string]string{
+		models.CategoryAnime:    "anime",
+		models.CategoryAudio:    "audio",
+		models.CategoryBooks:    "literature",
+		models.CategoryMovies:   "live_action",
+		models.CategoryTV:       "live_action",
+		models.CategoryPictures: "pictures",
+		models.CategorySoftware: "software",
+		models.CategoryGames:    "software",
+	}
+	sukebeiAPICategories = map[string]string{
+		models.CategoryAnime:    "art",
+		models.CategoryGames:    "art",
+		models.CategoryBooks:    "art",
+		models.CategoryPictures: "art",
+		models.CategoryMovies:   "real_life",
+		models.CategoryTV:       "real_life",
+	}
+)
+
+// apibayCategories 是 The Pirate Bay 的分类代码
+var apibayCategories = map[string]string{
+	models.CategoryAudio:    "100",
+	models.CategoryMovies:   "201",
+	models.CategoryTV:       "205",
+	models.CategorySoftware: "300",
+	models.CategoryGames:    "400",
+	models.CategoryBooks:    "601",
+	models.CategoryPictures: "603",
+}
+
+// torznabCategories 是标准 Torznab 分类
+var torznabCategories = map[string]string{
+	models.CategoryMovies:   "2000",
+	models.CategoryAudio:    "3000",
+	models.CategorySoftware: "4000",
+	models.CategoryGames:    "4050",
+	models.CategoryTV:       "5000",
+	models.CategoryAnime:    "5070",
+	models.CategoryBooks:    "7000",
+}
+
+// translateCategory 将通用分类转换为上游代码；已经是上游原生格式的代码原样返回
+func translateCategory(category string, table map[string]string, native *regexp.Regexp) string {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return ""
+	}
+	if code, ok := table[strings.ToLower(category)]; ok {
+		return code
+	}
+	if native != nil && native.MatchString(category) {
+		return category
+	}
+	return ""
+}
+
+// supportsCategory 报告分类能否转换为上游代码，空分类总是支持
+func supportsCategory(category string, table map[string]string, native *regexp.Regexp) bool {
+	return strings.TrimSpace(category) == "" || translateCategory(category, table, native) != ""
+}
+
+// nyaaSort 返回 nyaa 站点及 nyaaapi 的排序字段（s 参数）
+func nyaaSort(sort string) string {
+	switch sort {
+	case models.SortSeeders, models.SortLeechers, models.SortSize, models.SortDownloads:
+		return sort
+	case models.SortDate:
+		return "id"
+	}
+	return ""
+}
+
+// nyaaOrder 返回 nyaa 的排序方向（o 参数），未指定时按降序
+func nyaaOrder(order string) string {
+	if order == models.OrderAsc {
+		return models.OrderAsc
+	}
+	return models.OrderDesc
+}
+
+// nyaaFilter 返回 nyaa 的过滤器代码（f 参数）
+func nyaaFilter(filter string) string {
+	switch filter {
+	case models.FilterNoRemakes:
+		return NyaaFilterNoRemakes
+	case models.FilterTrusted:
+		return NyaaFilterTrusted
+	}
+	return NyaaFilterNone
+}
+
+// nyaaSorts 和 nyaaFilters 是 nyaa 系站点支持的通用排序字段和过滤器
+var (
+	nyaaSorts   = []string{models.SortSeeders, models.SortLeechers, models.SortSize, models.SortDate, models.SortDownloads}
+	nyaaFilters = []string{models.FilterNoRemakes, models.FilterTrusted}
+)
+
+// sortedKeys 返回映射表中按字母排序的键，用于声明适配器支持的通用选项
+func sortedKeys(table map[string]string) []string {
+	return slices.Sorted(maps.Keys(table))
+}
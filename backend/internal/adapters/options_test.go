@@ -0,0 +1,86 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+func TestTranslateCategory(t *testing.T) {
+	tests := []struct {
+		category string
+		table    map[string]string
+		native   *regexp.Regexp
+		want     string
+	}{
+		{"anime", nyaaSiteCategories, nyaaCategoryPattern, "1_0"},
+		{"Anime", sukebeiSiteCategories, nyaaCategoryPattern, "1_1"},
+		{"1_2", nyaaSiteCategories, nyaaCategoryPattern, "1_2"},
+		{"audio", sukebeiSiteCategories, nyaaCategoryPattern, ""},
+		{"movies", apibayCategories, numericCategoryPattern, "201"},
+		{"5000,2000", torznabCategories, numericCategoryPattern, "5000,2000"},
+		{"1_2", torznabCategories, numericCategoryPattern, ""},
+		{"", nyaaSiteCategories, nyaaCategoryPattern, ""},
+	}
+	for _, tt := range tests {
+		if got := translateCategory(tt.category, tt.table, tt.native); got != tt.want {
+			t.Errorf("translateCategory(%q) = %q, want %q", tt.category, got, tt.want)
+		}
+		if got := supportsCategory(tt.category, tt.table, tt.native); got != (tt.want != "" || tt.category == "") {
+			t.Errorf("supportsCategory(%q) = %v", tt.category, got)
+		}
+	}
+}
+
+func TestSearchOptionsUpstreamParams(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Write([]byte(`<html><body></body></html>`))
+	}))
+	defer server.Close()
+
+	options := models.SearchOptions{
+		Query:    "test",
+		Page:     2,
+		Category: models.CategoryAnime,
+		Sort:     models.SortDate,
+		Filter:   models.FilterNoRemakes,
+	}
+
+	if _, err := NewHTMLSukebei(server.URL, nil).SearchWithOptions(context.Background(), options); err != nil {
+		t.Fatalf("htmlsukebei: %v", err)
+	}
+	want := url.Values{"q": {"test"}, "p": {"2"}, "c": {"1_1"}, "f": {"1"}, "s": {"id"}, "o": {"desc"}}
+	if got.Encode() != want.Encode() {
+		t.Errorf("htmlsukebei query = %s, want %s", got.Encode(), want.Encode())
+	}
+
+	scraper, err := NewHTMLScraper(HTMLScraperRules{
+		ID:         "rules",
+		Endpoint:   server.URL,
+		Query:      map[string]string{"q": "{query}", "c": "{category}", "s": "{sort}", "o": "{order}", "f": "{filter}"},
+		Categories: map[string]string{"anime": "1_0"},
+		Row:        "tr",
+		Fields: HTMLFieldRules{
+			Title:  HTMLFieldRule{Selector: "a"},
+			Magnet: HTMLFieldRule{Selector: "a", Attr: "href"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scraper.SearchWithOptions(context.Background(), options); err != nil {
+		t.Fatalf("scraper: %v", err)
+	}
+	// 未配置 sorts / filters 映射时对应参数不发送
+	want = url.Values{"q": {"test"}, "c": {"1_0"}}
+	if got.Encode() != want.Encode() {
+		t.Errorf("scraper query = %s, want %s", got.Encode(), want.Encode())
+	}
+}
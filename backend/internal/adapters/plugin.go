@@ -0,0 +1,314 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+	// DefaultPluginTimeout 是插件单次搜索的默认超时
+	DefaultPluginTimeout = 30 * time.Second
+	// pluginDescribeTimeout 是启动时读取插件描述的超时
+	pluginDescribeTimeout = 5 * time.Second
+	// pluginOutputLimit 是插件标准输出的最大字节数，pluginStderrLimit 是错误信息中保留的标准错误字节数
+	pluginOutputLimit = 16 << 20
+	pluginStderrLimit = 4 << 10
+	// pluginWaitDelay 是插件被终止后等待其输出管道关闭的时间，避免插件派生的子进程拖住请求
+	pluginWaitDelay = time.Second
+)
+
+// 插件请求类型
+const (
+	PluginRequestDescribe = "describe"
+	PluginRequestSearch   = "search"
+)
+
+// pluginIDPattern 限制插件 ID 的字符，ID 会出现在逗号分隔的适配器列表和 URL 中
+var pluginIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// PluginRequest 是写入插件标准输入的请求，每次请求启动一个新的插件进程
+// describe 请求要求插件输出 PluginInfo；search 请求携带搜索选项，要求插件输出 SearchResult 数组，
+// 或 {"results": [...], "total": 0, "totalPages": 0} 形式的结果页
+type PluginRequest struct {
+	Type    string                `json:"type"`
+	Options *models.SearchOptions `json:"options,omitempty"`
+}
+
+// PluginInfo 是插件对 describe 请求的响应，插件以 ID 注册到适配器注册器
+type PluginInfo struct {
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	Capabilities models.Capabilities `json:"capabilities"`
+}
+
+// PluginCommand 描述启动插件进程的命令，Env 追加到服务自身的环境变量之后
+type PluginCommand struct {
+	Path string
+	Args []string
+	Env  []string
+}
+
+// Plugin 实现通过外部进程搜索的适配器，进程通过标准输入输出交换 JSON
+// 插件进程崩溃、超时或输出无效时只有本次搜索失败，不影响服务和其他适配器
+type Plugin struct {
+	command  PluginCommand
+	info     PluginInfo
+	timeout  time.Duration
+	trackers []string
+}
+
+// NewPlugin 启动插件读取其描述并创建适配器，timeout 为单次搜索的超时
+func NewPlugin(ctx context.Context, command PluginCommand, timeout time.Duration, trackers []string) (*Plugin, error) {
+	if timeout <= 0 {
+		timeout = DefaultPluginTimeout
+	}
+	// 插件在自身所在目录中运行，相对路径需要先转换为绝对路径
+	if filepath.Base(command.Path) != command.Path {
+		abs, err := filepath.Abs(command.Path)
+		if err != nil {
+			return nil, err
+		}
+		command.Path = abs
+	}
+	p := &Plugin{
+		command:  command,
+		timeout:  timeout,
+		trackers: append([]string(nil), trackers...),
+	}
+
+	output, err := p.run(ctx, PluginRequest{Type: PluginRequestDescribe}, min(timeout, pluginDescribeTimeout))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(output, &p.info); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid describe response: %w", p.label(), err)
+	}
+	if !pluginIDPattern.MatchString(p.info.ID) {
+		return nil, fmt.Errorf("plugin %s: invalid id %q", p.label(), p.info.ID)
+	}
+	p.info.Name = utils.Coalesce(p.info.Name, p.info.ID)
+	p.info.Description = utils.Coalesce(p.info.Description, "通过外部插件检索资源")
+	return p, nil
+}
+
+func (p *Plugin) ID() string          { return p.info.ID }
+func (p *Plugin) Name() string        { return p.info.Name }
+func (p *Plugin) Description() string { return p.info.Description }
+func (p *Plugin) Endpoint() string    { return "plugin:" + filepath.Base(p.command.Path) }
+
+// Capabilities 返回插件在描述中声明的功能
+func (p *Plugin) Capabilities() models.Capabilities {
+	return p.info.Capabilities
+}
+
+// Search 执行搜索
+func (p *Plugin) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return p.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+// SearchWithOptions 执行搜索
+func (p *Plugin) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	page, err := p.SearchPage(ctx, options)
+	return page.Results, err
+}
+
+// SearchPage 启动插件进程执行搜索，插件返回结果页时同时返回总数
+func (p *Plugin) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	output, err := p.run(ctx, PluginRequest{Type: PluginRequestSearch, Options: &options}, p.timeout)
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+
+	var response struct {
+		Results    []models.SearchResult `json:"results"`
+		Total      int                   `json:"total"`
+		TotalPages int                   `json:"totalPages"`
+	}
+	output = bytes.TrimSpace(output)
+	if bytes.HasPrefix(output, []byte("[")) {
+		err = json.Unmarshal(output, &response.Results)
+	} else {
+		err = json.Unmarshal(output, &response)
+	}
+	if err != nil {
+		return models.ResultPage{}, fmt.Errorf("plugin %s: invalid search response: %w", p.ID(), err)
+	}
+
+	return models.ResultPage{
+		Results:    p.normalize(response.Results),
+		Total:      response.Total,
+		TotalPages: response.TotalPages,
+	}, nil
+}
+
+// normalize 补全插件结果中的 info hash、磁力链接和来源，丢弃缺少标题或磁力链接的结果
+func (p *Plugin) normalize(results []models.SearchResult) []models.SearchResult {
+	normalized := make([]models.SearchResult, 0, len(results))
+	for _, result := range results {
+		if result.Title == "" {
+			continue
+		}
+		result.InfoHash = utils.NormalizeInfoHash(result.InfoHash)
+		if result.InfoHash == "" && result.Magnet != "" {
+			result.InfoHash = extractInfoHashFromMagnet(result.Magnet)
+		}
+		if result.Magnet == "" && result.InfoHash != "" {
+			result.Magnet = buildMagnetLink(result.InfoHash, result.Title, p.trackers)
+		}
+		if !strings.HasPrefix(result.Magnet, "magnet:") {
+			continue
+		}
+		if result.SizeLabel == "" && result.Size != nil && *result.Size > 0 {
+			result.SizeLabel = utils.FormatSize(*result.Size)
+		}
+		result.Category = utils.Coalesce(result.Category, "未知")
+		result.Source = p.ID()
+		result.Sources = nil
+		normalized = append(normalized, result)
+	}
+	return normalized
+}
+
+// label 返回错误信息中使用的插件名称，读取描述前使用可执行文件名
+func (p *Plugin) label() string {
+	return utils.Coalesce(p.info.ID, filepath.Base(p.command.Path))
+}
+
+// run 启动插件进程，写入请求并返回标准输出；超时、被取消或以非零状态退出时返回错误
+func (p *Plugin) run(ctx context.Context, request PluginRequest, timeout time.Duration) ([]byte, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, p.command.Path, p.command.Args...)
+	if filepath.IsAbs(p.command.Path) {
+		cmd.Dir = filepath.Dir(p.command.Path)
+	}
+	cmd.Env = append(os.Environ(), p.command.Env...)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	stdout := &limitedBuffer{limit: pluginOutputLimit}
+	stderr := &limitedBuffer{limit: pluginStderrLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = pluginWaitDelay
+
+	err = cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("plugin %s timed out after %s: %w", p.label(), timeout, context.DeadlineExceeded)
+	case err != nil:
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("plugin %s: %w: %s", p.label(), err, message)
+		}
+		return nil, fmt.Errorf("plugin %s: %w", p.label(), err)
+	case stdout.exceeded:
+		return nil, fmt.Errorf("plugin %s: output exceeds %d bytes", p.label(), pluginOutputLimit)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer 最多保存 limit 字节，超出的部分被丢弃，使插件不会因管道写满而阻塞
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.exceeded = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// LoadPlugins 加载插件目录中的所有可执行文件，目录不存在时返回空列表
+// 插件并发读取描述，描述失败、ID 无效或与其他插件重复的插件会被跳过并在错误中报告
+func LoadPlugins(dir string, timeout time.Duration, trackers []string) ([]models.Adapter, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !isExecutable(info) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+
+	plugins := make([]*Plugin, len(paths))
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			plugins[i], errs[i] = NewPlugin(context.Background(), PluginCommand{Path: path}, timeout, trackers)
+		}()
+	}
+	wg.Wait()
+
+	var adapters []models.Adapter
+	seen := make(map[string]string)
+	for i, plugin := range plugins {
+		if plugin == nil {
+			continue
+		}
+		name := filepath.Base(paths[i])
+		if other, ok := seen[plugin.ID()]; ok {
+			errs[i] = fmt.Errorf("plugin %s: id %q already used by %s", name, plugin.ID(), other)
+			continue
+		}
+		seen[plugin.ID()] = name
+		adapters = append(adapters, plugin)
+	}
+	return adapters, errors.Join(errs...)
+}
+
+// isExecutable 判断文件是否可以作为插件执行，Windows 上按扩展名判断
+func isExecutable(info os.FileInfo) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(info.Name())) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
@@ -0,0 +1,173 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// pluginHelperEnv 让测试二进制作为插件进程运行，值为插件的行为模式
+const pluginHelperEnv = "SEEDMANAGE_PLUGIN_HELPER"
+
+// TestPluginHelperProcess 不是真正的测试，而是被插件测试作为外部插件进程启动
+func TestPluginHelperProcess(t *testing.T) {
+	mode := os.Getenv(pluginHelperEnv)
+	if mode == "" {
+		return
+	}
+	defer os.Exit(0)
+
+	var request PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	id, mode, _ := strings.Cut(mode, ":")
+	if request.Type == PluginRequestDescribe {
+		fmt.Printf(`{"id":%q,"name":"Helper","capabilities":{"pagination":true,"pageSize":2}}`, id)
+		return
+	}
+
+	switch mode {
+	case "crash":
+		fmt.Fprintln(os.Stderr, "boom")
+		os.Exit(2)
+	case "hang":
+		time.Sleep(time.Minute)
+	case "garbage":
+		fmt.Print("not json")
+	case "array":
+		fmt.Printf(`[{"title":%q,"infoHash":"0123456789abcdef0123456789abcdef01234567","size":1048576}]`, request.Options.Query)
+	default:
+		fmt.Printf(`{"results":[{"title":%q,"magnet":"magnet:?xt=urn:btih:89ABCDEF0123456789ABCDEF0123456789ABCDEF&dn=x"},{"title":"","magnet":"magnet:?xt=urn:btih:AAA"}],"total":7,"totalPages":4}`, request.Options.Query)
+	}
+}
+
+func newHelperPlugin(t *testing.T, mode string, timeout time.Duration) *Plugin {
+	t.Helper()
+	plugin, err := NewPlugin(context.Background(), PluginCommand{
+		Path: os.Args[0],
+		Args: []string{"-test.run=^TestPluginHelperProcess$"},
+		Env:  []string{pluginHelperEnv + "=helper:" + mode},
+	}, timeout, []string{"udp://tracker.example:1337/announce"})
+	if err != nil {
+		t.Fatalf("NewPlugin: %v", err)
+	}
+	return plugin
+}
+
+func TestPluginDescribeAndSearch(t *testing.T) {
+	plugin := newHelperPlugin(t, "page", time.Minute)
+	if plugin.ID() != "helper" || plugin.Name() != "Helper" || !plugin.Capabilities().Pagination {
+		t.Fatalf("unexpected plugin info: %+v", plugin.info)
+	}
+
+	page, err := plugin.SearchPage(context.Background(), models.SearchOptions{Query: "Ubuntu", Page: 2})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(page.Results) != 1 || page.Total != 7 || page.TotalPages != 4 {
+		t.Fatalf("got %d results, total %d/%d; want 1 result, total 7/4", len(page.Results), page.Total, page.TotalPages)
+	}
+	result := page.Results[0]
+	if result.Title != "Ubuntu" || result.InfoHash != "89ABCDEF0123456789ABCDEF0123456789ABCDEF" || result.Source != "helper" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestPluginArrayResponse(t *testing.T) {
+	results, err := newHelperPlugin(t, "array", time.Minute).Search(context.Background(), "Debian")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	result := results[0]
+	if result.InfoHash != "0123456789ABCDEF0123456789ABCDEF01234567" || !strings.Contains(result.Magnet, "tracker.example") {
+		t.Errorf("magnet not built from info hash: %+v", result)
+	}
+	if result.SizeLabel == "" {
+		t.Error("size label not filled")
+	}
+}
+
+func TestPluginFailures(t *testing.T) {
+	t.Run("crash", func(t *testing.T) {
+		_, err := newHelperPlugin(t, "crash", time.Minute).Search(context.Background(), "x")
+		if err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Errorf("err = %v, want crash with stderr", err)
+		}
+	})
+	t.Run("garbage", func(t *testing.T) {
+		_, err := newHelperPlugin(t, "garbage", time.Minute).Search(context.Background(), "x")
+		if err == nil || !strings.Contains(err.Error(), "invalid search response") {
+			t.Errorf("err = %v, want invalid response", err)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		// describe 使用较长的超时，进程启动较慢时（如 -race）不会误判为搜索超时
+		plugin := newHelperPlugin(t, "hang", time.Minute)
+		plugin.timeout = 200 * time.Millisecond
+		started := time.Now()
+		_, err := plugin.Search(context.Background(), "x")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want deadline exceeded", err)
+		}
+		if elapsed := time.Since(started); elapsed > 5*time.Second {
+			t.Errorf("timed out plugin took %s to stop", elapsed)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		plugin := newHelperPlugin(t, "hang", time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if _, err := plugin.Search(ctx, "x"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want caller's deadline", err)
+		}
+	})
+}
+
+func TestLoadPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	script := func(name, mode string, perm os.FileMode) {
+		content := fmt.Sprintf("#!/bin/sh\n%s='%s' exec %q -test.run='^TestPluginHelperProcess$'\n", pluginHelperEnv, mode, os.Args[0])
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script("alpha.sh", "alpha:page", 0o755)
+	script("beta.sh", "beta:array", 0o755)
+	script("duplicate.sh", "alpha:page", 0o755)
+	script("invalid.sh", "bad id:page", 0o755)
+	script("notes.txt", "ignored:page", 0o644)
+
+	plugins, err := LoadPlugins(dir, time.Minute, nil)
+	var ids []string
+	for _, plugin := range plugins {
+		ids = append(ids, plugin.ID())
+	}
+	if strings.Join(ids, ",") != "alpha,beta" {
+		t.Errorf("loaded %v, want [alpha beta]", ids)
+	}
+	if err == nil || !strings.Contains(err.Error(), "already used") || !strings.Contains(err.Error(), "invalid id") {
+		t.Errorf("err = %v, want duplicate and invalid id errors", err)
+	}
+
+	if plugins, err := LoadPlugins(filepath.Join(dir, "missing"), time.Minute, nil); err != nil || len(plugins) != 0 {
+		t.Errorf("missing dir: %d plugins, err %v", len(plugins), err)
+	}
+}
@@ -12,6 +12,9 @@ import (
     "github.com/seedmanage/backend/internal/utils"
 )
 
+// samplePageSize 是本地示例数据模拟分页时的每页结果数
+const samplePageSize = 10
+
 // Sample 使用本地示例数据的适配器
 type Sample struct {
     items []models.SearchResult
@@ -98,40 +101,70 @@ func (s *Sample) Name() string        { return "本地示例数据" }
 func (s *Sample) Description() string { return "使用仓库内置示例结果进行匹配" }
 func (s *Sample) Endpoint() string    { return "local-data" }
 
+// Capabilities 声明适配器支持的功能
+func (s *Sample) Capabilities() models.Capabilities {
+    return models.Capabilities{
+        Pagination:     true,
+        PageSize:       samplePageSize,
+        InfoHashLookup: true,
+    }
+}
+
+// SupportsCategory 总是返回 true：分类按名称的子串匹配本地数据的分类
+func (s *Sample) SupportsCategory(category string) bool {
+    return true
+}
+
 // Search 在本地数据中搜索匹配的项
 func (s *Sample) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
     return s.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
 }
 
-// SearchWithOptions 在本地数据中搜索匹配的项，支持分页
+// SearchWithOptions 在本地数据中搜索匹配的项，支持分页和按分类名称过滤
 func (s *Sample) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+    page, err := s.SearchPage(ctx, options)
+    return page.Results, err
+}
+
+// SearchPage 在本地数据中搜索匹配的项，返回当前页以及匹配总数
+func (s *Sample) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
     lower := strings.ToLower(options.Query)
+    category := strings.ToLower(options.Category)
     results := make([]models.SearchResult, 0)
     for _, item := range s.items {
         select {
         case <-ctx.Done():
-            return nil, ctx.Err()
+            return models.ResultPage{}, ctx.Err()
         default:
         }
 
+        if category != "" && !strings.Contains(strings.ToLower(item.Category), category) {
+            continue
+        }
+
         if strings.Contains(strings.ToLower(item.Title), lower) || strings.Contains(strings.ToLower(item.InfoHash), strings.ToLower(options.Query)) {
             results = append(results, item)
         }
     }
-    
+
+    page := models.ResultPage{
+        Results:    []models.SearchResult{},
+        Total:      len(results),
+        TotalPages: (len(results) + samplePageSize - 1) / samplePageSize,
+    }
+
     // 本地数据不支持真正的分页，但可以模拟分页效果
-    const pageSize = 10
-    start := (options.Page - 1) * pageSize
-    end := start + pageSize
-    
+    start := (options.Page - 1) * samplePageSize
+    end := start + samplePageSize
+
     if start >= len(results) {
-        return []models.SearchResult{}, nil
+        return page, nil
     }
-    
+
     if end > len(results) {
         end = len(results)
     }
-    
-    return results[start:end], nil
-}
 
+    page.Results = results[start:end]
+    return page, nil
+}
@@ -17,61 +17,71 @@ import (
 
 // Sukebei 实现通过 nyaaapi.onrender.com/sukebei 进行搜索的适配器
 type Sukebei struct {
-    endpoint string
-    headers  http.Header
-    client   *http.Client
+    upstream
+    httpTransport
     trackers []string
 }
 
 // NewSukebei 创建一个新的 Sukebei 适配器
 func NewSukebei(endpoint string, trackers []string) models.Adapter {
     return &Sukebei{
-        endpoint: endpoint,
-        headers: http.Header{
-            "User-Agent": []string{"magnetsearch-backend/1.0"},
-        },
-        client:   &http.Client{Timeout: 10 * time.Second},
-        trackers: append([]string(nil), trackers...),
+        upstream:      upstream{mirrors: splitMirrors(endpoint)},
+        httpTransport: newHTTPTransport(10 * time.Second),
+        trackers:      append([]string(nil), trackers...),
     }
 }
 
 func (s *Sukebei) ID() string          { return "sukebei" }
 func (s *Sukebei) Name() string        { return "Sukebei" }
 func (s *Sukebei) Description() string { return "通过 nyaaapi.onrender.com 的 Sukebei 数据源检索资源" }
-func (s *Sukebei) Endpoint() string    { return s.endpoint }
+
+// Capabilities 声明适配器支持的功能
+func (s *Sukebei) Capabilities() models.Capabilities {
+    return models.Capabilities{
+        Pagination: true,
+        PageSize:   nyaaPageSize,
+        Categories: sortedKeys(sukebeiAPICategories),
+        Sort:       nyaaSorts,
+        Filters:    nyaaFilters,
+    }
+}
 
 // Search 执行搜索
 func (s *Sukebei) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
     return s.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
 }
 
-// SearchWithOptions 执行搜索，支持分页
+// SearchWithOptions 执行搜索，支持分页、分类、排序和过滤
 func (s *Sukebei) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
-    u, err := url.Parse(s.endpoint)
-    if err != nil {
-        return nil, fmt.Errorf("invalid sukebei endpoint: %w", err)
-    }
+    page, err := s.SearchPage(ctx, options)
+    return page.Results, err
+}
 
-    q := u.Query()
+// SearchPage 执行搜索并返回带总数的结果页，总数来自 API 响应中的 count 字段
+func (s *Sukebei) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+    q := url.Values{}
     q.Set("q", options.Query)
     q.Set("page", strconv.Itoa(options.Page))
-    u.RawQuery = q.Encode()
-
-    req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-    if err != nil {
-        return nil, err
+    if category := translateCategory(options.Category, sukebeiAPICategories, nil); category != "" {
+        q.Set("category", category)
+    }
+    if sort := nyaaSort(options.Sort); sort != "" {
+        q.Set("sort", sort)
+        q.Set("order", nyaaOrder(options.Order))
+    }
+    if options.Filter != models.FilterNone {
+        q.Set("filter", nyaaFilter(options.Filter))
     }
-    req.Header = s.headers.Clone()
 
-    resp, err := s.client.Do(req)
+    resp, endpoint, err := s.get(ctx, &s.upstream, q)
     if err != nil {
-        return nil, err
+        return models.ResultPage{}, err
     }
     defer resp.Body.Close()
 
     if resp.StatusCode != http.StatusOK {
         body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-        return nil, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+        return models.ResultPage{}, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
     }
 
     var response struct {
@@ -88,7 +98,7 @@ func (s *Sukebei) SearchWithOptions(ctx context.Context, options models.SearchOp
     }
 
     if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-        return nil, err
+        return models.ResultPage{}, err
     }
 
     payload := response.Data
@@ -145,5 +155,9 @@ func (s *Sukebei) SearchWithOptions(ctx context.Context, options models.SearchOp
         })
     }
 
-    return results, nil
+    page := models.ResultPage{Results: results, Total: response.Count, Endpoint: endpoint}
+    if response.Count > 0 {
+        page.TotalPages = (response.Count + nyaaPageSize - 1) / nyaaPageSize
+    }
+    return page, nil
 }
@@ -0,0 +1,259 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/torznab"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+// torznabPageSize 是每页向 Torznab 索引器请求的结果数
+const torznabPageSize = 50
+
+// TorznabConfig 描述一个 Torznab 索引器，Mirrors 是 Endpoint 不可用时按顺序尝试的镜像地址
+type TorznabConfig struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Endpoint    string   `json:"endpoint"`
+	Mirrors     []string `json:"mirrors,omitempty"`
+	APIKey      string   `json:"apiKey"`
+	Categories  []string `json:"categories,omitempty"`
+}
+
+// Torznab 实现查询 Jackett/Prowlarr 等 Torznab 索引器的适配器
+type Torznab struct {
+	config TorznabConfig
+	upstream
+	httpTransport
+	trackers []string
+}
+
+// NewTorznab 创建一个新的 Torznab 适配器
+func NewTorznab(config TorznabConfig, trackers []string) (models.Adapter, error) {
+	if config.ID == "" {
+		return nil, errors.New("torznab: id is required")
+	}
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("torznab %s: endpoint is required", config.ID)
+	}
+	if config.Name == "" {
+		config.Name = config.ID
+	}
+	if config.Description == "" {
+		config.Description = "通过 Torznab API 检索资源"
+	}
+
+	return &Torznab{
+		config:        config,
+		upstream:      upstream{mirrors: append([]string{config.Endpoint}, config.Mirrors...)},
+		httpTransport: newHTTPTransport(15 * time.Second),
+		trackers:      append([]string(nil), trackers...),
+	}, nil
+}
+
+// LoadTorznabIndexers 从 JSON 配置文件加载 Torznab 索引器，文件不存在时返回空列表
+func LoadTorznabIndexers(path string, trackers []string) ([]models.Adapter, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []TorznabConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("parse torznab config: %w", err)
+	}
+
+	var indexers []models.Adapter
+	var errs []error
+	for _, config := range configs {
+		indexer, err := NewTorznab(config, trackers)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		indexers = append(indexers, indexer)
+	}
+
+	return indexers, errors.Join(errs...)
+}
+
+func (t *Torznab) ID() string          { return t.config.ID }
+func (t *Torznab) Name() string        { return t.config.Name }
+func (t *Torznab) Description() string { return t.config.Description }
+
+// Capabilities 声明适配器支持的功能
+func (t *Torznab) Capabilities() models.Capabilities {
+	return models.Capabilities{
+		Pagination: true,
+		PageSize:   torznabPageSize,
+		Categories: sortedKeys(torznabCategories),
+	}
+}
+
+// SupportsCategory 报告能否按分类过滤，支持通用分类和数字 Torznab 分类
+func (t *Torznab) SupportsCategory(category string) bool {
+	return supportsCategory(category, torznabCategories, numericCategoryPattern)
+}
+
+// Search 执行搜索
+func (t *Torznab) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return t.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+// SearchWithOptions 执行搜索，通过 offset/limit 支持分页，支持按分类过滤
+func (t *Torznab) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	page, err := t.SearchPage(ctx, options)
+	return page.Results, err
+}
+
+// SearchPage 执行搜索并返回带总数的结果页，总数来自 newznab:response 的 total 属性
+func (t *Torznab) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	page := options.Page
+	if page < 1 {
+		page = 1
+	}
+
+	q := url.Values{}
+	q.Set("t", "search")
+	q.Set("q", options.Query)
+	q.Set("offset", strconv.Itoa((page-1)*torznabPageSize))
+	q.Set("limit", strconv.Itoa(torznabPageSize))
+	if t.config.APIKey != "" {
+		q.Set("apikey", t.config.APIKey)
+	}
+	// 请求指定的分类优先于配置中的默认分类
+	if category := translateCategory(options.Category, torznabCategories, numericCategoryPattern); category != "" {
+		q.Set("cat", category)
+	} else if len(t.config.Categories) > 0 {
+		q.Set("cat", strings.Join(t.config.Categories, ","))
+	}
+
+	resp, endpoint, err := t.get(ctx, &t.upstream, q)
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return models.ResultPage{}, fmt.Errorf("remote service error: %s - %s", resp.Status, string(body))
+	}
+
+	feed, err := torznab.ParseFeed(resp.Body)
+	if err != nil {
+		return models.ResultPage{}, err
+	}
+
+	results := make([]models.SearchResult, 0, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		if result, ok := t.convert(item); ok {
+			results = append(results, result)
+		}
+	}
+
+	result := models.ResultPage{Results: results, Endpoint: endpoint}
+	if feed.Channel.Response != nil && feed.Channel.Response.Total > 0 {
+		result.Total = feed.Channel.Response.Total
+		result.TotalPages = (result.Total + torznabPageSize - 1) / torznabPageSize
+	}
+	return result, nil
+}
+
+// convert 将 Torznab 条目转换为搜索结果，无法得到磁力链接的条目会被跳过
+func (t *Torznab) convert(item torznab.Item) (models.SearchResult, bool) {
+	if item.Title == "" {
+		return models.SearchResult{}, false
+	}
+
+	infoHash := strings.ToUpper(item.Attr(torznab.AttrInfoHash))
+	magnet := item.Attr(torznab.AttrMagnetURL)
+	if !strings.HasPrefix(magnet, "magnet:") {
+		magnet = ""
+		for _, candidate := range []string{item.Link, item.GUID} {
+			if strings.HasPrefix(candidate, "magnet:") {
+				magnet = candidate
+				break
+			}
+		}
+		if magnet == "" && item.Enclosure != nil && strings.HasPrefix(item.Enclosure.URL, "magnet:") {
+			magnet = item.Enclosure.URL
+		}
+	}
+	if infoHash == "" && magnet != "" {
+		infoHash = extractInfoHashFromMagnet(magnet)
+	}
+	if magnet == "" && infoHash != "" {
+		magnet = buildMagnetLink(infoHash, item.Title, t.trackers)
+	}
+	if magnet == "" {
+		return models.SearchResult{}, false
+	}
+
+	result := models.SearchResult{
+		Title:    item.Title,
+		Magnet:   magnet,
+		InfoHash: infoHash,
+		Trackers: append([]string(nil), t.trackers...),
+		Category: "未知",
+		Source:   t.ID(),
+	}
+
+	seeders, hasSeeders := item.AttrInt(torznab.AttrSeeders)
+	if hasSeeders {
+		result.Seeders = utils.PtrInt(int(seeders))
+	}
+	if peers, ok := item.AttrInt(torznab.AttrPeers); ok {
+		leechers := peers
+		if hasSeeders {
+			leechers = peers - seeders
+		}
+		if leechers >= 0 {
+			result.Leechers = utils.PtrInt(int(leechers))
+		}
+	}
+
+	size := item.Size
+	if size <= 0 {
+		size, _ = item.AttrInt(torznab.AttrSize)
+	}
+	if size <= 0 && item.Enclosure != nil {
+		size = item.Enclosure.Length
+	}
+	if size > 0 {
+		result.Size = utils.PtrInt64(size)
+		result.SizeLabel = utils.FormatSize(size)
+	}
+
+	if published, ok := item.Published(); ok {
+		result.Uploaded = &published
+	}
+
+	for _, value := range append(item.AttrValues(torznab.AttrCategory), item.Categories...) {
+		if id, err := strconv.Atoi(value); err == nil {
+			if name := torznab.CategoryName(id); name != "" {
+				result.Category = name
+				break
+			}
+		} else if value != "" {
+			result.Category = value
+			break
+		}
+	}
+
+	return result, true
+}
@@ -0,0 +1,104 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/torznab"
+)
+
+func TestTorznabSearch(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "torznab_search.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("apikey") != "secret" {
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Invalid API Key" />`))
+			return
+		}
+		if q.Get("t") != "search" || q.Get("q") != "example" || q.Get("offset") != "50" || q.Get("cat") != "5000,2000" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	adapter, err := NewTorznab(TorznabConfig{
+		ID:         "jackett",
+		Endpoint:   server.URL + "/api/v2.0/indexers/all/results/torznab/api",
+		APIKey:     "secret",
+		Categories: []string{"5000", "2000"},
+	}, []string{"udp://tracker.example:1337/announce"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := adapter.SearchWithOptions(context.Background(), models.SearchOptions{Query: "example", Page: 2})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	first := results[0]
+	if first.InfoHash != "F257AF31A6204CD734D2BAECB8331637850B7B44" {
+		t.Errorf("info hash = %q", first.InfoHash)
+	}
+	if first.Magnet != "magnet:?xt=urn:btih:f257af31a6204cd734d2baecb8331637850b7b44&dn=Example.Show.S01E01" {
+		t.Errorf("magnet = %q", first.Magnet)
+	}
+	if *first.Seeders != 57 || *first.Leechers != 7 {
+		t.Errorf("seeders/leechers = %d/%d, want 57/7", *first.Seeders, *first.Leechers)
+	}
+	if *first.Size != 1503238553 || first.Category != "TV/HD" || first.Source != "jackett" {
+		t.Errorf("unexpected fields: %+v", first)
+	}
+	if first.Uploaded == nil || first.Uploaded.Unix() != 1704110400 {
+		t.Errorf("uploaded = %v", first.Uploaded)
+	}
+
+	second := results[1]
+	if second.Magnet == "" || second.InfoHash != "0123456789ABCDEF0123456789ABCDEF01234567" {
+		t.Errorf("magnet not built from info hash: %+v", second)
+	}
+	if *second.Size != 524288000 || second.Category != "Movies/UHD" {
+		t.Errorf("unexpected fields: %+v", second)
+	}
+
+	page, err := adapter.(*Torznab).SearchPage(context.Background(), models.SearchOptions{Query: "example", Page: 2})
+	if err != nil {
+		t.Fatalf("search page: %v", err)
+	}
+	if page.Total != 180 || page.TotalPages != 4 {
+		t.Errorf("total = %d, pages = %d, want 180, 4", page.Total, page.TotalPages)
+	}
+}
+
+func TestTorznabErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Invalid API Key" />`))
+	}))
+	defer server.Close()
+
+	adapter, err := NewTorznab(TorznabConfig{ID: "bad", Endpoint: server.URL}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = adapter.Search(context.Background(), "example")
+	var apiErr *torznab.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 100 {
+		t.Fatalf("err = %v, want torznab error 100", err)
+	}
+}
@@ -0,0 +1,202 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seedmanage/backend/internal/httpclient"
+)
+
+// NetworkConfigurable 由通过 HTTP 访问上游的适配器实现，用于应用代理、超时、请求头等网络配置
+type NetworkConfigurable interface {
+	ConfigureNetwork(cfg httpclient.Config) error
+}
+
+// httpTransport 是 HTTP 适配器共用的默认请求头和客户端
+type httpTransport struct {
+	headers http.Header
+	client  *http.Client
+	// timeout 是适配器自身的默认超时，网络配置未指定超时时使用
+	timeout time.Duration
+}
+
+func newHTTPTransport(timeout time.Duration) httpTransport {
+	return httpTransport{
+		headers: httpclient.DefaultHeaders(),
+		client:  httpclient.MustNewClient(httpclient.Config{}, timeout),
+		timeout: timeout,
+	}
+}
+
+// ConfigureNetwork 按网络配置重建 HTTP 客户端，应在适配器开始处理请求前调用
+func (t *httpTransport) ConfigureNetwork(cfg httpclient.Config) error {
+	client, err := httpclient.NewClient(cfg, t.timeout)
+	if err != nil {
+		return err
+	}
+	t.client = client
+	return nil
+}
+
+// upstream 保存可在运行时修改的上游地址，多个地址按顺序作为同一站点的镜像
+// current 是最近一次请求成功的镜像，之后的请求优先使用它
+type upstream struct {
+	mu      sync.RWMutex
+	mirrors []string
+	current int
+}
+
+// splitMirrors 将逗号分隔的地址列表拆分为镜像列表，忽略空项
+func splitMirrors(endpoints string) []string {
+	var mirrors []string
+	for _, endpoint := range strings.Split(endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			mirrors = append(mirrors, endpoint)
+		}
+	}
+	return mirrors
+}
+
+// trimSlashes 去掉每个镜像地址末尾的斜杠
+func trimSlashes(mirrors []string) []string {
+	for i, mirror := range mirrors {
+		mirrors[i] = strings.TrimRight(mirror, "/")
+	}
+	return mirrors
+}
+
+// Endpoint 返回当前使用的镜像地址
+func (u *upstream) Endpoint() string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if len(u.mirrors) == 0 {
+		return ""
+	}
+	return u.mirrors[u.current]
+}
+
+// Mirrors 按配置顺序返回全部镜像地址
+func (u *upstream) Mirrors() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return append([]string(nil), u.mirrors...)
+}
+
+// SetEndpoint 修改上游地址，逗号分隔的多个地址按顺序作为镜像；只接受 http 和 https 地址
+func (u *upstream) SetEndpoint(endpoint string) error {
+	mirrors := splitMirrors(endpoint)
+	if len(mirrors) == 0 {
+		return errors.New("endpoint is required")
+	}
+	for i, mirror := range mirrors {
+		parsed, err := url.Parse(mirror)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint: %w", err)
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid endpoint %q: must be an http or https URL", mirror)
+		}
+		mirrors[i] = parsed.String()
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.mirrors = mirrors
+	u.current = 0
+	return nil
+}
+
+// order 返回本次请求尝试镜像的顺序：先尝试当前镜像，再按配置顺序尝试其余镜像
+func (u *upstream) order() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if len(u.mirrors) == 0 {
+		return nil
+	}
+	order := make([]string, 0, len(u.mirrors))
+	order = append(order, u.mirrors[u.current])
+	for i, mirror := range u.mirrors {
+		if i != u.current {
+			order = append(order, mirror)
+		}
+	}
+	return order
+}
+
+// markHealthy 记住请求成功的镜像
+func (u *upstream) markHealthy(endpoint string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if i := slices.Index(u.mirrors, endpoint); i >= 0 {
+		u.current = i
+	}
+}
+
+// get 依次在各个镜像上发起 GET 请求，query 中的参数覆盖镜像地址上的同名参数
+// 连接失败或返回 5xx 时切换到下一个镜像，并记住最终成功响应的镜像；返回响应和所用的镜像地址
+// 所有镜像都返回 5xx 时返回最后一个响应，由调用方按普通的错误状态码处理
+func (t *httpTransport) get(ctx context.Context, up *upstream, query url.Values) (*http.Response, string, error) {
+	var lastResp *http.Response
+	var lastEndpoint string
+	var errs []error
+	for _, endpoint := range up.order() {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid endpoint %q: %w", endpoint, err))
+			continue
+		}
+		q := u.Query()
+		for key, values := range query {
+			q[key] = values
+		}
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		req.Header = t.headers.Clone()
+
+		resp, err := t.client.Do(req)
+		switch {
+		case err != nil:
+			// 调用方取消、超时和本地限速与镜像无关，不再尝试其他镜像
+			if ctx.Err() != nil || errors.Is(err, httpclient.ErrRateLimited) {
+				if lastResp != nil {
+					lastResp.Body.Close()
+				}
+				return nil, endpoint, err
+			}
+			errs = append(errs, err)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			lastResp, lastEndpoint = resp, endpoint
+		default:
+			if lastResp != nil {
+				lastResp.Body.Close()
+			}
+			up.markHealthy(endpoint)
+			return resp, endpoint, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if lastResp != nil {
+		return lastResp, lastEndpoint, nil
+	}
+	if len(errs) == 0 {
+		return nil, "", errors.New("no endpoint configured")
+	}
+	return nil, "", errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/httpclient"
+	"github.com/seedmanage/backend/internal/models"
+)
+
+const apibayPayload = `[{"name":"Example","info_hash":"0123456789ABCDEF0123456789ABCDEF01234567","seeders":"1","leechers":"0","size":"1","added":"0","category":"200"}]`
+
+// mirrorServer 按固定状态码响应并记录请求次数
+func mirrorServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(apibayPayload))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// newMirroredAPIBay 创建不重试的 apibay 适配器，使每个镜像只请求一次
+func newMirroredAPIBay(t *testing.T, mirrors ...string) *APIBay {
+	t.Helper()
+	adapter := NewAPIBay(strings.Join(mirrors, ","), nil).(*APIBay)
+	if err := adapter.ConfigureNetwork(httpclient.Config{Retry: httpclient.RetryConfig{MaxAttempts: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	return adapter
+}
+
+func TestMirrorFailover(t *testing.T) {
+	broken, brokenHits := mirrorServer(t, http.StatusInternalServerError)
+	healthy, healthyHits := mirrorServer(t, http.StatusOK)
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refused.Close()
+
+	adapter := newMirroredAPIBay(t, refused.URL, broken.URL, healthy.URL)
+	page, err := adapter.SearchPage(context.Background(), models.SearchOptions{Query: "x", Page: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(page.Results) != 1 || page.Endpoint != healthy.URL {
+		t.Fatalf("got %d results from %q, want 1 from %q", len(page.Results), page.Endpoint, healthy.URL)
+	}
+	if got := adapter.Endpoint(); got != healthy.URL {
+		t.Errorf("Endpoint() = %q, want healthy mirror %q", got, healthy.URL)
+	}
+
+	// 之后的请求直接使用记住的镜像
+	if _, err := adapter.SearchPage(context.Background(), models.SearchOptions{Query: "y", Page: 1}); err != nil {
+		t.Fatalf("second search: %v", err)
+	}
+	if brokenHits.Load() != 1 || healthyHits.Load() != 2 {
+		t.Errorf("hits broken = %d, healthy = %d; want 1, 2", brokenHits.Load(), healthyHits.Load())
+	}
+}
+
+func TestMirrorNoFailoverOnClientError(t *testing.T) {
+	missing, _ := mirrorServer(t, http.StatusNotFound)
+	healthy, healthyHits := mirrorServer(t, http.StatusOK)
+
+	adapter := newMirroredAPIBay(t, missing.URL, healthy.URL)
+	if _, err := adapter.Search(context.Background(), "x"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("err = %v, want 404 from first mirror", err)
+	}
+	if healthyHits.Load() != 0 {
+		t.Error("4xx response triggered failover")
+	}
+}
+
+func TestMirrorAllUnavailable(t *testing.T) {
+	first, firstHits := mirrorServer(t, http.StatusBadGateway)
+	second, secondHits := mirrorServer(t, http.StatusInternalServerError)
+
+	adapter := newMirroredAPIBay(t, first.URL, second.URL)
+	if _, err := adapter.Search(context.Background(), "x"); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("err = %v, want last mirror's 500", err)
+	}
+	if firstHits.Load() != 1 || secondHits.Load() != 1 {
+		t.Errorf("hits = %d, %d; want each mirror tried once", firstHits.Load(), secondHits.Load())
+	}
+	if got := adapter.Endpoint(); got != first.URL {
+		t.Errorf("Endpoint() = %q, want unchanged %q", got, first.URL)
+	}
+}
+
+func TestSetEndpointMirrors(t *testing.T) {
+	adapter := NewNyaa("https://nyaa.example/a", nil).(*Nyaa)
+
+	if err := adapter.SetEndpoint("https://one.example/nyaa, https://two.example/nyaa"); err != nil {
+		t.Fatal(err)
+	}
+	mirrors := adapter.Mirrors()
+	if len(mirrors) != 2 || mirrors[0] != "https://one.example/nyaa" || mirrors[1] != "https://two.example/nyaa" {
+		t.Errorf("Mirrors() = %v", mirrors)
+	}
+	if got := adapter.Endpoint(); got != "https://one.example/nyaa" {
+		t.Errorf("Endpoint() = %q, want first mirror", got)
+	}
+
+	for _, endpoint := range []string{"", " , ", "https://ok.example, ftp://bad.example", "not a url"} {
+		if err := adapter.SetEndpoint(endpoint); err == nil {
+			t.Errorf("SetEndpoint(%q) succeeded, want error", endpoint)
+		}
+	}
+	if got := adapter.Mirrors(); len(got) != 2 {
+		t.Errorf("invalid SetEndpoint changed mirrors to %v", got)
+	}
+}
@@ -0,0 +1,251 @@
+// Package bencode 实现 BitTorrent 使用的 bencode 编码（BEP 3）
+// 解码结果为 int64、string（字节串）、[]any 和 map[string]any
+package bencode
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// maxDepth 限制列表和字典的嵌套层数，防止恶意输入耗尽栈空间
+const maxDepth = 64
+
+// ErrUnexpectedEOF 表示输入在值结束前截断
+var ErrUnexpectedEOF = errors.New("bencode: unexpected end of input")
+
+// Decode 解码完整的输入，值之后有多余数据时返回错误
+func Decode(data []byte) (any, error) {
+	value, n, err := DecodePrefix(data)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("bencode: %d trailing bytes after value", len(data)-n)
+	}
+	return value, nil
+}
+
+// DecodePrefix 解码输入开头的一个值，返回该值和它占用的字节数
+func DecodePrefix(data []byte) (any, int, error) {
+	d := decoder{data: data}
+	value, err := d.value(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d.pos, nil
+}
+
+// DecodeRawDict 解码一个完整的字典，返回每个键对应值的原始编码
+// 用于需要按原样哈希某个值的场景，例如 .torrent 文件中的 info 字典
+func DecodeRawDict(data []byte) (map[string][]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrUnexpectedEOF
+	}
+	if data[0] != 'd' {
+		return nil, errors.New("bencode: value is not a dictionary")
+	}
+	d := decoder{data: data, pos: 1}
+	dict := map[string][]byte{}
+	for {
+		if d.pos >= len(d.data) {
+			return nil, ErrUnexpectedEOF
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			break
+		}
+		key, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		start := d.pos
+		if _, err := d.value(1); err != nil {
+			return nil, err
+		}
+		dict[key] = d.data[start:d.pos]
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("bencode: %d trailing bytes after value", len(d.data)-d.pos)
+	}
+	return dict, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) value(depth int) (any, error) {
+	if d.pos >= len(d.data) {
+		return nil, ErrUnexpectedEOF
+	}
+	if depth > maxDepth {
+		return nil, fmt.Errorf("bencode: nesting deeper than %d", maxDepth)
+	}
+
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		d.pos++
+		return d.integer('e')
+	case c == 'l':
+		d.pos++
+		list := []any{}
+		for {
+			if d.pos >= len(d.data) {
+				return nil, ErrUnexpectedEOF
+			}
+			if d.data[d.pos] == 'e' {
+				d.pos++
+				return list, nil
+			}
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+	case c == 'd':
+		d.pos++
+		dict := map[string]any{}
+		for {
+			if d.pos >= len(d.data) {
+				return nil, ErrUnexpectedEOF
+			}
+			if d.data[d.pos] == 'e' {
+				d.pos++
+				return dict, nil
+			}
+			key, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = item
+		}
+	case c >= '0' && c <= '9':
+		return d.string()
+	default:
+		return nil, fmt.Errorf("bencode: invalid byte %q at offset %d", c, d.pos)
+	}
+}
+
+// integer 读取到 end 为止的十进制整数，拒绝前导零和 -0
+func (d *decoder) integer(end byte) (int64, error) {
+	start := d.pos
+	for d.pos < len(d.data) && d.data[d.pos] != end {
+		d.pos++
+	}
+	if d.pos >= len(d.data) {
+		return 0, ErrUnexpectedEOF
+	}
+	text := string(d.data[start:d.pos])
+	d.pos++
+
+	digits := text
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	if digits == "" || (digits[0] == '0' && len(text) > 1) {
+		return 0, fmt.Errorf("bencode: invalid integer %q at offset %d", text, start)
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bencode: invalid integer %q at offset %d", text, start)
+	}
+	return n, nil
+}
+
+func (d *decoder) string() (string, error) {
+	if d.pos >= len(d.data) {
+		return "", ErrUnexpectedEOF
+	}
+	if c := d.data[d.pos]; c < '0' || c > '9' {
+		return "", fmt.Errorf("bencode: expected string at offset %d", d.pos)
+	}
+	start := d.pos
+	length, err := d.integer(':')
+	if err != nil {
+		return "", err
+	}
+	if length < 0 {
+		return "", fmt.Errorf("bencode: negative string length at offset %d", start)
+	}
+	if length > int64(len(d.data)-d.pos) {
+		return "", ErrUnexpectedEOF
+	}
+	s := string(d.data[d.pos : d.pos+int(length)])
+	d.pos += int(length)
+	return s, nil
+}
+
+// Raw 是已经编码的值，Encode 原样写入，不做校验
+type Raw []byte
+
+// Encode 编码 int、int64、string、[]byte、[]string、[]any、map[string]any 和 Raw 组成的值，字典按键排序
+func Encode(value any) ([]byte, error) {
+	return appendValue(nil, value)
+}
+
+func appendValue(buf []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case int:
+		return appendInt(buf, int64(v)), nil
+	case int64:
+		return appendInt(buf, v), nil
+	case string:
+		return appendString(buf, v), nil
+	case []byte:
+		return appendString(buf, string(v)), nil
+	case Raw:
+		return append(buf, v...), nil
+	case []string:
+		buf = append(buf, 'l')
+		for _, item := range v {
+			buf = appendString(buf, item)
+		}
+		return append(buf, 'e'), nil
+	case []any:
+		buf = append(buf, 'l')
+		for _, item := range v {
+			var err error
+			if buf, err = appendValue(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, 'e'), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf = append(buf, 'd')
+		for _, key := range keys {
+			buf = appendString(buf, key)
+			var err error
+			if buf, err = appendValue(buf, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, 'e'), nil
+	default:
+		return nil, fmt.Errorf("bencode: unsupported type %T", value)
+	}
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	buf = append(buf, 'i')
+	buf = strconv.AppendInt(buf, n, 10)
+	return append(buf, 'e')
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = strconv.AppendInt(buf, int64(len(s)), 10)
+	buf = append(buf, ':')
+	return append(buf, s...)
+}
@@ -0,0 +1,96 @@
+package bencode
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  any
+	}{
+		{"i42e", int64(42)},
+		{"i-7e", int64(-7)},
+		{"i0e", int64(0)},
+		{"4:spam", "spam"},
+		{"0:", ""},
+		{"l4:spami3ee", []any{"spam", int64(3)}},
+		{"le", []any{}},
+		{"d3:bar4:spam3:fooi42ee", map[string]any{"bar": "spam", "foo": int64(42)}},
+		{"d5:filesd1:xd8:completei1eeee", map[string]any{"files": map[string]any{"x": map[string]any{"complete": int64(1)}}}},
+	}
+	for _, tt := range tests {
+		got, err := Decode([]byte(tt.input))
+		if err != nil {
+			t.Errorf("Decode(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Decode(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, input := range []string{"", "i03e", "i-0e", "ie", "i12", "5:abc", "l", "d3:fooe", "di1ei2ee", "x", "i1ei2e"} {
+		if _, err := Decode([]byte(input)); err == nil {
+			t.Errorf("Decode(%q) succeeded, want error", input)
+		}
+	}
+	if _, err := Decode([]byte("4:ab")); !errors.Is(err, ErrUnexpectedEOF) {
+		t.Errorf("truncated string: err = %v, want ErrUnexpectedEOF", err)
+	}
+}
+
+func TestDecodePrefix(t *testing.T) {
+	value, n, err := DecodePrefix([]byte("d1:ai1ee<raw piece data>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 8 || !reflect.DeepEqual(value, map[string]any{"a": int64(1)}) {
+		t.Errorf("got %#v (%d bytes), want dict of 8 bytes", value, n)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	value := map[string]any{
+		"name":  "demo",
+		"size":  int64(-3),
+		"count": 7,
+		"raw":   []byte{0, 1},
+		"path":  []string{"a", "b"},
+		"list":  []any{1, map[string]any{}},
+		"info":  Raw("d1:ai1ee"),
+	}
+	got, err := Encode(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d5:counti7e4:infod1:ai1ee4:listli1edee4:name4:demo4:pathl1:a1:be3:raw2:\x00\x014:sizei-3ee"
+	if string(got) != want {
+		t.Errorf("Encode = %q, want %q", got, want)
+	}
+	if decoded, err := Decode(got); err != nil || decoded.(map[string]any)["name"] != "demo" {
+		t.Errorf("round trip = %#v, %v", decoded, err)
+	}
+	if _, err := Encode(map[string]any{"bad": 1.5}); err == nil {
+		t.Error("Encode(float) succeeded, want error")
+	}
+}
+
+func TestDecodeRawDict(t *testing.T) {
+	raw, err := DecodeRawDict([]byte("d8:announce3:url4:infod4:name1:a6:lengthi1eee"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw["info"]) != "d4:name1:a6:lengthi1ee" || string(raw["announce"]) != "3:url" {
+		t.Errorf("raw = %q", raw)
+	}
+	for _, input := range []string{"", "le", "d4:infoi1e", "d4:infoi1eex"} {
+		if _, err := DecodeRawDict([]byte(input)); err == nil {
+			t.Errorf("DecodeRawDict(%q) succeeded, want error", input)
+		}
+	}
+}
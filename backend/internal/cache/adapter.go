@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// Adapter 是带缓存的适配器装饰器，命中缓存时不再访问上游
+// 只缓存成功且有结果的搜索，错误和空结果总是交给被包装的适配器重新处理
+type Adapter struct {
+	models.Adapter
+	store *Store
+}
+
+// Wrap 用缓存包装适配器
+func Wrap(adapter models.Adapter, store *Store) *Adapter {
+	return &Adapter{Adapter: adapter, store: store}
+}
+
+// Unwrap 返回被包装的适配器
+func (a *Adapter) Unwrap() models.Adapter {
+	return a.Adapter
+}
+
+// Purge 删除该适配器的全部缓存结果，上游地址修改后由注册器调用
+func (a *Adapter) Purge() {
+	a.store.Purge(a.ID())
+}
+
+// Capabilities 返回被包装适配器声明的功能
+func (a *Adapter) Capabilities() models.Capabilities {
+	if capable, ok := a.Adapter.(models.CapableAdapter); ok {
+		return capable.Capabilities()
+	}
+	return models.Capabilities{}
+}
+
+// Search 执行搜索
+func (a *Adapter) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return a.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+// SearchWithOptions 执行搜索
+func (a *Adapter) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	page, err := a.SearchPage(ctx, options)
+	return page.Results, err
+}
+
+// SearchPage 优先返回缓存的结果页，未命中或 context 要求跳过缓存时查询上游并写入缓存
+func (a *Adapter) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	key := Key(a.ID(), options)
+	if !bypassed(ctx) {
+		if page, ok := a.store.Get(key); ok {
+			return page, nil
+		}
+	}
+
+	var page models.ResultPage
+	var err error
+	if paged, ok := a.Adapter.(models.PagedAdapter); ok {
+		page, err = paged.SearchPage(ctx, options)
+	} else {
+		page.Results, err = a.Adapter.SearchWithOptions(ctx, options)
+	}
+	if err == nil && len(page.Results) > 0 {
+		a.store.Put(key, page)
+	}
+	return page, err
+}
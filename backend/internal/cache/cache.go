@@ -0,0 +1,280 @@
+// Package cache 提供适配器搜索结果的 TTL + LRU 缓存，以及包装 models.Adapter 的缓存装饰器
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+const (
+	// DefaultTTL 是缓存条目的默认有效期
+	DefaultTTL = 5 * time.Minute
+	// DefaultMaxEntries 是默认的最大缓存条目数
+	DefaultMaxEntries = 500
+	// persistDelay 是写入缓存后延迟持久化的时间，用于合并短时间内的多次写入
+	persistDelay = 2 * time.Second
+)
+
+// Entry 是一条缓存的结果页
+type Entry struct {
+	Key        string                `json:"key"`
+	Results    []models.SearchResult `json:"results"`
+	Total      int                   `json:"total,omitempty"`
+	TotalPages int                   `json:"totalPages,omitempty"`
+	Endpoint   string                `json:"endpoint,omitempty"`
+	CachedAt   time.Time             `json:"cachedAt"`
+	ExpiresAt  time.Time             `json:"expiresAt"`
+}
+
+func (e *Entry) page() models.ResultPage {
+	return models.ResultPage{
+		Results:    append([]models.SearchResult(nil), e.Results...),
+		Total:      e.Total,
+		TotalPages: e.TotalPages,
+		Endpoint:   e.Endpoint,
+		CachedAt:   e.CachedAt,
+	}
+}
+
+// Store 是按最近使用顺序淘汰的 TTL 缓存，path 非空时持久化到磁盘
+type Store struct {
+	ttl        time.Duration
+	maxEntries int
+	path       string
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // 元素为 *Entry，队首为最近使用
+	entries map[string]*list.Element
+	timer   *time.Timer
+}
+
+// NewStore 创建缓存，path 为空时只保存在内存中；已存在的缓存文件会被加载，过期条目被丢弃
+func NewStore(ttl time.Duration, maxEntries int, path string) (*Store, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	s := &Store{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		path:       path,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 返回未过期的缓存条目，并将其标记为最近使用
+func (s *Store) Get(key string) (models.ResultPage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return models.ResultPage{}, false
+	}
+	entry := elem.Value.(*Entry)
+	if !s.now().Before(entry.ExpiresAt) {
+		s.removeLocked(elem)
+		return models.ResultPage{}, false
+	}
+	s.order.MoveToFront(elem)
+	return entry.page(), true
+}
+
+// Put 保存结果页，超出容量时淘汰最久未使用的条目
+func (s *Store) Put(key string, page models.ResultPage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry := &Entry{
+		Key:        key,
+		Results:    append([]models.SearchResult(nil), page.Results...),
+		Total:      page.Total,
+		TotalPages: page.TotalPages,
+		Endpoint:   page.Endpoint,
+		CachedAt:   now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.order.MoveToFront(elem)
+	} else {
+		s.entries[key] = s.order.PushFront(entry)
+	}
+	for s.order.Len() > s.maxEntries {
+		s.removeLocked(s.order.Back())
+	}
+	s.schedulePersistLocked()
+}
+
+// Purge 删除适配器的全部缓存条目，返回删除的条目数
+func (s *Store) Purge(adapterID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := adapterID + "?"
+	removed := 0
+	for elem := s.order.Front(); elem != nil; {
+		next := elem.Next()
+		if strings.HasPrefix(elem.Value.(*Entry).Key, prefix) {
+			s.removeLocked(elem)
+			removed++
+		}
+		elem = next
+	}
+	if removed > 0 {
+		s.schedulePersistLocked()
+	}
+	return removed
+}
+
+// Len 返回当前缓存条目数（包括尚未清理的过期条目）
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Flush 立即将缓存写入磁盘
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return s.persistLocked()
+}
+
+func (s *Store) removeLocked(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*Entry).Key)
+}
+
+func (s *Store) schedulePersistLocked() {
+	if s.path == "" || s.timer != nil {
+		return
+	}
+	s.timer = time.AfterFunc(persistDelay, func() {
+		if err := s.Flush(); err != nil {
+			log.Printf("[cache] 保存搜索缓存失败: %v", err)
+		}
+	})
+}
+
+func (s *Store) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read cache file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parse cache file: %w", err)
+	}
+
+	// 文件中按最近使用在前的顺序保存
+	now := s.now()
+	for _, entry := range entries {
+		if entry == nil || entry.Key == "" || !now.Before(entry.ExpiresAt) || s.entries[entry.Key] != nil {
+			continue
+		}
+		if s.order.Len() >= s.maxEntries {
+			break
+		}
+		s.entries[entry.Key] = s.order.PushBack(entry)
+	}
+	return nil
+}
+
+func (s *Store) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	now := s.now()
+	entries := make([]*Entry, 0, s.order.Len())
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		if entry := elem.Value.(*Entry); now.Before(entry.ExpiresAt) {
+			entries = append(entries, entry)
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("encode cache file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("ensure cache dir: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("write cache file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("replace cache file: %w", err)
+	}
+	return nil
+}
+
+// Key 返回适配器 ID 与规范化后的搜索选项组成的缓存键
+// 关键字忽略大小写和多余空白；未指定排序字段时忽略排序方向
+func Key(adapterID string, options models.SearchOptions) string {
+	values := url.Values{}
+	values.Set("q", strings.ToLower(strings.Join(strings.Fields(options.Query), " ")))
+	values.Set("page", strconv.Itoa(max(options.Page, 1)))
+	if category := strings.ToLower(strings.TrimSpace(options.Category)); category != "" {
+		values.Set("category", category)
+	}
+	if options.Sort != "" {
+		values.Set("sort", options.Sort)
+		values.Set("order", options.Order)
+	}
+	if options.Filter != "" {
+		values.Set("filter", options.Filter)
+	}
+	return adapterID + "?" + values.Encode()
+}
+
+type bypassKey struct{}
+
+// WithBypass 返回跳过缓存读取的 context，搜索结果仍会写入缓存
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func bypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
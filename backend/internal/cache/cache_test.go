@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+type countingAdapter struct {
+	calls   int
+	results []models.SearchResult
+	err     error
+}
+
+func (a *countingAdapter) ID() string          { return "counting" }
+func (a *countingAdapter) Name() string        { return "counting" }
+func (a *countingAdapter) Description() string { return "" }
+func (a *countingAdapter) Endpoint() string    { return "" }
+
+func (a *countingAdapter) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return a.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+func (a *countingAdapter) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	a.calls++
+	return a.results, a.err
+}
+
+func (a *countingAdapter) Capabilities() models.Capabilities {
+	return models.Capabilities{Pagination: true, PageSize: 10}
+}
+
+func newTestStore(t *testing.T, ttl time.Duration, maxEntries int, path string) (*Store, *time.Time) {
+	t.Helper()
+	s, err := NewStore(ttl, maxEntries, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestAdapterCachesResults(t *testing.T) {
+	store, now := newTestStore(t, time.Minute, 10, "")
+	inner := &countingAdapter{results: []models.SearchResult{{Title: "one", InfoHash: "AAA"}}}
+	adapter := Wrap(inner, store)
+	options := models.SearchOptions{Query: "Ubuntu  ISO", Page: 1}
+
+	first, err := adapter.SearchPage(context.Background(), options)
+	if err != nil || !first.CachedAt.IsZero() {
+		t.Fatalf("first search: cachedAt = %v, err = %v; want upstream result", first.CachedAt, err)
+	}
+
+	// 关键字的大小写和空白差异不影响缓存命中
+	second, err := adapter.SearchPage(context.Background(), models.SearchOptions{Query: "ubuntu iso", Page: 1})
+	if err != nil || len(second.Results) != 1 || !second.CachedAt.Equal(*now) {
+		t.Fatalf("second search: %d results, cachedAt = %v, err = %v; want cached", len(second.Results), second.CachedAt, err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("upstream called %d times, want 1", inner.calls)
+	}
+
+	if _, err := adapter.SearchPage(WithBypass(context.Background()), options); err != nil || inner.calls != 2 {
+		t.Errorf("bypass: calls = %d, err = %v; want upstream call", inner.calls, err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if page, _ := adapter.SearchPage(context.Background(), options); !page.CachedAt.IsZero() || inner.calls != 3 {
+		t.Errorf("after ttl: calls = %d, cachedAt = %v; want expired entry refetched", inner.calls, page.CachedAt)
+	}
+
+	if caps := adapter.Capabilities(); !caps.Pagination || caps.PageSize != 10 {
+		t.Errorf("Capabilities = %+v, want wrapped adapter's", caps)
+	}
+}
+
+func TestAdapterSkipsErrorsAndEmptyResults(t *testing.T) {
+	store, _ := newTestStore(t, time.Minute, 10, "")
+	inner := &countingAdapter{err: errors.New("boom")}
+	adapter := Wrap(inner, store)
+	options := models.SearchOptions{Query: "x", Page: 1}
+
+	adapter.SearchPage(context.Background(), options)
+	adapter.SearchPage(context.Background(), options)
+	inner.err = nil
+	adapter.SearchPage(context.Background(), options)
+	adapter.SearchPage(context.Background(), options)
+
+	if inner.calls != 4 || store.Len() != 0 {
+		t.Errorf("calls = %d, cached = %d; want 4 upstream calls and nothing cached", inner.calls, store.Len())
+	}
+}
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store, _ := newTestStore(t, time.Minute, 2, "")
+	page := models.ResultPage{Results: []models.SearchResult{{Title: "r"}}}
+
+	store.Put("a", page)
+	store.Put("b", page)
+	store.Get("a")
+	store.Put("c", page)
+
+	if _, ok := store.Get("b"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := store.Get(key); !ok {
+			t.Errorf("entry %s evicted", key)
+		}
+	}
+}
+
+func TestStorePurge(t *testing.T) {
+	s, _ := newTestStore(t, time.Minute, 10, "")
+	page := models.ResultPage{Results: []models.SearchResult{{Title: "x"}}}
+	s.Put(Key("nyaa", models.SearchOptions{Query: "a"}), page)
+	s.Put(Key("nyaa", models.SearchOptions{Query: "b"}), page)
+	s.Put(Key("nyaarss", models.SearchOptions{Query: "a"}), page)
+
+	if removed := s.Purge("nyaa"); removed != 2 {
+		t.Errorf("Purge removed %d entries, want 2", removed)
+	}
+	if _, ok := s.Get(Key("nyaa", models.SearchOptions{Query: "a"})); ok {
+		t.Error("purged entry still cached")
+	}
+	if _, ok := s.Get(Key("nyaarss", models.SearchOptions{Query: "a"})); !ok {
+		t.Error("entry of another adapter was purged")
+	}
+}
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	store, now := newTestStore(t, time.Minute, 10, path)
+	store.Put("fresh", models.ResultPage{Results: []models.SearchResult{{Title: "fresh"}}, Total: 42})
+	*now = now.Add(50 * time.Second)
+	store.Put("newer", models.ResultPage{Results: []models.SearchResult{{Title: "newer"}}})
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewStore(time.Minute, 10, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.now = func() time.Time { return now.Add(30 * time.Second) }
+
+	// "fresh" 写入 80 秒后已过期
+	if _, ok := reloaded.Get("fresh"); ok {
+		t.Error("expired entry survived reload")
+	}
+	page, ok := reloaded.Get("newer")
+	if !ok || page.Results[0].Title != "newer" || !page.CachedAt.Equal(*now) {
+		t.Errorf("reloaded entry = %+v, %v", page, ok)
+	}
+}
+
+func TestKeyNormalizesOptions(t *testing.T) {
+	same := []models.SearchOptions{
+		{Query: "Ubuntu ISO", Page: 1},
+		{Query: "  ubuntu   iso ", Page: 0},
+		{Query: "ubuntu iso", Page: 1, Order: models.OrderAsc},
+	}
+	want := Key("nyaa", same[0])
+	for _, options := range same[1:] {
+		if got := Key("nyaa", options); got != want {
+			t.Errorf("Key(%+v) = %q, want %q", options, got, want)
+		}
+	}
+
+	different := []models.SearchOptions{
+		{Query: "ubuntu iso", Page: 2},
+		{Query: "ubuntu iso", Page: 1, Category: models.CategorySoftware},
+		{Query: "ubuntu iso", Page: 1, Sort: models.SortSize, Order: models.OrderAsc},
+		{Query: "ubuntu iso", Page: 1, Filter: models.FilterTrusted},
+	}
+	for _, options := range different {
+		if got := Key("nyaa", options); got == want {
+			t.Errorf("Key(%+v) collides with the default options", options)
+		}
+	}
+	if Key("apibay", same[0]) == want {
+		t.Error("keys for different adapters collide")
+	}
+}
@@ -0,0 +1,209 @@
+// Package coalesce 合并相同的并发适配器搜索，使重复的请求共享同一次上游调用和结果
+package coalesce
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/seedmanage/backend/internal/cache"
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// call 是一次正在进行的上游调用
+type call struct {
+	done    chan struct{}
+	page    models.ResultPage
+	err     error
+	waiters int
+	ctx     *callContext
+}
+
+// Group 按键合并并发调用
+// 调用在独立于调用方取消信号的 context 中执行，截止时间为所有等待者中最晚的截止时间，
+// 单个等待者取消或超时只会让它自己返回，所有等待者都离开后才取消上游调用
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do 执行 fn 或等待相同键上正在进行的调用，shared 表示结果来自其他调用方发起的调用
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) (models.ResultPage, error)) (page models.ResultPage, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, shared := g.calls[key]
+	// 已经超过截止时间的调用不再接受新的等待者
+	if shared && c.ctx.Err() == nil {
+		c.waiters++
+		c.ctx.join(ctx)
+	} else {
+		shared = false
+		c = &call{done: make(chan struct{}), waiters: 1, ctx: newCallContext(ctx)}
+		g.calls[key] = c
+		go g.run(key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		// 每个等待者拿到独立的结果切片，避免后续排序相互影响
+		page = c.page
+		page.Results = append([]models.SearchResult(nil), c.page.Results...)
+		return page, shared, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.ctx.stop()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return models.ResultPage{}, shared, ctx.Err()
+	}
+}
+
+func (g *Group) run(key string, c *call, fn func(ctx context.Context) (models.ResultPage, error)) {
+	defer c.ctx.stop()
+	c.page, c.err = fn(c.ctx)
+
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// callContext 是上游调用使用的 context：保留首个调用方的值但不随其取消，
+// 截止时间为所有等待者中最晚的截止时间，任一等待者没有截止时间时调用也没有截止时间
+// 到达截止时间后 Err 返回 context.DeadlineExceeded
+type callContext struct {
+	context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	deadline time.Time
+	bounded  bool
+	timer    *time.Timer
+	expired  bool
+}
+
+func newCallContext(first context.Context) *callContext {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(first))
+	c := &callContext{Context: ctx, cancel: cancel, bounded: true}
+	c.join(first)
+	return c
+}
+
+// join 把等待者的截止时间合并到调用的截止时间中
+func (c *callContext) join(waiter context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.bounded {
+		return
+	}
+
+	deadline, ok := waiter.Deadline()
+	switch {
+	case !ok:
+		c.bounded = false
+		c.deadline = time.Time{}
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+	case c.timer == nil:
+		c.deadline = deadline
+		c.timer = time.AfterFunc(time.Until(deadline), c.expire)
+	case deadline.After(c.deadline):
+		c.deadline = deadline
+		c.timer.Reset(time.Until(deadline))
+	}
+}
+
+func (c *callContext) expire() {
+	c.mu.Lock()
+	c.expired = true
+	c.mu.Unlock()
+	c.cancel()
+}
+
+// stop 取消调用并释放计时器
+func (c *callContext) stop() {
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.mu.Unlock()
+	c.cancel()
+}
+
+func (c *callContext) Deadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadline, c.bounded
+}
+
+func (c *callContext) Err() error {
+	err := c.Context.Err()
+	if err == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.expired {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// Adapter 是合并并发搜索的适配器装饰器，键为适配器 ID 与规范化后的搜索选项
+type Adapter struct {
+	models.Adapter
+	group Group
+}
+
+// Wrap 用并发合并包装适配器
+func Wrap(adapter models.Adapter) *Adapter {
+	return &Adapter{Adapter: adapter}
+}
+
+// Unwrap 返回被包装的适配器
+func (a *Adapter) Unwrap() models.Adapter {
+	return a.Adapter
+}
+
+// Capabilities 返回被包装适配器声明的功能
+func (a *Adapter) Capabilities() models.Capabilities {
+	if capable, ok := a.Adapter.(models.CapableAdapter); ok {
+		return capable.Capabilities()
+	}
+	return models.Capabilities{}
+}
+
+// Search 执行搜索
+func (a *Adapter) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return a.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+// SearchWithOptions 执行搜索
+func (a *Adapter) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	page, err := a.SearchPage(ctx, options)
+	return page.Results, err
+}
+
+// SearchPage 执行搜索，相同选项的并发搜索共享同一次上游调用
+func (a *Adapter) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	page, shared, err := a.group.Do(ctx, cache.Key(a.ID(), options), func(ctx context.Context) (models.ResultPage, error) {
+		if paged, ok := a.Adapter.(models.PagedAdapter); ok {
+			return paged.SearchPage(ctx, options)
+		}
+		results, err := a.Adapter.SearchWithOptions(ctx, options)
+		return models.ResultPage{Results: results}, err
+	})
+	page.Shared = shared
+	return page, err
+}
@@ -0,0 +1,244 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// blockingAdapter 在 release 关闭前阻塞搜索，并记录上游调用次数和调用 context 的结束原因
+type blockingAdapter struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	ctxErr  chan error
+}
+
+func newBlockingAdapter() *blockingAdapter {
+	return &blockingAdapter{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+		ctxErr:  make(chan error, 10),
+	}
+}
+
+func (a *blockingAdapter) ID() string          { return "blocking" }
+func (a *blockingAdapter) Name() string        { return "blocking" }
+func (a *blockingAdapter) Description() string { return "" }
+func (a *blockingAdapter) Endpoint() string    { return "" }
+
+func (a *blockingAdapter) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return a.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+func (a *blockingAdapter) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	a.calls.Add(1)
+	a.started <- struct{}{}
+	select {
+	case <-a.release:
+		a.ctxErr <- nil
+		return []models.SearchResult{{Title: options.Query, InfoHash: "AAA"}}, nil
+	case <-ctx.Done():
+		a.ctxErr <- ctx.Err()
+		return nil, ctx.Err()
+	}
+}
+
+type outcome struct {
+	page models.ResultPage
+	err  error
+}
+
+func search(ctx context.Context, adapter *Adapter, query string) <-chan outcome {
+	out := make(chan outcome, 1)
+	go func() {
+		page, err := adapter.SearchPage(ctx, models.SearchOptions{Query: query, Page: 1})
+		out <- outcome{page, err}
+	}()
+	return out
+}
+
+// waitForWaiters 等待指定数量的调用方加入正在进行的调用
+func waitForWaiters(t *testing.T, g *Group, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		total := 0
+		for _, c := range g.calls {
+			total += c.waiters
+		}
+		g.mu.Unlock()
+		if total == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters", n)
+}
+
+func TestConcurrentSearchesShareOneCall(t *testing.T) {
+	inner := newBlockingAdapter()
+	adapter := Wrap(inner)
+
+	var outs []<-chan outcome
+	for range 3 {
+		outs = append(outs, search(context.Background(), adapter, "Ubuntu"))
+	}
+	<-inner.started
+	waitForWaiters(t, &adapter.group, 3)
+	close(inner.release)
+
+	shared := 0
+	for _, out := range outs {
+		got := <-out
+		if got.err != nil || len(got.page.Results) != 1 {
+			t.Fatalf("got %d results, err %v", len(got.page.Results), got.err)
+		}
+		if got.page.Shared {
+			shared++
+		}
+	}
+	if calls := inner.calls.Load(); calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+	if shared != 2 {
+		t.Errorf("%d results marked shared, want 2", shared)
+	}
+}
+
+func TestCancelledWaiterDoesNotCancelOthers(t *testing.T) {
+	inner := newBlockingAdapter()
+	adapter := Wrap(inner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := search(ctx, adapter, "x")
+	<-inner.started
+	second := search(context.Background(), adapter, "x")
+	waitForWaiters(t, &adapter.group, 2)
+
+	// 发起调用的请求被取消，另一个等待者仍然拿到结果
+	cancel()
+	if got := <-first; !errors.Is(got.err, context.Canceled) {
+		t.Fatalf("cancelled waiter err = %v, want context.Canceled", got.err)
+	}
+	close(inner.release)
+	if got := <-second; got.err != nil || len(got.page.Results) != 1 {
+		t.Fatalf("remaining waiter got %d results, err %v", len(got.page.Results), got.err)
+	}
+	if err := <-inner.ctxErr; err != nil {
+		t.Errorf("upstream context ended with %v, want still running", err)
+	}
+}
+
+func TestCallUsesLatestWaiterDeadline(t *testing.T) {
+	inner := newBlockingAdapter()
+	adapter := Wrap(inner)
+
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	first := search(short, adapter, "x")
+	<-inner.started
+	long, cancelLong := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelLong()
+	second := search(long, adapter, "x")
+	waitForWaiters(t, &adapter.group, 2)
+
+	// 发起调用的请求截止时间较短，超时后调用继续为截止时间更晚的等待者执行
+	if got := <-first; !errors.Is(got.err, context.DeadlineExceeded) {
+		t.Fatalf("short waiter err = %v, want context.DeadlineExceeded", got.err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(inner.release)
+	if got := <-second; got.err != nil || len(got.page.Results) != 1 {
+		t.Fatalf("long waiter got %d results, err %v", len(got.page.Results), got.err)
+	}
+	if err := <-inner.ctxErr; err != nil {
+		t.Errorf("upstream context ended with %v, want still running", err)
+	}
+}
+
+func TestCallContextDeadline(t *testing.T) {
+	early, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	late, cancelLate := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelLate()
+
+	c := newCallContext(early)
+	c.join(late)
+	if deadline, ok := c.Deadline(); !ok || !deadline.Equal(mustDeadline(late)) {
+		t.Errorf("deadline = %v, %v; want the later waiter's deadline", deadline, ok)
+	}
+	c.join(context.Background())
+	if _, ok := c.Deadline(); ok {
+		t.Error("waiter without deadline should remove the call deadline")
+	}
+	c.stop()
+
+	expiring := newCallContext(early)
+	<-expiring.Done()
+	if !errors.Is(expiring.Err(), context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", expiring.Err())
+	}
+}
+
+func mustDeadline(ctx context.Context) time.Time {
+	deadline, _ := ctx.Deadline()
+	return deadline
+}
+
+func TestUpstreamCancelledWhenAllWaitersLeave(t *testing.T) {
+	inner := newBlockingAdapter()
+	adapter := Wrap(inner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := search(ctx, adapter, "x")
+	<-inner.started
+	cancel()
+	<-out
+
+	select {
+	case err := <-inner.ctxErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("upstream context ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("upstream call not cancelled after all waiters left")
+	}
+
+	// 之后的相同搜索会发起新的上游调用
+	next := search(context.Background(), adapter, "x")
+	<-inner.started
+	close(inner.release)
+	if got := <-next; got.err != nil || got.page.Shared {
+		t.Errorf("new search err = %v, shared = %v; want fresh call", got.err, got.page.Shared)
+	}
+}
+
+func TestDifferentOptionsAreNotCoalesced(t *testing.T) {
+	inner := newBlockingAdapter()
+	adapter := Wrap(inner)
+
+	var wg sync.WaitGroup
+	for _, query := range []string{"a", "b"} {
+		wg.Add(1)
+		out := search(context.Background(), adapter, query)
+		go func() {
+			defer wg.Done()
+			<-out
+		}()
+	}
+	<-inner.started
+	<-inner.started
+	close(inner.release)
+	wg.Wait()
+
+	if calls := inner.calls.Load(); calls != 2 {
+		t.Errorf("upstream called %d times, want 2", calls)
+	}
+}
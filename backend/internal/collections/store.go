@@ -15,7 +15,8 @@ import (
 
 // Store handles file-based storage for collections
 type Store struct {
-    dir string
+    dir       string
+    listeners []func(id string)
 }
 
 // NewStore creates a new collections store
@@ -30,6 +31,18 @@ func NewStore(dir string) (*Store, error) {
     return &Store{dir: absDir}, nil
 }
 
+// OnChange registers fn to be called with the collection ID after a collection is written or deleted.
+// It must be called before the store is used concurrently.
+func (s *Store) OnChange(fn func(id string)) {
+    s.listeners = append(s.listeners, fn)
+}
+
+func (s *Store) notify(id string) {
+    for _, fn := range s.listeners {
+        fn(id)
+    }
+}
+
 // CollectionMeta is the metadata stored in each collection JSON file
 type CollectionMeta struct {
     ID        string    `json:"id"`
@@ -120,6 +133,7 @@ func (s *Store) Delete(id string) error {
         }
         return fmt.Errorf("collections: failed to delete: %w", err)
     }
+    s.notify(id)
     return nil
 }
 
@@ -215,6 +229,24 @@ func (s *Store) UpdateItem(collectionID, magnet string, starred bool) (*models.C
     return updatedItem, nil
 }
 
+// UpdateItems applies update to every item of a collection and saves it
+func (s *Store) UpdateItems(collectionID string, update func(item *models.CollectionItem)) (*CollectionFile, error) {
+    cf, err := s.Get(collectionID)
+    if err != nil {
+        return nil, err
+    }
+
+    for i := range cf.Items {
+        update(&cf.Items[i])
+    }
+
+    if err := s.write(collectionID, cf); err != nil {
+        return nil, err
+    }
+
+    return cf, nil
+}
+
 // ImportCSVToCollection parses a CSV and appends items to an existing collection
 func (s *Store) ImportCSVToCollection(id string, csvContent string) ([]models.CollectionItem, error) {
     items, err := s.parseCSV(csvContent)
@@ -354,6 +386,7 @@ func (s *Store) write(id string, cf *CollectionFile) error {
         return fmt.Errorf("collections: failed to write: %w", err)
     }
 
+    s.notify(id)
     return nil
 }
 
@@ -17,8 +17,23 @@ const (
     NyaaEndpointEnv      = "NYAA_ENDPOINT"
     SukebeiEndpointEnv      = "SUKEBEI_ENDPOINT"
     HTMLSukebeiEndpointEnv = "HTML_SUKEBEI_ENDPOINT"
+    NyaaRSSEndpointEnv      = "NYAA_RSS_ENDPOINT"
+    SukebeiRSSEndpointEnv   = "SUKEBEI_RSS_ENDPOINT"
+    HTMLScrapersDirEnv      = "HTML_SCRAPERS_DIR"
+    TorznabConfigEnv        = "TORZNAB_CONFIG"
+    NetworkConfigEnv        = "NETWORK_CONFIG"
+    PluginsDirEnv           = "PLUGINS_DIR"
+    PluginTimeoutEnv        = "PLUGIN_TIMEOUT"
+    AdapterSettingsEnv      = "ADAPTER_SETTINGS_FILE"
     SampleDataEnv           = "SAMPLE_DATA_FILE"
     SearchHistoryFileEnv = "SEARCH_HISTORY_FILE"
+    SearchCacheTTLEnv    = "SEARCH_CACHE_TTL"
+    SearchCacheSizeEnv   = "SEARCH_CACHE_SIZE"
+    SearchCacheFileEnv   = "SEARCH_CACHE_FILE"
+    MetadataCacheDirEnv  = "METADATA_CACHE_DIR"
     PasswordEnv          = "PASSWORD"
+    TorznabAPIKeyEnv     = "TORZNAB_API_KEY"
+    CircuitThresholdEnv  = "CIRCUIT_FAILURE_THRESHOLD"
+    CircuitCooldownEnv   = "CIRCUIT_COOLDOWN"
 )
 
@@ -36,6 +36,7 @@ type Store struct {
     resultsPerEntry int
     mu              sync.Mutex
     entries         []Entry
+    listeners       []func()
 }
 
 // NewStore 创建文件存储实例
@@ -61,8 +62,21 @@ func NewStore(path string, limit, resultsPerEntry int) (*Store, error) {
     return store, nil
 }
 
+// OnChange 注册历史记录变化后的回调，回调在释放锁之后执行，可以调用 List
+// 应在开始处理请求前调用
+func (s *Store) OnChange(fn func()) {
+    s.listeners = append(s.listeners, fn)
+}
+
+func (s *Store) notify() {
+    for _, fn := range s.listeners {
+        fn()
+    }
+}
+
 // Record 保存一次搜索结果
 func (s *Store) Record(response models.SearchResponse) error {
+    defer s.notify()
     s.mu.Lock()
     defer s.mu.Unlock()
 
@@ -101,6 +115,7 @@ func (s *Store) List() []Entry {
 
 // Delete 删除指定ID的历史记录
 func (s *Store) Delete(id string) error {
+    defer s.notify()
     s.mu.Lock()
     defer s.mu.Unlock()
 
@@ -0,0 +1,287 @@
+// Package httpclient 提供适配器共用的 HTTP 客户端工厂，支持代理、超时、请求头、Cookie、TLS 配置、幂等请求的重试以及按主机共享的限速
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent 是未配置 User-Agent 时使用的默认值
+const DefaultUserAgent = "magnetsearch-backend/1.0"
+
+// DefaultConfigKey 是配置文件中适用于所有适配器的默认配置键
+const DefaultConfigKey = "*"
+
+// Config 描述单个适配器的网络配置，零值表示使用默认设置
+type Config struct {
+	// Proxy 为代理地址，支持 http://、https://、socks5:// 和 socks5h://
+	Proxy     string            `json:"proxy,omitempty"`
+	Timeout   Duration          `json:"timeout,omitempty"`
+	UserAgent string            `json:"userAgent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Cookies   map[string]string `json:"cookies,omitempty"`
+	TLS       TLSConfig         `json:"tls,omitempty"`
+	Retry     RetryConfig       `json:"retry,omitempty"`
+	RateLimit RateLimitConfig   `json:"rateLimit,omitempty"`
+}
+
+// TLSConfig 描述 TLS 设置
+type TLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	ServerName         string `json:"serverName,omitempty"`
+	// MinVersion 为最低 TLS 版本，取值 "1.0"、"1.1"、"1.2" 或 "1.3"
+	MinVersion string `json:"minVersion,omitempty"`
+	// CAFile 为额外信任的 PEM 格式 CA 证书文件
+	CAFile string `json:"caFile,omitempty"`
+}
+
+// Duration 支持以 "15s" 这样的字符串或秒数在 JSON 中表示时长
+type Duration time.Duration
+
+// UnmarshalJSON 解析字符串或数字形式的时长
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// MarshalJSON 以字符串形式输出时长
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Merge 返回以 c 为基础、用 override 中非零字段覆盖后的配置，请求头和 Cookie 按键合并
+func (c Config) Merge(override Config) Config {
+	merged := c
+	if override.Proxy != "" {
+		merged.Proxy = override.Proxy
+	}
+	if override.Timeout > 0 {
+		merged.Timeout = override.Timeout
+	}
+	if override.UserAgent != "" {
+		merged.UserAgent = override.UserAgent
+	}
+	merged.Headers = mergeMaps(c.Headers, override.Headers)
+	merged.Cookies = mergeMaps(c.Cookies, override.Cookies)
+	if override.TLS != (TLSConfig{}) {
+		merged.TLS = override.TLS
+	}
+	merged.Retry = c.Retry.merge(override.Retry)
+	merged.RateLimit = c.RateLimit.merge(override.RateLimit)
+	return merged
+}
+
+func mergeMaps(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Configs 是按适配器 ID 索引的网络配置
+type Configs map[string]Config
+
+// LoadConfigs 从 JSON 文件加载网络配置，文件不存在时返回空配置
+func LoadConfigs(path string) (Configs, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Configs{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var configs Configs
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("parse network config: %w", err)
+	}
+	return configs, nil
+}
+
+// For 返回适配器的最终配置：默认配置（"*"）合并该适配器的配置
+func (c Configs) For(adapterID string) Config {
+	return c[DefaultConfigKey].Merge(c[adapterID])
+}
+
+// transports 缓存按代理和 TLS 设置区分的底层 Transport，使相同配置的适配器共享连接池
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[transportKey]*http.Transport)
+)
+
+type transportKey struct {
+	proxy string
+	tls   TLSConfig
+}
+
+// NewClient 根据配置创建 HTTP 客户端，未配置超时时使用 defaultTimeout
+func NewClient(cfg Config, defaultTimeout time.Duration) (*http.Client, error) {
+	if err := cfg.RateLimit.validate(); err != nil {
+		return nil, err
+	}
+	base, err := transportFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout)
+	}
+
+	var rt http.RoundTripper = base
+	if cfg.UserAgent != "" || len(cfg.Headers) > 0 || len(cfg.Cookies) > 0 {
+		headers := make(http.Header, len(cfg.Headers)+1)
+		for k, v := range cfg.Headers {
+			headers.Set(k, v)
+		}
+		if cfg.UserAgent != "" {
+			headers.Set("User-Agent", cfg.UserAgent)
+		}
+		rt = &headerTransport{base: rt, headers: headers, cookies: cfg.Cookies}
+	}
+	// 限速位于重试之内，每次重试同样消耗令牌
+	rt = newRateLimitTransport(rt, cfg.RateLimit)
+	rt = newRetryTransport(rt, cfg.Retry)
+
+	return &http.Client{Timeout: timeout, Transport: rt}, nil
+}
+
+// MustNewClient 与 NewClient 相同，配置无效时 panic，仅用于零值配置
+func MustNewClient(cfg Config, defaultTimeout time.Duration) *http.Client {
+	client, err := NewClient(cfg, defaultTimeout)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// DefaultHeaders 返回适配器请求使用的默认请求头
+func DefaultHeaders() http.Header {
+	return http.Header{
+		"User-Agent": []string{DefaultUserAgent},
+	}
+}
+
+func transportFor(cfg Config) (*http.Transport, error) {
+	key := transportKey{proxy: strings.TrimSpace(cfg.Proxy), tls: cfg.TLS}
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if t, ok := transports[key]; ok {
+		return t, nil
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if key.proxy != "" {
+		proxyURL, err := url.Parse(key.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", key.proxy, err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if key.tls != (TLSConfig{}) {
+		tlsConfig, err := buildTLSConfig(key.tls)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+
+	transports[key] = t
+	return t, nil
+}
+
+func buildTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ServerName:         cfg.ServerName,
+	}
+
+	switch cfg.MinVersion {
+	case "":
+	case "1.0":
+		tlsConfig.MinVersion = tls.VersionTLS10
+	case "1.1":
+		tlsConfig.MinVersion = tls.VersionTLS11
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported tls minVersion %q", cfg.MinVersion)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls caFile: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls caFile %s contains no certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// headerTransport 为每个请求注入配置的请求头和 Cookie，配置的值优先于适配器的默认值
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+	cookies map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	for name, value := range t.cookies {
+		if _, err := req.Cookie(name); errors.Is(err, http.ErrNoCookie) {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+	}
+	return t.base.RoundTrip(req)
+}
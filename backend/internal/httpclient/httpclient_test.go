@@ -0,0 +1,226 @@
+package httpclient
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClientInjectsHeadersAndCookies(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{
+		UserAgent: "custom-agent",
+		Headers:   map[string]string{"Accept-Language": "ja"},
+		Cookies:   map[string]string{"session": "configured", "theme": "dark"},
+	}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header = DefaultHeaders()
+	req.AddCookie(&http.Cookie{Name: "session", Value: "adapter"})
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if ua := got.Header.Get("User-Agent"); ua != "custom-agent" {
+		t.Errorf("User-Agent = %q, want custom-agent", ua)
+	}
+	if lang := got.Header.Get("Accept-Language"); lang != "ja" {
+		t.Errorf("Accept-Language = %q, want ja", lang)
+	}
+	// 请求已带的 Cookie 不被覆盖，缺失的 Cookie 由配置补充
+	if c, err := got.Cookie("session"); err != nil || c.Value != "adapter" {
+		t.Errorf("session cookie = %v, %v; want adapter", c, err)
+	}
+	if c, err := got.Cookie("theme"); err != nil || c.Value != "dark" {
+		t.Errorf("theme cookie = %v, %v; want dark", c, err)
+	}
+	if client.Timeout != time.Second {
+		t.Errorf("Timeout = %v, want default 1s", client.Timeout)
+	}
+}
+
+func TestClientUsesHTTPProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(Config{Proxy: proxy.URL, Timeout: Duration(2 * time.Second)}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Timeout != 2*time.Second {
+		t.Errorf("Timeout = %v, want configured 2s", client.Timeout)
+	}
+
+	resp, err := client.Get("http://upstream.invalid/search?q=test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "via proxy" {
+		t.Errorf("body = %q", body)
+	}
+	if proxied != "http://upstream.invalid/search?q=test" {
+		t.Errorf("proxy received %q, want absolute upstream URL", proxied)
+	}
+}
+
+func TestClientUsesSOCKS5Proxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	proxyAddr, dialed := startSOCKS5(t)
+
+	client, err := NewClient(Config{Proxy: "socks5://" + proxyAddr}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "ok" {
+		t.Errorf("body = %q", body)
+	}
+	if got := <-dialed; got != upstream.Listener.Addr().String() {
+		t.Errorf("socks5 dialed %q, want %q", got, upstream.Listener.Addr().String())
+	}
+}
+
+func TestNewClientRejectsInvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{Proxy: "ftp://127.0.0.1:21"},
+		{TLS: TLSConfig{MinVersion: "0.9"}},
+		{TLS: TLSConfig{CAFile: "testdata/missing.pem"}},
+	} {
+		if _, err := NewClient(cfg, time.Second); err == nil {
+			t.Errorf("NewClient(%+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+func TestConfigsForMergesDefaults(t *testing.T) {
+	var configs Configs
+	data := `{
+		"*": {"timeout": "20s", "userAgent": "default", "headers": {"Accept": "*/*"}},
+		"nyaa-rss": {"proxy": "socks5://127.0.0.1:1080", "timeout": 5, "headers": {"Accept-Language": "en"}}
+	}`
+	if err := json.Unmarshal([]byte(data), &configs); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := configs.For("nyaa-rss")
+	if cfg.Proxy != "socks5://127.0.0.1:1080" || time.Duration(cfg.Timeout) != 5*time.Second || cfg.UserAgent != "default" {
+		t.Errorf("For(nyaa-rss) = %+v", cfg)
+	}
+	if cfg.Headers["Accept"] != "*/*" || cfg.Headers["Accept-Language"] != "en" {
+		t.Errorf("headers = %v, want merged", cfg.Headers)
+	}
+
+	if other := configs.For("apibay"); time.Duration(other.Timeout) != 20*time.Second || other.Proxy != "" {
+		t.Errorf("For(apibay) = %+v, want defaults only", other)
+	}
+}
+
+// startSOCKS5 启动一个只支持无认证 CONNECT 的 SOCKS5 代理，返回监听地址和每次连接的目标地址
+func startSOCKS5(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	dialed := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, dialed)
+		}
+	}()
+	return ln.Addr().String(), dialed
+}
+
+func serveSOCKS5(conn net.Conn, dialed chan<- string) {
+	defer conn.Close()
+
+	// 协商：VER NMETHODS METHODS...，回复无需认证
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, head[1])); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 0})
+
+	// 请求：VER CMD RSV ATYP DST.ADDR DST.PORT
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		n := make([]byte, 1)
+		io.ReadFull(conn, n)
+		name := make([]byte, n[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	select {
+	case dialed <- addr:
+	default:
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
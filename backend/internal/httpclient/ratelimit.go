@@ -0,0 +1,190 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited 表示请求超出了上游主机的速率限制预算而未发出
+var ErrRateLimited = errors.New("rate limited")
+
+// 超出速率限制时的处理方式
+const (
+	// RateLimitWait 在 context 截止时间内排队等待令牌，无法及时获得令牌时立即失败
+	RateLimitWait = "wait"
+	// RateLimitFail 没有可用令牌时立即失败
+	RateLimitFail = "fail"
+)
+
+// RateLimitConfig 描述按上游主机共享的令牌桶限速，Rate 为 0 表示不限速；同一主机配置了多个限额时以最严格的为准
+// 限速位于重试之内，每次重试同样消耗一个令牌
+type RateLimitConfig struct {
+	// Rate 为每秒补充的令牌数（即每秒允许的请求数）
+	Rate float64 `json:"rate,omitempty"`
+	// Burst 为令牌桶容量，默认 1
+	Burst int `json:"burst,omitempty"`
+	// Mode 取 RateLimitWait（默认）或 RateLimitFail
+	Mode string `json:"mode,omitempty"`
+}
+
+func (c RateLimitConfig) merge(override RateLimitConfig) RateLimitConfig {
+	if override.Rate > 0 {
+		c.Rate = override.Rate
+	}
+	if override.Burst > 0 {
+		c.Burst = override.Burst
+	}
+	if override.Mode != "" {
+		c.Mode = override.Mode
+	}
+	return c
+}
+
+func (c RateLimitConfig) validate() error {
+	if c.Rate < 0 || c.Burst < 0 {
+		return fmt.Errorf("invalid rateLimit rate %v burst %d", c.Rate, c.Burst)
+	}
+	switch c.Mode {
+	case "", RateLimitWait, RateLimitFail:
+		return nil
+	}
+	return fmt.Errorf("unsupported rateLimit mode %q", c.Mode)
+}
+
+// limitTTL 是限额在最后一次使用之后继续参与计算的时间
+// 被替换的客户端（例如修改配置后重建）不再发出请求，其限额过期后主机的限额随之放宽
+const limitTTL = 10 * time.Minute
+
+// buckets 是按上游主机共享的令牌桶，所有适配器访问同一主机时消耗同一个桶，
+// 桶的速率和容量取最近访问过该主机的客户端中最严格的限额，多个适配器合起来也不会超出主机的预算
+var (
+	bucketsMu sync.Mutex
+	buckets   = make(map[string]*bucket)
+)
+
+// bucketFor 返回主机对应的令牌桶
+func bucketFor(host string) *bucket {
+	host = strings.ToLower(host)
+
+	bucketsMu.Lock()
+	defer bucketsMu.Unlock()
+	b, ok := buckets[host]
+	if !ok {
+		b = &bucket{}
+		buckets[host] = b
+	}
+	return b
+}
+
+// limit 是一个客户端为主机配置的限额
+type limit struct {
+	rate  float64
+	burst int
+}
+
+// bucket 是一个令牌桶，令牌可以透支以表示已排队等待的请求
+type bucket struct {
+	mu sync.Mutex
+	// limits 记录每个限额最后一次使用的时间，rate 和 burst 是其中最严格的限额
+	limits map[limit]time.Time
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// applyLocked 记录本次请求使用的限额，并把 rate 和 burst 更新为未过期限额中最严格的值
+func (b *bucket) applyLocked(now time.Time, l limit) {
+	if b.limits == nil {
+		b.limits = make(map[limit]time.Time)
+	}
+	b.limits[l] = now
+	b.rate, b.burst = l.rate, float64(l.burst)
+	for other, used := range b.limits {
+		if now.Sub(used) > limitTTL {
+			delete(b.limits, other)
+			continue
+		}
+		b.rate = min(b.rate, other.rate)
+		b.burst = min(b.burst, float64(other.burst))
+	}
+}
+
+// reserve 按限额 l（与其他客户端的限额取最严格的）预留一个令牌并返回需要等待的时间；
+// 等待时间超过 maxWait 时不预留并返回 false
+func (b *bucket) reserve(now time.Time, l limit, maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 补充令牌使用上次请求时生效的速率
+	if b.last.IsZero() {
+		b.applyLocked(now, l)
+		b.tokens = b.burst
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		b.applyLocked(now, l)
+		b.tokens = min(b.burst, b.tokens)
+	}
+	b.last = now
+
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return wait, false
+	}
+	b.tokens--
+	return wait, true
+}
+
+// cancel 归还一个未使用的预留令牌
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// rateLimitTransport 在发出请求前从目标主机的令牌桶中获取令牌
+type rateLimitTransport struct {
+	base  http.RoundTripper
+	limit RateLimitConfig
+	now   func() time.Time
+}
+
+func newRateLimitTransport(base http.RoundTripper, limit RateLimitConfig) http.RoundTripper {
+	if limit.Rate <= 0 {
+		return base
+	}
+	return &rateLimitTransport{base: base, limit: limit, now: time.Now}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	b := bucketFor(req.URL.Host)
+
+	var maxWait time.Duration
+	if t.limit.Mode != RateLimitFail {
+		maxWait = time.Duration(math.MaxInt64)
+		if deadline, ok := ctx.Deadline(); ok {
+			maxWait = deadline.Sub(t.now())
+		}
+	}
+
+	wait, ok := b.reserve(t.now(), limit{rate: t.limit.Rate, burst: max(t.limit.Burst, 1)}, maxWait)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (next slot in %s)", ErrRateLimited, req.URL.Host, wait.Round(time.Millisecond))
+	}
+	if wait > 0 {
+		if err := sleepContext(ctx, wait); err != nil {
+			b.cancel()
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,183 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// resetBuckets 清空共享的令牌桶，测试之间互不影响
+func resetBuckets(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		bucketsMu.Lock()
+		defer bucketsMu.Unlock()
+		clear(buckets)
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := &bucket{}
+	l := limit{rate: 2, burst: 2}
+
+	for i := range 2 {
+		if wait, ok := b.reserve(now, l, 0); !ok || wait != 0 {
+			t.Fatalf("burst request %d: wait = %v, ok = %v", i, wait, ok)
+		}
+	}
+	if wait, ok := b.reserve(now, l, 0); ok || wait != 500*time.Millisecond {
+		t.Errorf("over budget: wait = %v, ok = %v; want 500ms, false", wait, ok)
+	}
+	// 排队的请求透支令牌，后续请求需要等待更久
+	if wait, ok := b.reserve(now, l, time.Second); !ok || wait != 500*time.Millisecond {
+		t.Errorf("queued: wait = %v, ok = %v; want 500ms, true", wait, ok)
+	}
+	if wait, ok := b.reserve(now, l, time.Second); !ok || wait != time.Second {
+		t.Errorf("queued behind: wait = %v, ok = %v; want 1s, true", wait, ok)
+	}
+
+	if wait, ok := b.reserve(now.Add(2*time.Second), l, 0); !ok || wait != 0 {
+		t.Errorf("after refill: wait = %v, ok = %v; want 0, true", wait, ok)
+	}
+}
+
+func TestBucketStrictestLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := &bucket{}
+	loose := limit{rate: 10, burst: 5}
+	strict := limit{rate: 1, burst: 1}
+
+	if _, ok := b.reserve(now, loose, 0); !ok {
+		t.Fatal("first loose request rejected")
+	}
+	// 更严格的限额加入后，桶容量降为 1，所有客户端都按 1/s 共享
+	if _, ok := b.reserve(now, strict, 0); !ok {
+		t.Fatal("strict request rejected")
+	}
+	if wait, ok := b.reserve(now.Add(500*time.Millisecond), loose, 0); ok || wait != 500*time.Millisecond {
+		t.Errorf("loose request under strict limit: wait = %v, ok = %v; want 500ms, false", wait, ok)
+	}
+
+	// 严格限额长时间未使用（例如客户端已被替换）后过期，恢复宽松限额
+	later := now.Add(limitTTL + time.Second)
+	for i := range 5 {
+		if _, ok := b.reserve(later, loose, 0); !ok {
+			t.Fatalf("loose request %d after strict limit expired rejected", i)
+		}
+	}
+}
+
+func TestRateLimitFailFast(t *testing.T) {
+	resetBuckets(t)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{RateLimit: RateLimitConfig{Rate: 0.1, Mode: RateLimitFail}}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ctx, attempts := WithAttempts(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second request err = %v, want ErrRateLimited", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("upstream received %d requests, want 1", calls.Load())
+	}
+	if attempts.Retries() != 0 {
+		t.Errorf("rate-limited request retried %d times", attempts.Retries())
+	}
+}
+
+func TestRateLimitQueuesWithinDeadline(t *testing.T) {
+	resetBuckets(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client, _ := NewClient(Config{RateLimit: RateLimitConfig{Rate: 20}}, time.Second)
+
+	started := time.Now()
+	for range 2 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(started); elapsed < 40*time.Millisecond {
+		t.Errorf("two requests at 20/s took %v, want queued ~50ms", elapsed)
+	}
+
+	// 截止时间内无法获得令牌时立即失败，而不是等到超时；使用另一个主机，避免与上面的客户端共享令牌桶
+	slowSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer slowSrv.Close()
+	slow, _ := NewClient(Config{RateLimit: RateLimitConfig{Rate: 0.1}}, time.Second)
+	resp, err := slow.Get(slowSrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, slowSrv.URL, nil)
+	if _, err := slow.Do(req); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("rate-limited request waited until the deadline")
+	}
+}
+
+func TestRateLimitSharedAcrossClients(t *testing.T) {
+	resetBuckets(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	relaxed, _ := NewClient(Config{RateLimit: RateLimitConfig{Rate: 5, Burst: 3, Mode: RateLimitFail}}, time.Second)
+	strict, _ := NewClient(Config{RateLimit: RateLimitConfig{Rate: 0.1, Mode: RateLimitFail}}, time.Second)
+
+	resp, err := relaxed.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp, err = strict.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// 同一主机的所有客户端共享一个桶并按最严格的限额（0.1/s，容量 1）限速，
+	// 限额宽松的客户端不能绕过更严格的限额
+	if _, err := relaxed.Get(srv.URL); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("relaxed client err = %v, want ErrRateLimited from the strictest limit", err)
+	}
+	if _, err := strict.Get(srv.URL); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("strict client err = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestRateLimitConfigValidation(t *testing.T) {
+	for _, cfg := range []RateLimitConfig{{Rate: -1}, {Rate: 1, Mode: "drop"}} {
+		if _, err := NewClient(Config{RateLimit: cfg}, time.Second); err == nil {
+			t.Errorf("NewClient accepted rateLimit %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// 重试的默认参数
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 500 * time.Millisecond
+	DefaultMaxDelay    = 5 * time.Second
+)
+
+// RetryConfig 描述幂等请求（GET、HEAD）的重试策略，零值字段使用默认值
+type RetryConfig struct {
+	// MaxAttempts 为包含首次请求在内的最大尝试次数，1 表示不重试
+	MaxAttempts int      `json:"maxAttempts,omitempty"`
+	BaseDelay   Duration `json:"baseDelay,omitempty"`
+	MaxDelay    Duration `json:"maxDelay,omitempty"`
+}
+
+func (c RetryConfig) merge(override RetryConfig) RetryConfig {
+	if override.MaxAttempts > 0 {
+		c.MaxAttempts = override.MaxAttempts
+	}
+	if override.BaseDelay > 0 {
+		c.BaseDelay = override.BaseDelay
+	}
+	if override.MaxDelay > 0 {
+		c.MaxDelay = override.MaxDelay
+	}
+	return c
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   Duration(DefaultBaseDelay),
+		MaxDelay:    Duration(DefaultMaxDelay),
+	}.merge(c)
+}
+
+// Attempts 统计一次适配器调用中发出的 HTTP 请求次数，通过 WithAttempts 附加到 context 上
+type Attempts struct {
+	requests atomic.Int64
+	retries  atomic.Int64
+}
+
+type attemptsKey struct{}
+
+// WithAttempts 返回附带请求计数器的 context，使用该 context 发出的请求都会计入返回的计数器
+func WithAttempts(ctx context.Context) (context.Context, *Attempts) {
+	attempts := &Attempts{}
+	return context.WithValue(ctx, attemptsKey{}, attempts), attempts
+}
+
+// Requests 返回尝试发出的 HTTP 请求数（包括重试和因限速未发出的请求）
+func (a *Attempts) Requests() int { return int(a.requests.Load()) }
+
+// Retries 返回其中重试的次数
+func (a *Attempts) Retries() int { return int(a.retries.Load()) }
+
+func attemptsFrom(ctx context.Context) *Attempts {
+	attempts, _ := ctx.Value(attemptsKey{}).(*Attempts)
+	return attempts
+}
+
+// retryTransport 对幂等请求在网络错误或临时性状态码时按带抖动的指数退避重试，
+// 优先遵循上游的 Retry-After；等待时间超出 context 截止时间时放弃并返回最后一次的结果
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryConfig
+	// sleep 和 jitter 可在测试中替换
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper, policy RetryConfig) *retryTransport {
+	return &retryTransport{base: base, policy: policy.withDefaults(), sleep: sleepContext, jitter: equalJitter}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	attempts := attemptsFrom(ctx)
+	retryable := isIdempotent(req)
+
+	for attempt := 1; ; attempt++ {
+		if attempts != nil {
+			attempts.requests.Add(1)
+			if attempt > 1 {
+				attempts.retries.Add(1)
+			}
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if !retryable || attempt >= t.policy.MaxAttempts || ctx.Err() != nil || errors.Is(err, ErrRateLimited) {
+			return resp, err
+		}
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			delay = t.backoff(attempt)
+		case retryableStatus(resp.StatusCode):
+			var ok bool
+			if delay, ok = retryAfter(resp.Header.Get("Retry-After"), time.Now()); !ok {
+				delay = t.backoff(attempt)
+			}
+		default:
+			return resp, nil
+		}
+
+		// 等待时间超出截止时间时直接返回本次结果，由调用方按原有方式处理错误
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		if sleepErr := t.sleep(ctx, delay); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+}
+
+// backoff 返回第 attempt 次失败后的等待时间：BaseDelay * 2^(attempt-1)，不超过 MaxDelay，并加入抖动
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := time.Duration(t.policy.BaseDelay)
+	for i := 1; i < attempt && delay < time.Duration(t.policy.MaxDelay); i++ {
+		delay *= 2
+	}
+	return t.jitter(min(delay, time.Duration(t.policy.MaxDelay)))
+}
+
+// equalJitter 返回 [d/2, d) 范围内的随机时长，避免多个客户端同时重试
+func equalJitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isIdempotent 判断请求能否安全重试：只重试没有请求体的 GET 和 HEAD
+func isIdempotent(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead && req.Method != "" {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+// retryableStatus 判断状态码是否为临时性错误
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter 解析 Retry-After 头，支持秒数和 HTTP 日期两种格式
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,157 @@
+package localindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/seedmanage/backend/internal/collections"
+	"github.com/seedmanage/backend/internal/history"
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+	// ID 是本地索引适配器的 ID
+	ID = "local"
+	// pageSize 是每页返回的结果数
+	pageSize = 50
+	// historyGroup 是搜索历史在索引中的组名，收藏夹的组名为 collectionGroup 加收藏夹 ID
+	historyGroup    = "history"
+	collectionGroup = "collection:"
+)
+
+// Adapter 是在本地收藏夹和搜索历史中搜索的适配器，存储写入后自动更新索引
+type Adapter struct {
+	index       *Index
+	collections *collections.Store
+	history     *history.Store
+}
+
+// NewAdapter 索引全部收藏夹和搜索历史并订阅它们的变化，任一存储可以为 nil
+// 无法读取的收藏夹会被跳过并在错误中报告，返回的适配器仍然可用
+func NewAdapter(collStore *collections.Store, historyStore *history.Store) (*Adapter, error) {
+	a := &Adapter{index: NewIndex(), collections: collStore, history: historyStore}
+
+	var errs []error
+	if collStore != nil {
+		metas, err := collStore.List()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, meta := range metas {
+			if err := a.indexCollection(meta.ID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		collStore.OnChange(func(id string) {
+			// 收藏夹被删除时 Get 失败，indexCollection 会清空该组
+			_ = a.indexCollection(id)
+		})
+	}
+	if historyStore != nil {
+		a.indexHistory()
+		historyStore.OnChange(a.indexHistory)
+	}
+	return a, errors.Join(errs...)
+}
+
+func (a *Adapter) ID() string          { return ID }
+func (a *Adapter) Name() string        { return "本地索引" }
+func (a *Adapter) Description() string { return "在本地收藏夹和搜索历史中检索" }
+func (a *Adapter) Endpoint() string    { return "local-index" }
+
+// Capabilities 声明适配器支持的功能，查询 info hash 时直接匹配索引中的 info hash
+func (a *Adapter) Capabilities() models.Capabilities {
+	return models.Capabilities{
+		Pagination:     true,
+		PageSize:       pageSize,
+		InfoHashLookup: true,
+	}
+}
+
+// Len 返回索引中的条目数
+func (a *Adapter) Len() int {
+	return a.index.Len()
+}
+
+// Search 执行搜索
+func (a *Adapter) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return a.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+// SearchWithOptions 执行搜索
+func (a *Adapter) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	page, err := a.SearchPage(ctx, options)
+	return page.Results, err
+}
+
+// SearchPage 按相关度返回一页结果以及匹配总数；分类和过滤器不适用于本地条目，会被忽略
+func (a *Adapter) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	if err := ctx.Err(); err != nil {
+		return models.ResultPage{}, err
+	}
+
+	hits := a.index.Search(options.Query)
+	page := max(options.Page, 1)
+	start := min((page-1)*pageSize, len(hits))
+	end := min(start+pageSize, len(hits))
+
+	results := make([]models.SearchResult, 0, end-start)
+	for _, hit := range hits[start:end] {
+		results = append(results, hit.Result)
+	}
+	return models.ResultPage{
+		Results:    results,
+		Total:      len(hits),
+		TotalPages: (len(hits) + pageSize - 1) / pageSize,
+		Endpoint:   a.Endpoint(),
+	}, nil
+}
+
+// indexCollection 重新索引一个收藏夹，收藏夹不存在时从索引中移除
+func (a *Adapter) indexCollection(id string) error {
+	cf, err := a.collections.Get(id)
+	if err != nil {
+		a.index.Replace(collectionGroup+id, nil)
+		return fmt.Errorf("index collection %s: %w", id, err)
+	}
+
+	docs := make([]Document, 0, len(cf.Items))
+	for _, item := range cf.Items {
+		result := models.SearchResult{
+			Title:    utils.Coalesce(item.Title, item.Remarks, item.Magnet),
+			Magnet:   item.Magnet,
+			Category: cf.Meta.Name,
+			Source:   ID,
+		}
+		if parsed, _, err := utils.ParseMagnetLink(item.Magnet); err == nil {
+			result.InfoHash = utils.NormalizeInfoHash(parsed.InfoHash)
+			result.Trackers = parsed.Trackers
+		}
+		docs = append(docs, Document{
+			Result:   result,
+			Keywords: item.Keywords,
+			Remarks:  item.Remarks,
+			Starred:  item.Starred,
+		})
+	}
+	a.index.Replace(collectionGroup+id, docs)
+	return nil
+}
+
+// indexHistory 重新索引全部搜索历史，跳过来自本地索引自身的结果
+func (a *Adapter) indexHistory() {
+	var docs []Document
+	for _, entry := range a.history.List() {
+		for _, result := range entry.Results {
+			if result.Source == ID || result.Magnet == "" {
+				continue
+			}
+			result.Source = ID
+			result.Sources = nil
+			docs = append(docs, Document{Result: result})
+		}
+	}
+	a.index.Replace(historyGroup, docs)
+}
@@ -0,0 +1,251 @@
+// Package localindex 为本地收藏夹和搜索历史建立倒排索引，并以 local 适配器的形式提供离线搜索
+package localindex
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// 各字段在相关度中的权重，标题命中比关键字和备注更重要
+const (
+	titleWeight    = 3.0
+	keywordsWeight = 2.0
+	remarksWeight  = 1.0
+	infoHashWeight = 1.0
+	// phraseBoost 是标题包含完整查询时的加权，starredBoost 是星标条目的加权
+	phraseBoost  = 1.5
+	starredBoost = 1.1
+)
+
+// Document 是索引中的一个条目，Result.Title 和 Result.InfoHash 也会被索引
+type Document struct {
+	Result   models.SearchResult
+	Keywords string
+	Remarks  string
+	Starred  bool
+}
+
+// Hit 是一条搜索命中及其相关度
+type Hit struct {
+	Result models.SearchResult
+	Score  float64
+}
+
+type indexedDoc struct {
+	Document
+	// title 是小写的标题，用于判断完整查询是否出现在标题中
+	title string
+	terms []string
+}
+
+// Index 是按组整体替换的内存倒排索引，组对应一个收藏夹或全部搜索历史
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[int]*indexedDoc
+	postings map[string]map[int]float64
+	groups   map[string][]int
+	nextID   int
+}
+
+// NewIndex 创建空索引
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[int]*indexedDoc),
+		postings: make(map[string]map[int]float64),
+		groups:   make(map[string][]int),
+	}
+}
+
+// Replace 用 docs 替换组中的全部条目，docs 为空时删除该组
+func (ix *Index) Replace(group string, docs []Document) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, id := range ix.groups[group] {
+		for _, term := range ix.docs[id].terms {
+			delete(ix.postings[term], id)
+			if len(ix.postings[term]) == 0 {
+				delete(ix.postings, term)
+			}
+		}
+		delete(ix.docs, id)
+	}
+	delete(ix.groups, group)
+
+	ids := make([]int, 0, len(docs))
+	for _, doc := range docs {
+		weights := make(map[string]float64)
+		for _, field := range []struct {
+			text   string
+			weight float64
+		}{
+			{doc.Result.Title, titleWeight},
+			{doc.Keywords, keywordsWeight},
+			{doc.Remarks, remarksWeight},
+			{doc.Result.InfoHash, infoHashWeight},
+		} {
+			for _, term := range tokenize(field.text, false) {
+				weights[term] += field.weight
+			}
+		}
+		if len(weights) == 0 {
+			continue
+		}
+
+		id := ix.nextID
+		ix.nextID++
+		indexed := &indexedDoc{Document: doc, title: strings.ToLower(doc.Result.Title)}
+		for term, weight := range weights {
+			if ix.postings[term] == nil {
+				ix.postings[term] = make(map[int]float64)
+			}
+			ix.postings[term][id] = weight
+			indexed.terms = append(indexed.terms, term)
+		}
+		ix.docs[id] = indexed
+		ids = append(ids, id)
+	}
+	if len(ids) > 0 {
+		ix.groups[group] = ids
+	}
+}
+
+// Len 返回索引中的条目数
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Search 返回包含查询中全部词项的条目，按相关度从高到低排序
+// 相关度为各词项的 IDF 与字段权重之积的和，标题包含完整查询和星标条目额外加权；
+// 相同 info hash（没有时为磁力链接）的条目只保留相关度最高的一条
+func (ix *Index) Search(query string) []Hit {
+	terms := unique(tokenize(query, true))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// 从文档最少的词项开始求交集
+	sort.Slice(terms, func(i, j int) bool { return len(ix.postings[terms[i]]) < len(ix.postings[terms[j]]) })
+	scores := make(map[int]float64)
+	for id := range ix.postings[terms[0]] {
+		scores[id] = 0
+	}
+	total := float64(len(ix.docs))
+	for _, term := range terms {
+		postings := ix.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+		for id := range scores {
+			weight, ok := postings[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] += idf * weight
+		}
+	}
+
+	phrase := strings.ToLower(strings.TrimSpace(query))
+	type candidate struct {
+		id  int
+		hit Hit
+	}
+	best := make(map[string]candidate)
+	for id, score := range scores {
+		doc := ix.docs[id]
+		if strings.Contains(doc.title, phrase) {
+			score *= phraseBoost
+		}
+		if doc.Starred {
+			score *= starredBoost
+		}
+		key := doc.Result.InfoHash
+		if key == "" {
+			key = doc.Result.Magnet
+		}
+		// 相关度相同时保留先索引的条目，使结果稳定
+		current, ok := best[key]
+		if !ok || score > current.hit.Score || (score == current.hit.Score && id < current.id) {
+			best[key] = candidate{id: id, hit: Hit{Result: doc.Result, Score: score}}
+		}
+	}
+
+	hits := make([]Hit, 0, len(best))
+	for _, c := range best {
+		hits = append(hits, c.hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Result.Title < hits[j].Result.Title
+	})
+	return hits
+}
+
+// tokenize 把文本切分为小写词项：字母数字按连续片段切分，中日韩文字按二元组切分
+// 索引时同时保留单字，使单字查询也能命中；查询时片段长度大于 1 则只使用二元组
+func tokenize(text string, query bool) []string {
+	var tokens []string
+	var word, cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 || (!query && len(cjk) > 0) {
+			for _, r := range cjk {
+				tokens = append(tokens, string(r))
+			}
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			out = append(out, term)
+		}
+	}
+	return out
+}
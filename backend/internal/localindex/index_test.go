@@ -0,0 +1,125 @@
+package localindex
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/collections"
+	"github.com/seedmanage/backend/internal/history"
+	"github.com/seedmanage/backend/internal/models"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text  string
+		query bool
+		want  []string
+	}{
+		{"Ubuntu 24.04-Desktop", false, []string{"ubuntu", "24", "04", "desktop"}},
+		{"进击的巨人S01", false, []string{"进", "击", "的", "巨", "人", "进击", "击的", "的巨", "巨人", "s01"}},
+		{"进击的巨人", true, []string{"进击", "击的", "的巨", "巨人"}},
+		{"巨", true, []string{"巨"}},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.text, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q, %v) = %q, want %q", tt.text, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestIndexRanking(t *testing.T) {
+	ix := NewIndex()
+	ix.Replace("a", []Document{
+		{Result: models.SearchResult{Title: "Debian netinst", InfoHash: "A1"}, Remarks: "ubuntu alternative"},
+		{Result: models.SearchResult{Title: "Ubuntu Server", InfoHash: "A2"}},
+		{Result: models.SearchResult{Title: "Linux ISO", InfoHash: "A3"}, Keywords: "ubuntu"},
+		{Result: models.SearchResult{Title: "Fedora", InfoHash: "A4"}},
+	})
+	ix.Replace("b", []Document{
+		{Result: models.SearchResult{Title: "Ubuntu Server copy", InfoHash: "A2"}},
+	})
+
+	var titles []string
+	for _, hit := range ix.Search("ubuntu") {
+		titles = append(titles, hit.Result.Title)
+	}
+	want := []string{"Ubuntu Server", "Linux ISO", "Debian netinst"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("got %q, want %q", titles, want)
+	}
+
+	if hits := ix.Search("ubuntu fedora"); len(hits) != 0 {
+		t.Errorf("all terms must match, got %d hits", len(hits))
+	}
+
+	ix.Replace("a", nil)
+	if ix.Len() != 1 {
+		t.Errorf("Len() = %d after removing group, want 1", ix.Len())
+	}
+}
+
+func TestAdapterFollowsStores(t *testing.T) {
+	dir := t.TempDir()
+	collStore, err := collections.NewStore(filepath.Join(dir, "collections"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	historyStore, err := history.NewStore(filepath.Join(dir, "history.json"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := collStore.Create("动画")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collStore.AddItem(meta.ID, models.CollectionItem{
+		Magnet:   "magnet:?xt=urn:btih:0123456789abcdef0123456789abcdef01234567&dn=x",
+		Title:    "进击的巨人 第一季",
+		Keywords: "shingeki",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	adapter, err := NewAdapter(collStore, historyStore)
+	if err != nil {
+		t.Fatalf("NewAdapter: %v", err)
+	}
+	search := func(query string) []models.SearchResult {
+		t.Helper()
+		results, err := adapter.Search(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	results := search("巨人")
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if got := results[0]; got.Source != ID || got.Category != "动画" || got.InfoHash != "0123456789ABCDEF0123456789ABCDEF01234567" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+
+	if err := historyStore.Record(models.SearchResponse{
+		Query: "shingeki",
+		Results: []models.SearchResult{
+			{Title: "Shingeki no Kyojin OVA", Magnet: "magnet:?xt=urn:btih:AAAA", InfoHash: "AAAA", Source: "nyaa"},
+			{Title: "Shingeki from local", Magnet: "magnet:?xt=urn:btih:BBBB", InfoHash: "BBBB", Source: ID},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if results := search("shingeki"); len(results) != 2 {
+		t.Errorf("got %d results after recording history, want 2", len(results))
+	}
+
+	if err := collStore.Delete(meta.ID); err != nil {
+		t.Fatal(err)
+	}
+	if results := search("巨人"); len(results) != 0 {
+		t.Errorf("got %d results after deleting collection, want 0", len(results))
+	}
+}
@@ -0,0 +1,347 @@
+// Package magnet 解析和构建磁力链接（BEP 9、BEP 53）
+// 支持十六进制和 base32 的 v1 btih、v2 btmh、多个 xt、xl、ws/as/xs、kt、x.pe 和 so 参数
+package magnet
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sha256Multihash 是 btmh 中 SHA-256 multihash 的前缀：0x12 表示 SHA-256，0x20 为摘要长度
+const sha256Multihash = "1220"
+
+// Magnet 是解析后的磁力链接，Parse(m.String()) 与 m 相同
+type Magnet struct {
+	// InfoHash 是 v1 info hash（大写十六进制），来自 urn:btih
+	InfoHash string
+	// InfoHashV2 是 v2 info hash（SHA-256，大写十六进制），来自 urn:btmh
+	InfoHashV2 string
+	// Topics 是其余的 xt，包括额外的 btih/btmh 和其他类型（如 urn:ed2k），按出现顺序保留
+	Topics []string
+	// Name 是显示名称（dn）
+	Name string
+	// Size 是内容的总字节数（xl），0 表示未知
+	Size int64
+	// Trackers 是 tracker 地址（tr）
+	Trackers []string
+	// WebSeeds 是 HTTP 种子地址（ws，BEP 19）
+	WebSeeds []string
+	// AcceptableSources 和 ExactSources 是可下载内容的地址（as）和 .torrent 文件的地址（xs）
+	AcceptableSources []string
+	ExactSources      []string
+	// Keywords 是搜索关键字（kt）
+	Keywords string
+	// Peers 是可直接连接的 peer 地址（x.pe）
+	Peers []string
+	// Select 是只下载的文件序号范围（so，BEP 53）
+	Select []Range
+	// Extra 保存无法识别的参数，构建时按键排序追加
+	Extra url.Values
+}
+
+// Range 是 so 参数中的文件序号范围，First 和 Last 都包含在内
+type Range struct {
+	First int
+	Last  int
+}
+
+// Parse 解析磁力链接；xt、xl 或 so 无效时返回错误，没有 xt 的链接也视为无效
+// 格式错误的百分号编码参数会被忽略
+func Parse(raw string) (Magnet, error) {
+	return parse(raw, true)
+}
+
+// ParseLenient 宽松地解析磁力链接，只在不是磁力链接时返回错误，用于保存和展示用户或上游提供的链接
+// 无效的 xt 原样保留在 Topics 中，无效的 xl 和 so 原样保留在 Extra 中，String() 时不会丢失；没有 xt 的链接也可以解析
+func ParseLenient(raw string) (Magnet, error) {
+	return parse(raw, false)
+}
+
+func parse(raw string, strict bool) (Magnet, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Magnet{}, fmt.Errorf("invalid magnet link: %w", err)
+	}
+	if u.Scheme != "magnet" {
+		return Magnet{}, errors.New("not a magnet link")
+	}
+	params, _ := url.ParseQuery(u.RawQuery)
+
+	var m Magnet
+	for _, key := range sortedKeys(params) {
+		values := params[key]
+		switch key {
+		case "dn":
+			m.Name = values[0]
+		case "xl":
+			size, err := strconv.ParseInt(values[0], 10, 64)
+			if err != nil || size < 0 {
+				if strict {
+					return Magnet{}, fmt.Errorf("invalid xl %q", values[0])
+				}
+				m.addExtra(key, values)
+				continue
+			}
+			m.Size = size
+		case "tr":
+			m.Trackers = appendNonEmpty(m.Trackers, values)
+		case "ws":
+			m.WebSeeds = appendNonEmpty(m.WebSeeds, values)
+		case "as":
+			m.AcceptableSources = appendNonEmpty(m.AcceptableSources, values)
+		case "xs":
+			m.ExactSources = appendNonEmpty(m.ExactSources, values)
+		case "kt":
+			m.Keywords = values[0]
+		case "x.pe":
+			m.Peers = appendNonEmpty(m.Peers, values)
+		case "so":
+			ranges, err := parseSelect(values[0])
+			if err != nil {
+				if strict {
+					return Magnet{}, err
+				}
+				m.addExtra(key, values)
+				continue
+			}
+			m.Select = ranges
+		default:
+			if isTopicKey(key) {
+				for _, value := range values {
+					if err := m.addTopic(value); err != nil {
+						if strict {
+							return Magnet{}, err
+						}
+						m.addOtherTopic(strings.TrimSpace(value))
+					}
+				}
+				continue
+			}
+			m.addExtra(key, values)
+		}
+	}
+	if strict && m.InfoHash == "" && m.InfoHashV2 == "" && len(m.Topics) == 0 {
+		return Magnet{}, errors.New("magnet link has no xt")
+	}
+	return m, nil
+}
+
+// InfoHash 返回磁力链接的 Hash()，只解析 xt 参数：无效的 xt 被跳过，其他参数无效时不影响结果
+// 不是磁力链接或没有有效的 btih/btmh 时返回空字符串
+func InfoHash(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "magnet" {
+		return ""
+	}
+	params, _ := url.ParseQuery(u.RawQuery)
+
+	var m Magnet
+	for _, key := range sortedKeys(params) {
+		if !isTopicKey(key) {
+			continue
+		}
+		for _, value := range params[key] {
+			_ = m.addTopic(value)
+		}
+	}
+	return m.Hash()
+}
+
+// sortedKeys 返回排序后的参数名；xt 可以带序号（xt.1、xt.2），按键排序保证结果稳定
+func sortedKeys(params url.Values) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isTopicKey(key string) bool {
+	return key == "xt" || strings.HasPrefix(key, "xt.")
+}
+
+// addTopic 解析一个 xt 值，第一个 btih 和 btmh 分别作为 InfoHash 和 InfoHashV2，重复的值被忽略
+func (m *Magnet) addTopic(topic string) error {
+	topic = strings.TrimSpace(topic)
+	lower := strings.ToLower(topic)
+	switch {
+	case strings.HasPrefix(lower, "urn:btih:"):
+		hash := NormalizeHash(topic[len("urn:btih:"):])
+		if !isHex(hash, 40) {
+			return fmt.Errorf("invalid btih %q", topic)
+		}
+		switch {
+		case m.InfoHash == "":
+			m.InfoHash = hash
+		case m.InfoHash != hash:
+			m.addOtherTopic("urn:btih:" + hash)
+		}
+	case strings.HasPrefix(lower, "urn:btmh:"):
+		multihash := lower[len("urn:btmh:"):]
+		if !strings.HasPrefix(multihash, sha256Multihash) || !isHex(multihash, len(sha256Multihash)+64) {
+			return fmt.Errorf("unsupported btmh %q", topic)
+		}
+		hash := strings.ToUpper(multihash[len(sha256Multihash):])
+		switch {
+		case m.InfoHashV2 == "":
+			m.InfoHashV2 = hash
+		case m.InfoHashV2 != hash:
+			m.addOtherTopic("urn:btmh:" + multihash)
+		}
+	case topic != "":
+		m.addOtherTopic(topic)
+	}
+	return nil
+}
+
+func (m *Magnet) addExtra(key string, values []string) {
+	if m.Extra == nil {
+		m.Extra = url.Values{}
+	}
+	m.Extra[key] = values
+}
+
+func (m *Magnet) addOtherTopic(topic string) {
+	for _, existing := range m.Topics {
+		if existing == topic {
+			return
+		}
+	}
+	m.Topics = append(m.Topics, topic)
+}
+
+// Hash 返回用于 tracker 和 peer 通信的 20 字节 info hash：v1 hash，纯 v2 链接为截断的 v2 hash
+func (m Magnet) Hash() string {
+	if m.InfoHash != "" || m.InfoHashV2 == "" {
+		return m.InfoHash
+	}
+	return m.InfoHashV2[:40]
+}
+
+// String 构建磁力链接，参数顺序为 xt、dn、xl、tr、ws、as、xs、kt、x.pe、so，之后是 Extra
+func (m Magnet) String() string {
+	var b strings.Builder
+	b.WriteString("magnet:?")
+	add := func(key, value string) {
+		if b.Len() > len("magnet:?") {
+			b.WriteByte('&')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(value)
+	}
+	addAll := func(key string, values []string) {
+		for _, value := range values {
+			add(key, url.QueryEscape(value))
+		}
+	}
+
+	// btih 和 btmh 只包含字母数字和冒号，不需要转义
+	if m.InfoHash != "" {
+		add("xt", "urn:btih:"+strings.ToUpper(m.InfoHash))
+	}
+	if m.InfoHashV2 != "" {
+		add("xt", "urn:btmh:"+sha256Multihash+strings.ToLower(m.InfoHashV2))
+	}
+	for _, topic := range m.Topics {
+		add("xt", escapeTopic(topic))
+	}
+	if m.Name != "" {
+		add("dn", url.QueryEscape(m.Name))
+	}
+	if m.Size > 0 {
+		add("xl", strconv.FormatInt(m.Size, 10))
+	}
+	addAll("tr", m.Trackers)
+	addAll("ws", m.WebSeeds)
+	addAll("as", m.AcceptableSources)
+	addAll("xs", m.ExactSources)
+	if m.Keywords != "" {
+		add("kt", url.QueryEscape(m.Keywords))
+	}
+	addAll("x.pe", m.Peers)
+	if len(m.Select) > 0 {
+		add("so", formatSelect(m.Select))
+	}
+
+	keys := make([]string, 0, len(m.Extra))
+	for key := range m.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range m.Extra[key] {
+			add(url.QueryEscape(key), url.QueryEscape(value))
+		}
+	}
+	return b.String()
+}
+
+// NormalizeHash 把 info hash 规范化为大写十六进制，32 位的 base32 btih 被转换为十六进制
+func NormalizeHash(hash string) string {
+	hash = strings.TrimSpace(hash)
+	if len(hash) == 32 {
+		if decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+			return strings.ToUpper(hex.EncodeToString(decoded))
+		}
+	}
+	return strings.ToUpper(hash)
+}
+
+// parseSelect 解析 so 参数，如 "0,2,4-6"
+func parseSelect(value string) ([]Range, error) {
+	var ranges []Range
+	for _, part := range strings.Split(value, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, err := strconv.Atoi(first)
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(last)
+		}
+		if err != nil || start < 0 || end < start {
+			return nil, fmt.Errorf("invalid so %q", value)
+		}
+		ranges = append(ranges, Range{First: start, Last: end})
+	}
+	return ranges, nil
+}
+
+func formatSelect(ranges []Range) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = strconv.Itoa(r.First)
+		if r.Last != r.First {
+			parts[i] += "-" + strconv.Itoa(r.Last)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// escapeTopic 转义 xt 的值，保留 URN 中的冒号以便阅读
+func escapeTopic(topic string) string {
+	return strings.ReplaceAll(url.QueryEscape(topic), "%3A", ":")
+}
+
+func appendNonEmpty(list, values []string) []string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func isHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
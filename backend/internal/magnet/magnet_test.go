@@ -0,0 +1,152 @@
+package magnet
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	hexHash    = "F257AF31A6204CD734D2BAECB8331637850B7B44"
+	base32Hash = "6JL26MNGEBGNONGSXLWLQMYWG6CQW62E"
+	v2Hash     = "CB7A6F1C8D4E2B0A9F3E5D7C1B2A39485766A5B4C3D2E1F00FEDCBA987654321"
+)
+
+func TestParse(t *testing.T) {
+	raw := "magnet:?xt=urn:btih:" + strings.ToLower(base32Hash) +
+		"&xt=urn:btmh:1220" + strings.ToLower(v2Hash) +
+		"&xt=urn:ed2k:31D6CFE0D16AE931B73C59D7E0C089C0" +
+		"&xt.1=urn:btih:" + hexHash +
+		"&dn=Demo+Pack&xl=10826029&tr=" + url.QueryEscape("udp://tracker.example:1337/announce") +
+		"&ws=" + url.QueryEscape("https://seed.example/demo/") +
+		"&as=https%3A%2F%2Fmirror.example%2Fdemo.zip&xs=https%3A%2F%2Fsite.example%2Fdemo.torrent" +
+		"&kt=demo+pack&x.pe=10.0.0.1%3A6881&so=0,2,4-6&foo=bar"
+
+	m, err := Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Magnet{
+		InfoHash:          hexHash,
+		InfoHashV2:        v2Hash,
+		Topics:            []string{"urn:ed2k:31D6CFE0D16AE931B73C59D7E0C089C0"},
+		Name:              "Demo Pack",
+		Size:              10826029,
+		Trackers:          []string{"udp://tracker.example:1337/announce"},
+		WebSeeds:          []string{"https://seed.example/demo/"},
+		AcceptableSources: []string{"https://mirror.example/demo.zip"},
+		ExactSources:      []string{"https://site.example/demo.torrent"},
+		Keywords:          "demo pack",
+		Peers:             []string{"10.0.0.1:6881"},
+		Select:            []Range{{0, 0}, {2, 2}, {4, 6}},
+		Extra:             url.Values{"foo": {"bar"}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Parse =\n%#v\nwant\n%#v", m, want)
+	}
+
+	again, err := Parse(m.String())
+	if err != nil {
+		t.Fatalf("Parse(String()): %v", err)
+	}
+	if !reflect.DeepEqual(again, m) {
+		t.Errorf("round trip =\n%#v\nwant\n%#v", again, m)
+	}
+	if !strings.HasPrefix(m.String(), "magnet:?xt=urn:btih:"+hexHash+"&xt=urn:btmh:1220"+strings.ToLower(v2Hash)+"&xt=urn:ed2k:") {
+		t.Errorf("String = %s", m.String())
+	}
+}
+
+func TestParseMultipleHashes(t *testing.T) {
+	other := strings.Repeat("AB", 20)
+	m, err := Parse("magnet:?xt=urn:btih:" + hexHash + "&xt=urn:btih:" + strings.ToLower(hexHash) + "&xt=urn:btih:" + other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.InfoHash != hexHash || !reflect.DeepEqual(m.Topics, []string{"urn:btih:" + other}) {
+		t.Errorf("got %+v", m)
+	}
+	if again, _ := Parse(m.String()); !reflect.DeepEqual(again, m) {
+		t.Errorf("round trip = %+v", again)
+	}
+}
+
+func TestParseV2Only(t *testing.T) {
+	m, err := Parse("magnet:?xt=urn:btmh:1220" + v2Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.InfoHash != "" || m.InfoHashV2 != v2Hash || m.Hash() != v2Hash[:40] {
+		t.Errorf("got %+v, hash %s", m, m.Hash())
+	}
+	if m.String() != "magnet:?xt=urn:btmh:1220"+strings.ToLower(v2Hash) {
+		t.Errorf("String = %s", m.String())
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"https://example.com/?xt=urn:btih:" + hexHash,
+		"magnet:?dn=no+topic",
+		"magnet:?xt=urn:btih:ABC",
+		"magnet:?xt=urn:btmh:1114" + strings.Repeat("00", 20),
+		"magnet:?xt=urn:btih:" + hexHash + "&xl=-1",
+		"magnet:?xt=urn:btih:" + hexHash + "&so=3-1",
+		"magnet:?xt=urn:btih:" + hexHash + "&so=a",
+	} {
+		if m, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%q) = %+v, want error", raw, m)
+		}
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	m, err := ParseLenient("magnet:?xt=urn:btih:ABC&xt=urn:btih:" + hexHash + "&dn=Demo&xl=-1&so=a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Magnet{
+		InfoHash: hexHash,
+		Topics:   []string{"urn:btih:ABC"},
+		Name:     "Demo",
+		Extra:    url.Values{"xl": {"-1"}, "so": {"a"}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("ParseLenient =\n%#v\nwant\n%#v", m, want)
+	}
+	if again, err := ParseLenient(m.String()); err != nil || !reflect.DeepEqual(again, m) {
+		t.Errorf("round trip = %#v, %v", again, err)
+	}
+
+	if m, err := ParseLenient("magnet:?dn=no+topic"); err != nil || m.Name != "no topic" || m.Hash() != "" {
+		t.Errorf("magnet without xt = %+v, %v", m, err)
+	}
+	if _, err := ParseLenient("https://example.com/?xt=urn:btih:" + hexHash); err == nil {
+		t.Error("ParseLenient accepted a non-magnet URL")
+	}
+}
+
+func TestInfoHash(t *testing.T) {
+	for raw, want := range map[string]string{
+		"magnet:?xt=urn:btih:" + hexHash + "&xl=-1":                    hexHash,
+		"magnet:?xt=urn:btih:" + strings.ToLower(base32Hash) + "&so=a": hexHash,
+		"magnet:?xt=urn:btih:ABC&xt.1=urn:btih:" + hexHash:             hexHash,
+		"magnet:?xt=urn:btmh:1220" + v2Hash + "&so=3-1":                v2Hash[:40],
+		"magnet:?xt=urn:btih:ABC&xl=10":                                "",
+		"https://example.com/?xt=urn:btih:" + hexHash:                  "",
+	} {
+		if got := InfoHash(raw); got != want {
+			t.Errorf("InfoHash(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestNormalizeHash(t *testing.T) {
+	if got := NormalizeHash(" " + strings.ToLower(base32Hash) + " "); got != hexHash {
+		t.Errorf("base32: got %s", got)
+	}
+	if got := NormalizeHash(strings.ToLower(hexHash)); got != hexHash {
+		t.Errorf("hex: got %s", got)
+	}
+}
@@ -0,0 +1,213 @@
+package metadata
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/seedmanage/backend/internal/magnet"
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/scrape"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+	// DefaultTimeout 是获取一个种子元数据的默认总超时
+	DefaultTimeout = 30 * time.Second
+	// maxPeerConnections 是同时连接的 peer 数
+	maxPeerConnections = 8
+	// peerIDPrefix 是握手使用的 peer ID 前缀（Azureus 风格），其余字节随机生成
+	peerIDPrefix = "-SM0100-"
+)
+
+// ErrNoPeers 表示没有找到可用的 peer
+var ErrNoPeers = errors.New("no peers found")
+
+// Request 描述要获取元数据的种子，Peers 为磁力链接 x.pe 参数中的 peer 地址
+type Request struct {
+	InfoHash string
+	Trackers []string
+	Peers    []string
+}
+
+// ParseMagnet 从磁力链接中提取 info hash、tracker 和 peer 地址
+func ParseMagnet(raw string) (Request, error) {
+	m, err := magnet.Parse(raw)
+	if err != nil {
+		return Request{}, err
+	}
+	return Request{InfoHash: m.Hash(), Trackers: m.Trackers, Peers: m.Peers}, nil
+}
+
+// Fetcher 从 tracker 获取 peer 并下载 info 字典，结果保存在 Store 中
+type Fetcher struct {
+	store   *Store
+	tracker *scrape.Scraper
+	timeout time.Duration
+	peerID  [20]byte
+}
+
+// NewFetcher 创建 Fetcher，tracker 用于 announce 获取 peer，timeout 不大于 0 时使用 DefaultTimeout
+func NewFetcher(store *Store, tracker *scrape.Scraper, timeout time.Duration) *Fetcher {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	f := &Fetcher{store: store, tracker: tracker, timeout: timeout}
+	copy(f.peerID[:], peerIDPrefix)
+	cryptorand.Read(f.peerID[len(peerIDPrefix):])
+	return f
+}
+
+// Cached 返回已缓存的元数据，不访问网络
+func (f *Fetcher) Cached(infoHash string) (models.TorrentInfo, bool) {
+	raw, ok := f.store.Get(utils.NormalizeInfoHash(infoHash))
+	if !ok {
+		return models.TorrentInfo{}, false
+	}
+	info, err := ParseInfo(raw)
+	return info, err == nil
+}
+
+// RawInfo 返回已缓存的原始 info 字典
+func (f *Fetcher) RawInfo(infoHash string) ([]byte, bool) {
+	return f.store.Get(utils.NormalizeInfoHash(infoHash))
+}
+
+// Put 缓存来自其他来源（如上传的 .torrent 文件）的 info 字典
+// 纯 v2 种子无法按 SHA-1 校验，不会被缓存
+func (f *Fetcher) Put(rawInfo []byte) error {
+	info, err := ParseInfo(rawInfo)
+	if err != nil {
+		return err
+	}
+	if info.InfoHash != InfoHash(rawInfo) {
+		return fmt.Errorf("metadata %s: v2-only torrents are not cached", info.InfoHash)
+	}
+	return f.store.Put(info.InfoHash, rawInfo)
+}
+
+// Fetch 返回种子的元数据，未缓存时向 tracker 获取 peer 并逐个尝试下载，首个成功的结果被缓存
+// 获取 peer 和连接 peer 同时进行，整个过程受 timeout 限制
+func (f *Fetcher) Fetch(ctx context.Context, req Request) (models.TorrentInfo, error) {
+	infoHash := utils.NormalizeInfoHash(req.InfoHash)
+	var hash [20]byte
+	if n, err := hex.Decode(hash[:], []byte(infoHash)); err != nil || n != len(hash) || len(infoHash) != 40 {
+		return models.TorrentInfo{}, fmt.Errorf("invalid info hash %q", req.InfoHash)
+	}
+	if info, ok := f.Cached(infoHash); ok {
+		return info, nil
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	peers := f.discover(fetchCtx, infoHash, req)
+
+	var mu sync.Mutex
+	var found []byte
+	var lastErr error
+	tried := 0
+	var wg sync.WaitGroup
+	for range maxPeerConnections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for addr := range peers {
+				if fetchCtx.Err() != nil {
+					continue
+				}
+				raw, err := fetchFromPeer(fetchCtx, addr, hash, f.peerID)
+
+				mu.Lock()
+				tried++
+				if err == nil && found == nil {
+					found = raw
+					cancel()
+				} else if err != nil && fetchCtx.Err() == nil {
+					lastErr = fmt.Errorf("%s: %w", addr, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	switch {
+	case found != nil:
+	case ctx.Err() != nil:
+		return models.TorrentInfo{}, ctx.Err()
+	case tried == 0:
+		return models.TorrentInfo{}, fmt.Errorf("metadata %s: %w", infoHash, ErrNoPeers)
+	case lastErr == nil:
+		return models.TorrentInfo{}, fmt.Errorf("metadata %s: timed out after %s trying %d peers", infoHash, f.timeout, tried)
+	default:
+		return models.TorrentInfo{}, fmt.Errorf("metadata %s: tried %d peers, last error: %w", infoHash, tried, lastErr)
+	}
+
+	info, err := ParseInfo(found)
+	if err != nil {
+		return models.TorrentInfo{}, err
+	}
+	if err := f.store.Put(infoHash, found); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// discover 并发地从磁力链接的 peer 地址和各 tracker 收集 peer，去重后写入返回的通道
+// 所有来源结束或 ctx 结束后关闭通道
+func (f *Fetcher) discover(ctx context.Context, infoHash string, req Request) <-chan netip.AddrPort {
+	peers := make(chan netip.AddrPort)
+	var mu sync.Mutex
+	seen := make(map[netip.AddrPort]bool)
+	send := func(list []netip.AddrPort) {
+		for _, addr := range list {
+			mu.Lock()
+			duplicate := seen[addr]
+			seen[addr] = true
+			mu.Unlock()
+			if duplicate {
+				continue
+			}
+			select {
+			case peers <- addr:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var direct []netip.AddrPort
+		for _, peer := range req.Peers {
+			if addr, err := netip.ParseAddrPort(peer); err == nil {
+				direct = append(direct, addr)
+			}
+		}
+		send(direct)
+	}()
+	if f.tracker != nil {
+		for _, tracker := range f.tracker.Trackers(scrape.Target{InfoHash: infoHash, Trackers: req.Trackers}) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				list, err := f.tracker.Announce(ctx, tracker, infoHash)
+				if err == nil {
+					send(list)
+				}
+			}()
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(peers)
+	}()
+	return peers
+}
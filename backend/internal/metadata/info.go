@@ -0,0 +1,166 @@
+// Package metadata 通过 BitTorrent 扩展协议（BEP 9/10）从 peer 获取种子的 info 字典，
+// 解析文件列表、总大小和分块信息，并缓存原始 info 字典
+package metadata
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/seedmanage/backend/internal/bencode"
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+// InfoHash 返回 info 字典的 v1 info hash（SHA-1，大写十六进制）
+func InfoHash(rawInfo []byte) string {
+	sum := sha1.Sum(rawInfo)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// InfoHashV2 返回 info 字典的 v2 info hash（SHA-256，大写十六进制）
+func InfoHashV2(rawInfo []byte) string {
+	sum := sha256.Sum256(rawInfo)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// ParseInfo 解析 bencode 编码的 info 字典，支持单文件、多文件（v1）和 file tree（v2）格式
+// 优先使用 name.utf-8 / path.utf-8，跳过 BEP 47 的填充文件
+// meta version 为 2 时同时计算 v2 info hash；纯 v2 种子没有 SHA-1 hash，InfoHash 为截断到 20 字节的 v2 hash
+func ParseInfo(rawInfo []byte) (models.TorrentInfo, error) {
+	decoded, err := bencode.Decode(rawInfo)
+	if err != nil {
+		return models.TorrentInfo{}, err
+	}
+	dict, ok := decoded.(map[string]any)
+	if !ok {
+		return models.TorrentInfo{}, errors.New("info is not a dictionary")
+	}
+
+	info := models.TorrentInfo{
+		InfoHash: InfoHash(rawInfo),
+		Name:     utils.Coalesce(stringField(dict, "name.utf-8"), stringField(dict, "name")),
+	}
+	info.PieceLength, _ = dict["piece length"].(int64)
+	if info.Name == "" || info.PieceLength <= 0 {
+		return models.TorrentInfo{}, errors.New("info has no name or piece length")
+	}
+	private, _ := dict["private"].(int64)
+	info.Private = private == 1
+
+	switch {
+	case dict["files"] != nil:
+		list, ok := dict["files"].([]any)
+		if !ok {
+			return models.TorrentInfo{}, errors.New("invalid files list")
+		}
+		for _, item := range list {
+			file, ok := item.(map[string]any)
+			if !ok {
+				return models.TorrentInfo{}, errors.New("invalid file entry")
+			}
+			if attr, _ := file["attr"].(string); strings.Contains(attr, "p") {
+				continue
+			}
+			path := pathField(file, "path.utf-8")
+			if len(path) == 0 {
+				path = pathField(file, "path")
+			}
+			length, _ := file["length"].(int64)
+			if len(path) == 0 || length < 0 {
+				return models.TorrentInfo{}, errors.New("invalid file entry")
+			}
+			info.Files = append(info.Files, models.TorrentFile{Path: strings.Join(path, "/"), Size: length})
+		}
+	case dict["length"] != nil:
+		length, _ := dict["length"].(int64)
+		if length < 0 {
+			return models.TorrentInfo{}, errors.New("invalid length")
+		}
+		info.Files = []models.TorrentFile{{Path: info.Name, Size: length}}
+	case dict["file tree"] != nil:
+		tree, ok := dict["file tree"].(map[string]any)
+		if !ok {
+			return models.TorrentInfo{}, errors.New("invalid file tree")
+		}
+		if err := walkFileTree(tree, nil, &info.Files); err != nil {
+			return models.TorrentInfo{}, err
+		}
+	default:
+		return models.TorrentInfo{}, errors.New("info has no files")
+	}
+
+	for _, file := range info.Files {
+		info.Size += file.Size
+	}
+	info.SizeLabel = utils.FormatSize(info.Size)
+	pieces, v1 := dict["pieces"].(string)
+	if version, _ := dict["meta version"].(int64); version == 2 {
+		info.InfoHashV2 = InfoHashV2(rawInfo)
+		if !v1 {
+			info.InfoHash = info.InfoHashV2[:40]
+		}
+	}
+	if v1 {
+		info.Pieces = len(pieces) / sha1.Size
+	} else {
+		// v2 种子没有 pieces 字段，每个文件单独按 piece length 分块
+		for _, file := range info.Files {
+			info.Pieces += int((file.Size + info.PieceLength - 1) / info.PieceLength)
+		}
+	}
+	return info, nil
+}
+
+// walkFileTree 遍历 v2 的 file tree，键为路径的一段，空键对应文件本身
+func walkFileTree(tree map[string]any, prefix []string, files *[]models.TorrentFile) error {
+	if len(prefix) > 64 {
+		return errors.New("file tree too deep")
+	}
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		node, ok := tree[name].(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid file tree node %q", name)
+		}
+		if name == "" {
+			length, _ := node["length"].(int64)
+			if len(prefix) == 0 || length < 0 {
+				return errors.New("invalid file tree entry")
+			}
+			*files = append(*files, models.TorrentFile{Path: strings.Join(prefix, "/"), Size: length})
+			continue
+		}
+		if err := walkFileTree(node, append(prefix[:len(prefix):len(prefix)], name), files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stringField(dict map[string]any, key string) string {
+	value, _ := dict[key].(string)
+	return value
+}
+
+func pathField(dict map[string]any, key string) []string {
+	list, _ := dict[key].([]any)
+	path := make([]string, 0, len(list))
+	for _, item := range list {
+		segment, ok := item.(string)
+		if !ok {
+			return nil
+		}
+		path = append(path, segment)
+	}
+	return path
+}
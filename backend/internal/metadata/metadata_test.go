@@ -0,0 +1,311 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/bencode"
+	"github.com/seedmanage/backend/internal/scrape"
+)
+
+// testInfo 返回一个多文件种子的 info 字典，pieces 足够长使元数据分为多块传输
+func testInfo(t *testing.T) []byte {
+	t.Helper()
+	raw, err := bencode.Encode(map[string]any{
+		"name":         "Demo Pack",
+		"piece length": 1 << 18,
+		"pieces":       strings.Repeat("x", 20*1200),
+		"files": []any{
+			map[string]any{"length": 700 << 20, "path": []string{"video", "episode 01.mkv"}},
+			map[string]any{"length": 1234, "path": []string{".pad", "1234"}, "attr": "p"},
+			map[string]any{"length": 4096, "path": []string{"readme.txt"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// seeder 是进程内的做种 peer，只实现握手、扩展握手和 ut_metadata
+type seeder struct {
+	ln       net.Listener
+	info     []byte
+	hash     [20]byte
+	tamper   bool
+	requests chan int
+}
+
+func newSeeder(t *testing.T, info []byte, tamper bool) *seeder {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &seeder{ln: ln, info: info, hash: sha1.Sum(info), tamper: tamper, requests: make(chan int, 100)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *seeder) addr() string { return s.ln.Addr().String() }
+
+func (s *seeder) serve(conn net.Conn) {
+	defer conn.Close()
+	request := make([]byte, 68)
+	if _, err := io.ReadFull(conn, request); err != nil || !bytes.Equal(request[28:48], s.hash[:]) {
+		return
+	}
+	reply := append([]byte(nil), request[:48]...)
+	reply = append(reply, "-TS0001-000000000000"...)
+	conn.Write(reply)
+
+	send := func(id byte, payload []byte) {
+		header := binary.BigEndian.AppendUint32(nil, uint32(1+len(payload)))
+		conn.Write(append(append(header, id), payload...))
+	}
+	handshake, _ := bencode.Encode(map[string]any{"m": map[string]any{"ut_metadata": 3}, "metadata_size": len(s.info)})
+	send(msgExtended, append([]byte{extHandshake}, handshake...))
+	// 非扩展消息应当被跳过
+	send(5, []byte{0xff, 0xff})
+
+	remoteID := byte(0)
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		message := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, err := io.ReadFull(conn, message); err != nil || len(message) < 2 || message[0] != msgExtended {
+			return
+		}
+		value, _ := bencode.Decode(message[2:])
+		dict, _ := value.(map[string]any)
+		switch message[1] {
+		case extHandshake:
+			m, _ := dict["m"].(map[string]any)
+			id, _ := m["ut_metadata"].(int64)
+			remoteID = byte(id)
+		case 3:
+			piece, _ := dict["piece"].(int64)
+			s.requests <- int(piece)
+			start := int(piece) * metadataPieceSize
+			block := append([]byte(nil), s.info[start:min(start+metadataPieceSize, len(s.info))]...)
+			if s.tamper {
+				block[0] ^= 0xff
+			}
+			header, _ := bencode.Encode(map[string]any{"msg_type": metadataData, "piece": piece, "total_size": len(s.info)})
+			send(msgExtended, append(append([]byte{remoteID}, header...), block...))
+		}
+	}
+}
+
+// newAnnounceTracker 是只返回指定 peer 的 HTTP tracker
+func newAnnounceTracker(t *testing.T, peer string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, port, _ := net.SplitHostPort(peer)
+		var p int
+		fmt.Sscan(port, &p)
+		compact := append([]byte(net.ParseIP(host).To4()), byte(p>>8), byte(p))
+		body, _ := bencode.Encode(map[string]any{"interval": 1800, "peers": compact})
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchFromTrackerPeer(t *testing.T) {
+	info := testInfo(t)
+	peer := newSeeder(t, info, false)
+	tracker := newAnnounceTracker(t, peer.addr())
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(store, scrape.New(nil, time.Second), 5*time.Second)
+
+	req, err := ParseMagnet("magnet:?xt=urn:btih:" + InfoHash(info) + "&tr=" + tracker.URL + "/announce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fetcher.Fetch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got.Name != "Demo Pack" || got.Pieces != 1200 || got.PieceLength != 1<<18 || len(got.Files) != 2 {
+		t.Errorf("unexpected metadata: %+v", got)
+	}
+	if got.Size != 700<<20+4096 || got.Files[0].Path != "video/episode 01.mkv" {
+		t.Errorf("files = %+v, size %d", got.Files, got.Size)
+	}
+	if len(peer.requests) != 2 {
+		t.Errorf("peer served %d metadata pieces, want 2", len(peer.requests))
+	}
+
+	// 第二次从磁盘缓存读取，不再连接 peer
+	peer.ln.Close()
+	reloaded, _ := NewStore(store.dir)
+	cached, err := NewFetcher(reloaded, nil, time.Second).Fetch(context.Background(), Request{InfoHash: strings.ToLower(got.InfoHash)})
+	if err != nil || cached.Name != got.Name {
+		t.Errorf("cached fetch = %+v, %v", cached, err)
+	}
+}
+
+func TestFetchRejectsTamperedMetadata(t *testing.T) {
+	info := testInfo(t)
+	bad := newSeeder(t, info, true)
+	good := newSeeder(t, info, false)
+	store, _ := NewStore("")
+	fetcher := NewFetcher(store, nil, 5*time.Second)
+
+	got, err := fetcher.Fetch(context.Background(), Request{InfoHash: InfoHash(info), Peers: []string{bad.addr()}})
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("tampered peer: %+v, err %v", got, err)
+	}
+	if _, ok := fetcher.Cached(InfoHash(info)); ok {
+		t.Fatal("tampered metadata cached")
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), Request{InfoHash: InfoHash(info), Peers: []string{bad.addr(), good.addr()}}); err != nil {
+		t.Fatalf("fetch with one good peer: %v", err)
+	}
+}
+
+func TestFetchWithoutPeers(t *testing.T) {
+	store, _ := NewStore("")
+	fetcher := NewFetcher(store, nil, time.Second)
+	_, err := fetcher.Fetch(context.Background(), Request{InfoHash: strings.Repeat("AB", 20)})
+	if !errors.Is(err, ErrNoPeers) {
+		t.Errorf("err = %v, want ErrNoPeers", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), Request{InfoHash: "xyz"}); err == nil {
+		t.Error("invalid info hash accepted")
+	}
+}
+
+func TestParseInfoFormats(t *testing.T) {
+	single, _ := bencode.Encode(map[string]any{"name": "a.iso", "name.utf-8": "ä.iso", "piece length": 1 << 20, "pieces": strings.Repeat("x", 40), "length": 3 << 20, "private": 1})
+	info, err := ParseInfo(single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "ä.iso" || info.Size != 3<<20 || info.Pieces != 2 || !info.Private || len(info.Files) != 1 {
+		t.Errorf("single file: %+v", info)
+	}
+
+	v2, _ := bencode.Encode(map[string]any{
+		"name":         "tree",
+		"piece length": 1 << 16,
+		"meta version": 2,
+		"file tree": map[string]any{
+			"b.txt": map[string]any{"": map[string]any{"length": 10}},
+			"dir":   map[string]any{"a.bin": map[string]any{"": map[string]any{"length": 1<<16 + 1}}},
+		},
+	})
+	info, err = ParseInfo(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Files) != 2 || info.Files[0].Path != "b.txt" || info.Files[1].Path != "dir/a.bin" || info.Pieces != 3 {
+		t.Errorf("file tree: %+v", info)
+	}
+
+	for _, invalid := range []string{"le", "d4:name1:ae", "d4:name1:a12:piece lengthi1ee"} {
+		if _, err := ParseInfo([]byte(invalid)); err == nil {
+			t.Errorf("ParseInfo(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestParseTorrent(t *testing.T) {
+	info := testInfo(t)
+	// info 字典之外的键不影响 info hash，announce-list 优先于 announce 且去重
+	data := []byte("d8:announce16:udp://a/announce13:announce-listll16:udp://b/announceel16:udp://a/announce16:udp://b/announceee4:info")
+	data = append(data, info...)
+	data = append(data, 'e')
+
+	torrent, err := ParseTorrent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent.Info.InfoHash != InfoHash(info) || torrent.Info.InfoHashV2 != "" || !bytes.Equal(torrent.RawInfo, info) {
+		t.Errorf("info hash = %s / %q", torrent.Info.InfoHash, torrent.Info.InfoHashV2)
+	}
+	if strings.Join(torrent.Trackers, ",") != "udp://b/announce,udp://a/announce" {
+		t.Errorf("trackers = %v", torrent.Trackers)
+	}
+	result := torrent.SearchResult()
+	if result.Title != "Demo Pack" || result.Size == nil || *result.Size != torrent.Info.Size || result.InfoHash != InfoHash(info) {
+		t.Errorf("result = %+v", result)
+	}
+	if !strings.HasPrefix(result.Magnet, "magnet:?xt=urn:btih:"+InfoHash(info)+"&dn=Demo+Pack&xl="+fmt.Sprint(torrent.Info.Size)+"&tr=udp") {
+		t.Errorf("magnet = %s", result.Magnet)
+	}
+
+	// 混合种子同时有 v1 和 v2 hash，纯 v2 种子使用截断的 v2 hash
+	hybrid, _ := bencode.Encode(map[string]any{
+		"name":         "hybrid",
+		"piece length": 1 << 16,
+		"pieces":       strings.Repeat("x", 20),
+		"length":       10,
+		"meta version": 2,
+		"file tree":    map[string]any{"hybrid": map[string]any{"": map[string]any{"length": 10}}},
+	})
+	torrent, err = ParseTorrent(append(append([]byte("d4:info"), hybrid...), 'e'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent.Info.InfoHash != InfoHash(hybrid) || torrent.Info.InfoHashV2 != InfoHashV2(hybrid) {
+		t.Errorf("hybrid hashes = %s, %s", torrent.Info.InfoHash, torrent.Info.InfoHashV2)
+	}
+	if !strings.Contains(torrent.Magnet(), "&xt=urn:btmh:1220"+strings.ToLower(InfoHashV2(hybrid))+"&") {
+		t.Errorf("hybrid magnet = %s", torrent.Magnet())
+	}
+
+	v2, _ := bencode.Encode(map[string]any{
+		"name":         "v2",
+		"piece length": 1 << 16,
+		"meta version": 2,
+		"file tree":    map[string]any{"v2": map[string]any{"": map[string]any{"length": 10}}},
+	})
+	torrent, err = ParseTorrent(append(append([]byte("d4:info"), v2...), 'e'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent.Info.InfoHash != InfoHashV2(v2)[:40] {
+		t.Errorf("v2 info hash = %s", torrent.Info.InfoHash)
+	}
+	if req, err := ParseMagnet(torrent.Magnet()); err != nil || req.InfoHash != torrent.Info.InfoHash || strings.Contains(torrent.Magnet(), "btih") {
+		t.Errorf("v2 magnet %s parsed as %+v, %v", torrent.Magnet(), req, err)
+	}
+	store, _ := NewStore("")
+	if err := NewFetcher(store, nil, time.Second).Put(v2); err == nil {
+		t.Error("v2-only info cached")
+	}
+
+	for _, invalid := range []string{"", "le", "d8:announce1:ae", "d4:infoi1ee"} {
+		if _, err := ParseTorrent([]byte(invalid)); err == nil {
+			t.Errorf("ParseTorrent(%q) succeeded", invalid)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/seedmanage/backend/internal/bencode"
+)
+
+// 对等协议常量（BEP 3、BEP 9、BEP 10）
+const (
+	protocolName = "BitTorrent protocol"
+	// msgExtended 是扩展协议消息的 ID，扩展消息 ID 0 为扩展握手
+	msgExtended  = 20
+	extHandshake = 0
+	// utMetadataID 是本端在扩展握手中为 ut_metadata 分配的消息 ID
+	utMetadataID = 1
+	// metadataPieceSize 是 ut_metadata 每块的大小
+	metadataPieceSize = 16 << 10
+	// maxMetadataSize 是接受的 info 字典最大字节数
+	maxMetadataSize = 16 << 20
+	// maxExtendedMessage 是扩展消息的最大长度，其他消息直接丢弃
+	maxExtendedMessage = metadataPieceSize + 1<<10
+	// peerTimeout 是单个 peer 的连接和传输超时
+	peerTimeout = 15 * time.Second
+)
+
+// ut_metadata 消息类型
+const (
+	metadataRequest = 0
+	metadataData    = 1
+	metadataReject  = 2
+)
+
+// fetchFromPeer 连接 peer，通过 ut_metadata 下载 info 字典并校验 info hash
+func fetchFromPeer(ctx context.Context, addr netip.AddrPort, infoHash, peerID [20]byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, peerTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// 调用方取消时立即中断阻塞的读写
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	if err := handshake(conn, infoHash, peerID); err != nil {
+		return nil, err
+	}
+	payload, err := bencode.Encode(map[string]any{
+		"m": map[string]any{"ut_metadata": utMetadataID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeExtended(conn, extHandshake, payload); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	var metadata []byte
+	var received []bool
+	remaining := 0
+	for {
+		message, err := readExtended(reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if len(message) == 0 {
+			continue
+		}
+
+		switch message[0] {
+		case extHandshake:
+			if metadata != nil {
+				continue
+			}
+			peerMetadataID, size, err := parseExtendedHandshake(message[1:])
+			if err != nil {
+				return nil, err
+			}
+			metadata = make([]byte, size)
+			remaining = int((size + metadataPieceSize - 1) / metadataPieceSize)
+			received = make([]bool, remaining)
+			for piece := range remaining {
+				request, _ := bencode.Encode(map[string]any{"msg_type": metadataRequest, "piece": piece})
+				if err := writeExtended(conn, byte(peerMetadataID), request); err != nil {
+					return nil, err
+				}
+			}
+
+		case utMetadataID:
+			if metadata == nil {
+				continue
+			}
+			value, n, err := bencode.DecodePrefix(message[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid ut_metadata message: %w", err)
+			}
+			dict, _ := value.(map[string]any)
+			msgType, _ := dict["msg_type"].(int64)
+			piece, _ := dict["piece"].(int64)
+			switch msgType {
+			case metadataReject:
+				return nil, errors.New("peer rejected metadata request")
+			case metadataData:
+			default:
+				continue
+			}
+			if piece < 0 || piece >= int64(len(received)) {
+				return nil, fmt.Errorf("invalid metadata piece %d", piece)
+			}
+			block := message[1+n:]
+			offset := int(piece) * metadataPieceSize
+			if len(block) != min(metadataPieceSize, len(metadata)-offset) {
+				return nil, fmt.Errorf("metadata piece %d has wrong size %d", piece, len(block))
+			}
+			if !received[piece] {
+				copy(metadata[offset:], block)
+				received[piece] = true
+				remaining--
+			}
+			if remaining == 0 {
+				if sha1.Sum(metadata) != infoHash {
+					return nil, errors.New("metadata does not match info hash")
+				}
+				return metadata, nil
+			}
+		}
+	}
+}
+
+// handshake 交换 BitTorrent 握手，声明并要求对方支持扩展协议
+func handshake(conn net.Conn, infoHash, peerID [20]byte) error {
+	var reserved [8]byte
+	reserved[5] |= 0x10
+	message := make([]byte, 0, 68)
+	message = append(message, byte(len(protocolName)))
+	message = append(message, protocolName...)
+	message = append(message, reserved[:]...)
+	message = append(message, infoHash[:]...)
+	message = append(message, peerID[:]...)
+	if _, err := conn.Write(message); err != nil {
+		return err
+	}
+
+	response := make([]byte, 68)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return fmt.Errorf("read handshake: %w", err)
+	}
+	if response[0] != byte(len(protocolName)) || string(response[1:20]) != protocolName {
+		return errors.New("invalid handshake")
+	}
+	if !bytes.Equal(response[28:48], infoHash[:]) {
+		return errors.New("peer replied with a different info hash")
+	}
+	if response[25]&0x10 == 0 {
+		return errors.New("peer does not support the extension protocol")
+	}
+	return nil
+}
+
+// parseExtendedHandshake 返回 peer 的 ut_metadata 消息 ID 和 info 字典大小
+func parseExtendedHandshake(payload []byte) (int64, int64, error) {
+	value, _, err := bencode.DecodePrefix(payload)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid extension handshake: %w", err)
+	}
+	dict, _ := value.(map[string]any)
+	m, _ := dict["m"].(map[string]any)
+	id, _ := m["ut_metadata"].(int64)
+	size, _ := dict["metadata_size"].(int64)
+	if id <= 0 || id > 255 {
+		return 0, 0, errors.New("peer does not support ut_metadata")
+	}
+	if size <= 0 || size > maxMetadataSize {
+		return 0, 0, fmt.Errorf("invalid metadata size %d", size)
+	}
+	return id, size, nil
+}
+
+// writeExtended 发送一条扩展消息
+func writeExtended(conn net.Conn, extID byte, payload []byte) error {
+	message := make([]byte, 6, 6+len(payload))
+	binary.BigEndian.PutUint32(message, uint32(2+len(payload)))
+	message[4] = msgExtended
+	message[5] = extID
+	_, err := conn.Write(append(message, payload...))
+	return err
+}
+
+// readExtended 读取下一条扩展消息，返回扩展消息 ID 及之后的内容；其他消息和 keep-alive 被跳过
+func readExtended(r *bufio.Reader) ([]byte, error) {
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint32(header[:])
+		if length == 0 {
+			continue
+		}
+		id, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if id != msgExtended {
+			if _, err := r.Discard(int(length - 1)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if length-1 > maxExtendedMessage {
+			return nil, fmt.Errorf("extended message too large: %d bytes", length)
+		}
+		message := make([]byte, length-1)
+		if _, err := io.ReadFull(r, message); err != nil {
+			return nil, err
+		}
+		return message, nil
+	}
+}
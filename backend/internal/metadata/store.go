@@ -0,0 +1,83 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store 缓存原始 info 字典，以 info hash 命名保存在目录中；dir 为空时只保存在内存
+// info 字典由 info hash 唯一确定，缓存不会过期
+type Store struct {
+	dir    string
+	mu     sync.RWMutex
+	memory map[string][]byte
+}
+
+// NewStore 创建缓存，目录不存在时自动创建
+func NewStore(dir string) (*Store, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("ensure metadata dir: %w", err)
+		}
+	}
+	return &Store{dir: dir, memory: make(map[string][]byte)}, nil
+}
+
+// Get 返回缓存的 info 字典，infoHash 须为规范化的大写十六进制
+func (s *Store) Get(infoHash string) ([]byte, bool) {
+	s.mu.RLock()
+	raw, ok := s.memory[infoHash]
+	s.mu.RUnlock()
+	if ok || s.dir == "" || !validName(infoHash) {
+		return raw, ok
+	}
+
+	raw, err := os.ReadFile(filepath.Join(s.dir, infoHash+".info"))
+	if err != nil {
+		return nil, false
+	}
+	// 文件可能被外部修改，校验后才使用
+	if InfoHash(raw) != infoHash {
+		return nil, false
+	}
+	return raw, true
+}
+
+// Put 保存 info 字典；使用目录时只写入磁盘，不常驻内存
+func (s *Store) Put(infoHash string, raw []byte) error {
+	if !validName(infoHash) {
+		return fmt.Errorf("invalid info hash %q", infoHash)
+	}
+	if s.dir == "" {
+		s.mu.Lock()
+		s.memory[infoHash] = raw
+		s.mu.Unlock()
+		return nil
+	}
+
+	path := filepath.Join(s.dir, infoHash+".info")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0o644); err != nil {
+		return fmt.Errorf("write metadata: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Join(fmt.Errorf("replace metadata: %w", err), os.Remove(tmpPath))
+	}
+	return nil
+}
+
+// validName 确认 info hash 是 40 位十六进制，可以安全地用作文件名
+func validName(infoHash string) bool {
+	if len(infoHash) != 40 {
+		return false
+	}
+	for _, c := range infoHash {
+		if !('0' <= c && c <= '9' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
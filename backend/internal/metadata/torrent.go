@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/seedmanage/backend/internal/bencode"
+	"github.com/seedmanage/backend/internal/magnet"
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+// createdBy 是生成的 .torrent 文件中的 created by 字段
+const createdBy = "seedManage"
+
+// Torrent 是解析后的 .torrent 文件
+type Torrent struct {
+	Info    models.TorrentInfo
+	RawInfo []byte
+	// Trackers 是 announce 和 announce-list（BEP 12）合并去重后的 tracker 列表
+	Trackers []string
+}
+
+// ParseTorrent 解析 .torrent 文件，info hash 按文件中 info 字典的原始编码计算
+func ParseTorrent(data []byte) (Torrent, error) {
+	fields, err := bencode.DecodeRawDict(data)
+	if err != nil {
+		return Torrent{}, fmt.Errorf("invalid torrent file: %w", err)
+	}
+	rawInfo, ok := fields["info"]
+	if !ok {
+		return Torrent{}, errors.New("torrent file has no info dictionary")
+	}
+	info, err := ParseInfo(rawInfo)
+	if err != nil {
+		return Torrent{}, err
+	}
+
+	var trackers []string
+	seen := make(map[string]bool)
+	add := func(value any) {
+		tracker, _ := value.(string)
+		tracker = strings.TrimSpace(tracker)
+		if tracker != "" && !seen[tracker] {
+			seen[tracker] = true
+			trackers = append(trackers, tracker)
+		}
+	}
+	if raw, ok := fields["announce-list"]; ok {
+		tiers, _ := bencode.Decode(raw)
+		list, _ := tiers.([]any)
+		for _, tier := range list {
+			urls, _ := tier.([]any)
+			for _, tracker := range urls {
+				add(tracker)
+			}
+		}
+	}
+	if raw, ok := fields["announce"]; ok {
+		announce, _ := bencode.Decode(raw)
+		add(announce)
+	}
+
+	return Torrent{Info: info, RawInfo: rawInfo, Trackers: trackers}, nil
+}
+
+// Magnet 返回种子的磁力链接，带有总大小（xl）；v2 和混合种子带有 btmh，纯 v2 种子没有 btih
+func (t Torrent) Magnet() string {
+	m := magnet.Magnet{
+		InfoHashV2: t.Info.InfoHashV2,
+		Name:       t.Info.Name,
+		Size:       t.Info.Size,
+		Trackers:   t.Trackers,
+	}
+	if InfoHash(t.RawInfo) == t.Info.InfoHash {
+		m.InfoHash = t.Info.InfoHash
+	}
+	return m.String()
+}
+
+// SearchResult 把种子转换为搜索结果，不附带元数据
+func (t Torrent) SearchResult() models.SearchResult {
+	info := t.Info
+	return models.SearchResult{
+		Title:     info.Name,
+		Magnet:    t.Magnet(),
+		InfoHash:  info.InfoHash,
+		Trackers:  t.Trackers,
+		Size:      utils.PtrInt64(info.Size),
+		SizeLabel: info.SizeLabel,
+		Category:  "Torrent File",
+		Source:    "torrent-file",
+	}
+}
+
+// BuildTorrent 用 info 字典的原始编码和 tracker 列表生成 .torrent 文件
+// 每个 tracker 单独作为 announce-list 的一层，客户端按顺序尝试
+func BuildTorrent(rawInfo []byte, trackers []string) ([]byte, error) {
+	torrent := map[string]any{
+		"info":       bencode.Raw(rawInfo),
+		"created by": createdBy,
+	}
+	if len(trackers) > 0 {
+		tiers := make([]any, len(trackers))
+		for i, tracker := range trackers {
+			tiers[i] = []string{tracker}
+		}
+		torrent["announce"] = trackers[0]
+		torrent["announce-list"] = tiers
+	}
+	return bencode.Encode(torrent)
+}
@@ -7,39 +7,82 @@ import (
 
 // SearchResult 表示单个搜索结果
 type SearchResult struct {
-    Title     string     `json:"title"`
-    Magnet    string     `json:"magnet"`
-    InfoHash  string     `json:"infoHash,omitempty"`
-    Trackers  []string   `json:"trackers,omitempty"`
-    Seeders   *int       `json:"seeders"`
-    Leechers  *int       `json:"leechers"`
-    Size      *int64     `json:"size"`
-    SizeLabel string     `json:"sizeLabel,omitempty"`
-    Uploaded  *time.Time `json:"uploaded"`
-    Category  string     `json:"category,omitempty"`
-    Source    string     `json:"source,omitempty"`
+    Title     string       `json:"title"`
+    Magnet    string       `json:"magnet"`
+    InfoHash  string       `json:"infoHash,omitempty"`
+    Trackers  []string     `json:"trackers,omitempty"`
+    Seeders   *int         `json:"seeders"`
+    Leechers  *int         `json:"leechers"`
+    Size      *int64       `json:"size"`
+    SizeLabel string       `json:"sizeLabel,omitempty"`
+    Uploaded  *time.Time   `json:"uploaded"`
+    Category  string       `json:"category,omitempty"`
+    Source    string       `json:"source,omitempty"`
+    Sources   []string     `json:"sources,omitempty"`
+    // Metadata 是按需获取的种子文件列表，仅在请求 metadata=true 时填充
+    Metadata  *TorrentInfo `json:"metadata,omitempty"`
 }
 
 // SearchMeta 包含搜索元数据信息
 type SearchMeta struct {
-    Mode                 string `json:"mode"`
-    Adapter              string `json:"adapter,omitempty"`
-    AdapterName          string `json:"adapterName,omitempty"`
-    AdapterDescription   string `json:"adapterDescription,omitempty"`
-    AdapterEndpoint      string `json:"adapterEndpoint,omitempty"`
-    ResultCount          int    `json:"resultCount"`
-    AdapterError         string `json:"adapterError,omitempty"`
-    FallbackUsed         bool   `json:"fallbackUsed"`
-    FallbackAdapter      string `json:"fallbackAdapter,omitempty"`
-    FallbackAdapterName  string `json:"fallbackAdapterName,omitempty"`
-    FallbackAdapterError string `json:"fallbackAdapterError,omitempty"`
+    Mode                string `json:"mode"`
+    Adapter             string `json:"adapter,omitempty"`
+    AdapterName         string `json:"adapterName,omitempty"`
+    AdapterDescription  string `json:"adapterDescription,omitempty"`
+    AdapterEndpoint     string `json:"adapterEndpoint,omitempty"`
+    ResultCount         int    `json:"resultCount"`
+    AdapterError        string `json:"adapterError,omitempty"`
+    FallbackUsed        bool   `json:"fallbackUsed"`
+    FallbackAdapter     string `json:"fallbackAdapter,omitempty"`
+    FallbackAdapterName string `json:"fallbackAdapterName,omitempty"`
+    // Attempts 按顺序记录主适配器和备用适配器链的每次尝试
+    Attempts []AdapterStatus `json:"attempts,omitempty"`
+    // Adapters 记录聚合搜索中每个适配器的执行情况
+    Adapters []AdapterStatus `json:"adapters,omitempty"`
     // Pagination fields
-    CurrentPage int  `json:"currentPage,omitempty"`
-    TotalPages  int  `json:"totalPages,omitempty"`
-    HasNextPage bool `json:"hasNextPage,omitempty"`
-    HasPrevPage bool `json:"hasPrevPage,omitempty"`
+    CurrentPage  int  `json:"currentPage,omitempty"`
+    TotalPages   int  `json:"totalPages,omitempty"`
+    TotalResults int  `json:"totalResults,omitempty"`
+    HasNextPage  bool `json:"hasNextPage,omitempty"`
+    HasPrevPage  bool `json:"hasPrevPage,omitempty"`
+    // Cached 表示结果来自缓存，CachedAt 为结果从上游获取的时间（聚合搜索取最早的时间）
+    Cached   bool       `json:"cached"`
+    CachedAt *time.Time `json:"cachedAt,omitempty"`
+    // CategoryIgnored 表示返回结果的适配器（聚合搜索中任一返回结果的适配器）无法按请求的分类过滤
+    CategoryIgnored bool `json:"categoryIgnored,omitempty"`
 }
 
+// AdapterStatus 记录单个适配器在一次搜索中的执行情况
+type AdapterStatus struct {
+    Adapter     string `json:"adapter"`
+    AdapterName string `json:"adapterName,omitempty"`
+    Status      string `json:"status"`
+    LatencyMs   int64  `json:"latencyMs"`
+    ResultCount int    `json:"resultCount"`
+    Error       string `json:"error,omitempty"`
+    // Requests 为本次调用发出的 HTTP 请求数，Retries 为其中因临时性错误重试的次数
+    Requests int `json:"requests,omitempty"`
+    Retries  int `json:"retries,omitempty"`
+    // Cached 表示本次结果来自缓存，Shared 表示本次结果来自其他请求发起的相同搜索
+    Cached bool `json:"cached,omitempty"`
+    Shared bool `json:"shared,omitempty"`
+    // Endpoint 是实际返回结果的上游地址，适配器配置了多个镜像时为所用的镜像
+    Endpoint string `json:"endpoint,omitempty"`
+    // CategoryIgnored 表示适配器无法按请求的分类过滤，返回的是未过滤的结果
+    CategoryIgnored bool `json:"categoryIgnored,omitempty"`
+}
+
+// 适配器执行状态
+const (
+    AdapterStatusOK      = "ok"
+    AdapterStatusEmpty   = "empty"
+    AdapterStatusError   = "error"
+    AdapterStatusTimeout = "timeout"
+    AdapterStatusSkipped = "circuit-open"
+    // AdapterStatusRateLimited 表示请求超出了本地的上游速率限制而未发出
+    AdapterStatusRateLimited = "rate-limited"
+)
+
 // SearchResponse 是搜索 API 的响应结构
 type SearchResponse struct {
     Query   string         `json:"query"`
@@ -47,12 +90,52 @@ type SearchResponse struct {
     Meta    SearchMeta     `json:"meta"`
 }
 
-// SearchOptions 包含搜索选项，包括分页参数
+// SearchOptions 包含搜索选项，包括分页、分类、排序和过滤参数
+// Category 可以是通用分类（Category* 常量），也可以是上游原生的分类代码（如 nyaa 的 "1_2"、apibay 的 "201"），
+// 适配器负责将其转换为上游参数，无法支持的选项会被忽略；忽略分类时 SearchMeta.CategoryIgnored 为 true
 type SearchOptions struct {
-    Query string `json:"query"`
-    Page  int    `json:"page"`
+    Query    string `json:"query"`
+    Page     int    `json:"page"`
+    Category string `json:"category,omitempty"`
+    Sort     string `json:"sort,omitempty"`
+    Order    string `json:"order,omitempty"`
+    Filter   string `json:"filter,omitempty"`
 }
 
+// 通用分类
+const (
+    CategoryAnime    = "anime"
+    CategoryMovies   = "movies"
+    CategoryTV       = "tv"
+    CategoryAudio    = "audio"
+    CategoryBooks    = "books"
+    CategorySoftware = "software"
+    CategoryGames    = "games"
+    CategoryPictures = "pictures"
+)
+
+// 排序字段
+const (
+    SortSeeders   = "seeders"
+    SortLeechers  = "leechers"
+    SortSize      = "size"
+    SortDate      = "date"
+    SortDownloads = "downloads"
+)
+
+// 排序方向
+const (
+    OrderAsc  = "asc"
+    OrderDesc = "desc"
+)
+
+// 过滤器
+const (
+    FilterNone      = ""
+    FilterNoRemakes = "no-remakes"
+    FilterTrusted   = "trusted"
+)
+
 // PaginationInfo 包含分页信息
 type PaginationInfo struct {
     CurrentPage int  `json:"currentPage"`
@@ -71,23 +154,117 @@ type Adapter interface {
     SearchWithOptions(ctx context.Context, options SearchOptions) ([]SearchResult, error)
 }
 
+// ResultPage 是一页搜索结果及上游报告的总数，Total 和 TotalPages 为 0 表示未知
+// CachedAt 非零表示结果来自缓存，值为结果从上游获取的时间；Shared 表示结果来自合并的并发请求
+// Endpoint 是实际返回结果的上游地址（配置了多个镜像时为所用的镜像），为空表示未知
+type ResultPage struct {
+    Results    []SearchResult
+    Total      int
+    TotalPages int
+    CachedAt   time.Time
+    Shared     bool
+    Endpoint   string
+}
+
+// PagedAdapter 是可选接口，适配器实现后返回带总结果数和总页数的结果页
+type PagedAdapter interface {
+    SearchPage(ctx context.Context, options SearchOptions) (ResultPage, error)
+}
+
+// Capabilities 描述适配器声明支持的功能
+// PageSize 为上游每页返回的结果数，0 表示未知；Categories、Sort、Filters 列出可用的通用选项取值
+type Capabilities struct {
+    Pagination     bool     `json:"pagination"`
+    PageSize       int      `json:"pageSize,omitempty"`
+    Categories     []string `json:"categories,omitempty"`
+    Sort           []string `json:"sort,omitempty"`
+    Filters        []string `json:"filters,omitempty"`
+    InfoHashLookup bool     `json:"infoHashLookup"`
+}
+
+// CapableAdapter 是可选接口，适配器实现后声明自身支持的功能
+// 未实现该接口的适配器视为不支持分页、分类、排序和过滤
+type CapableAdapter interface {
+    Capabilities() Capabilities
+}
+
+// CategoryAdapter 是可选接口，适配器实现后报告能否按给定分类过滤（包括上游原生的分类代码）
+// 未实现时只有 Capabilities 中声明的通用分类视为支持
+type CategoryAdapter interface {
+    SupportsCategory(category string) bool
+}
+
+// EndpointAdapter 是可选接口，适配器实现后允许在运行时修改上游地址
+// 逗号分隔的多个地址按顺序作为镜像，Endpoint() 返回当前使用的镜像，Mirrors 返回全部镜像
+type EndpointAdapter interface {
+    SetEndpoint(endpoint string) error
+    Mirrors() []string
+}
+
 // AdapterInfo 包含适配器的基本信息
 type AdapterInfo struct {
-    ID          string `json:"id"`
-    Name        string `json:"name"`
-    Description string `json:"description"`
-    Endpoint    string `json:"endpoint,omitempty"`
-    Default     bool   `json:"default"`
-    Fallback    bool   `json:"fallback"`
+    ID          string        `json:"id"`
+    Name        string        `json:"name"`
+    Description string        `json:"description"`
+    Endpoint    string        `json:"endpoint,omitempty"`
+    // Mirrors 是按顺序尝试的全部镜像地址，Endpoint 为当前使用的镜像
+    Mirrors []string `json:"mirrors,omitempty"`
+    // EndpointEditable 表示上游地址可以在运行时修改，Enabled 为 false 的适配器不参与搜索
+    EndpointEditable bool          `json:"endpointEditable"`
+    Enabled          bool          `json:"enabled"`
+    Default          bool          `json:"default"`
+    Fallback         bool          `json:"fallback"`
+    Health           AdapterHealth `json:"health"`
+    Capabilities     Capabilities  `json:"capabilities"`
+}
+
+// AdapterHealth 描述适配器的健康状况和熔断器状态
+type AdapterHealth struct {
+    State               string     `json:"state"`
+    Successes           int64      `json:"successes"`
+    Failures            int64      `json:"failures"`
+    ConsecutiveFailures int        `json:"consecutiveFailures"`
+    LastLatencyMs       int64      `json:"lastLatencyMs"`
+    AvgLatencyMs        int64      `json:"avgLatencyMs"`
+    LastError           string     `json:"lastError,omitempty"`
+    LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+    LastFailureAt       *time.Time `json:"lastFailureAt,omitempty"`
+    RetryAt             *time.Time `json:"retryAt,omitempty"`
 }
 
 // CollectionItem 表示集合中的单个条目
 type CollectionItem struct {
-    Magnet    string    `json:"magnet"`
-    Keywords  string    `json:"keywords"`
-    Remarks   string    `json:"remarks"`
-    Title     string    `json:"title"`
-    Starred   bool      `json:"starred"`
-    AddedAt   time.Time `json:"addedAt"`
+    Magnet    string       `json:"magnet"`
+    Keywords  string       `json:"keywords"`
+    Remarks   string       `json:"remarks"`
+    Title     string       `json:"title"`
+    Starred   bool         `json:"starred"`
+    AddedAt   time.Time    `json:"addedAt"`
+    // 最近一次 tracker scrape 得到的做种数和下载数
+    Seeders   *int         `json:"seeders,omitempty"`
+    Leechers  *int         `json:"leechers,omitempty"`
+    ScrapedAt *time.Time   `json:"scrapedAt,omitempty"`
+    // Metadata 是按需获取的种子文件列表，仅在请求 metadata=true 时填充
+    Metadata  *TorrentInfo `json:"metadata,omitempty"`
+}
+
+// TorrentFile 表示种子中的单个文件，Path 以 / 分隔
+type TorrentFile struct {
+    Path string `json:"path"`
+    Size int64  `json:"size"`
+}
+
+// TorrentInfo 是从种子 info 字典中解析出的文件列表、总大小和分块信息
+type TorrentInfo struct {
+    InfoHash    string        `json:"infoHash"`
+    // InfoHashV2 是 BitTorrent v2（BEP 52）的 SHA-256 info hash，仅 v2 和混合种子有
+    InfoHashV2  string        `json:"infoHashV2,omitempty"`
+    Name        string        `json:"name"`
+    Size        int64         `json:"size"`
+    SizeLabel   string        `json:"sizeLabel"`
+    PieceLength int64         `json:"pieceLength"`
+    Pieces      int           `json:"pieces"`
+    Private     bool          `json:"private,omitempty"`
+    Files       []TorrentFile `json:"files"`
 }
 
@@ -0,0 +1,187 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// 熔断器状态
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+const (
+	// DefaultFailureThreshold 是连续失败多少次后打开熔断器
+	DefaultFailureThreshold = 3
+	// DefaultCooldown 是熔断器打开后再次探测前的冷却时间
+	DefaultCooldown = 30 * time.Second
+	// latencyWeight 是平均延迟指数滑动平均的权重
+	latencyWeight = 0.3
+)
+
+// breaker 记录单个适配器的调用统计和熔断状态
+type breaker struct {
+	state               string
+	successes           int64
+	failures            int64
+	consecutiveFailures int
+	lastLatency         time.Duration
+	avgLatency          time.Duration
+	lastError           string
+	lastSuccessAt       time.Time
+	lastFailureAt       time.Time
+	openedAt            time.Time
+	probing             bool
+}
+
+func newBreaker() *breaker {
+	return &breaker{state: CircuitClosed}
+}
+
+// SetBreakerPolicy 配置熔断阈值和冷却时间，非正值表示使用默认值
+func (r *AdapterRegistry) SetBreakerPolicy(threshold int, cooldown time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+	r.threshold = threshold
+	r.cooldown = cooldown
+}
+
+// Allow 判断当前是否允许调用指定适配器
+// 熔断器打开且冷却时间已过时，会放行一次探测请求并进入半开状态
+func (r *AdapterRegistry) Allow(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[id]
+	if !ok {
+		return true
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		if r.now().Sub(b.openedAt) < r.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordResult 记录一次适配器调用的结果和耗时
+func (r *AdapterRegistry) RecordResult(id string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[id]
+	if !ok {
+		return
+	}
+
+	now := r.now()
+	b.probing = false
+	b.lastLatency = latency
+	if b.avgLatency == 0 {
+		b.avgLatency = latency
+	} else {
+		b.avgLatency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(b.avgLatency))
+	}
+
+	if err == nil {
+		b.successes++
+		b.consecutiveFailures = 0
+		b.lastSuccessAt = now
+		b.state = CircuitClosed
+		return
+	}
+
+	b.failures++
+	b.consecutiveFailures++
+	b.lastError = err.Error()
+	b.lastFailureAt = now
+
+	if b.state == CircuitHalfOpen || b.consecutiveFailures >= r.threshold {
+		b.state = CircuitOpen
+		b.openedAt = now
+	}
+}
+
+// Abandon 放弃一次未完成的调用（例如客户端取消），释放半开状态下的探测名额
+func (r *AdapterRegistry) Abandon(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b, ok := r.breakers[id]; ok {
+		b.probing = false
+	}
+}
+
+// Health 返回指定适配器的健康状况
+func (r *AdapterRegistry) Health(id string) models.AdapterHealth {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.healthLocked(id)
+}
+
+func (r *AdapterRegistry) healthLocked(id string) models.AdapterHealth {
+	b, ok := r.breakers[id]
+	if !ok {
+		return models.AdapterHealth{State: CircuitClosed}
+	}
+
+	health := models.AdapterHealth{
+		State:               b.state,
+		Successes:           b.successes,
+		Failures:            b.failures,
+		ConsecutiveFailures: b.consecutiveFailures,
+		LastLatencyMs:       b.lastLatency.Milliseconds(),
+		AvgLatencyMs:        b.avgLatency.Milliseconds(),
+		LastError:           b.lastError,
+		LastSuccessAt:       timePtr(b.lastSuccessAt),
+		LastFailureAt:       timePtr(b.lastFailureAt),
+	}
+	if b.state == CircuitOpen {
+		health.RetryAt = timePtr(b.openedAt.Add(r.cooldown))
+	}
+	return health
+}
+
+// openLocked 判断适配器的熔断器是否处于打开状态且仍在冷却期内
+func (r *AdapterRegistry) openLocked(id string) bool {
+	b, ok := r.breakers[id]
+	if !ok {
+		return false
+	}
+	switch b.state {
+	case CircuitOpen:
+		return r.now().Sub(b.openedAt) < r.cooldown
+	case CircuitHalfOpen:
+		return b.probing
+	default:
+		return false
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+type fakeAdapter struct{ id string }
+
+func (a fakeAdapter) ID() string          { return a.id }
+func (a fakeAdapter) Name() string        { return a.id }
+func (a fakeAdapter) Description() string { return "" }
+func (a fakeAdapter) Endpoint() string    { return "" }
+
+func (a fakeAdapter) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return nil, nil
+}
+
+func (a fakeAdapter) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	return nil, nil
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New()
+	r.now = func() time.Time { return now }
+	r.SetBreakerPolicy(2, time.Minute)
+	r.Register(fakeAdapter{id: "nyaa"})
+	r.Register(fakeAdapter{id: "sample"})
+	if err := r.Configure("sample", "nyaa"); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("timeout")
+	r.RecordResult("nyaa", time.Second, failure)
+	if !r.Allow("nyaa") {
+		t.Fatal("circuit opened before reaching threshold")
+	}
+	r.RecordResult("nyaa", time.Second, failure)
+
+	if got := r.Health("nyaa").State; got != CircuitOpen {
+		t.Fatalf("state = %q, want open", got)
+	}
+	if r.Allow("nyaa") {
+		t.Error("open circuit allowed a call")
+	}
+	if len(r.Fallbacks("sample")) != 0 {
+		t.Error("open circuit returned as fallback")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !r.Allow("nyaa") {
+		t.Fatal("probe not allowed after cool-down")
+	}
+	if r.Allow("nyaa") {
+		t.Error("second concurrent probe allowed")
+	}
+
+	r.RecordResult("nyaa", time.Second, failure)
+	if got := r.Health("nyaa").State; got != CircuitOpen {
+		t.Fatalf("failed probe: state = %q, want open", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !r.Allow("nyaa") {
+		t.Fatal("probe not allowed after second cool-down")
+	}
+	r.RecordResult("nyaa", 100*time.Millisecond, nil)
+
+	health := r.Health("nyaa")
+	if health.State != CircuitClosed || health.ConsecutiveFailures != 0 {
+		t.Errorf("after successful probe: %+v", health)
+	}
+	if health.Successes != 1 || health.Failures != 3 {
+		t.Errorf("counters = %d/%d, want 1/3", health.Successes, health.Failures)
+	}
+}
@@ -3,24 +3,43 @@ package registry
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/seedmanage/backend/internal/models"
 )
 
 // AdapterRegistry 管理所有已注册的适配器
 type AdapterRegistry struct {
-	mu         sync.RWMutex
-	adapters   map[string]models.Adapter
-	defaultID  string
-	fallbackID string
+	mu          sync.RWMutex
+	adapters    map[string]models.Adapter
+	defaultID   string
+	fallbackIDs []string
+	breakers    map[string]*breaker
+	threshold   int
+	cooldown    time.Duration
+	now         func() time.Time
+	// disabled、endpoints 和 routingChanged（默认/备用适配器是否在运行时修改过）是运行时设置，
+	// settingsPath 非空时修改会被持久化
+	disabled       map[string]bool
+	endpoints      map[string]string
+	routingChanged bool
+	settingsPath   string
 }
 
 // New 创建一个新的适配器注册器
 func New() *AdapterRegistry {
 	return &AdapterRegistry{
-		adapters: make(map[string]models.Adapter),
+		adapters:  make(map[string]models.Adapter),
+		breakers:  make(map[string]*breaker),
+		disabled:  make(map[string]bool),
+		endpoints: make(map[string]string),
+		threshold: DefaultFailureThreshold,
+		cooldown:  DefaultCooldown,
+		now:       time.Now,
 	}
 }
 
@@ -29,10 +48,14 @@ func (r *AdapterRegistry) Register(adapter models.Adapter) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.adapters[adapter.ID()] = adapter
+	if _, ok := r.breakers[adapter.ID()]; !ok {
+		r.breakers[adapter.ID()] = newBreaker()
+	}
 }
 
-// Configure 配置默认和备用适配器
-func (r *AdapterRegistry) Configure(defaultID, fallbackID string) error {
+// Configure 配置默认适配器和按顺序尝试的备用适配器链
+// 备用链中未注册的适配器会被跳过并在错误中报告，其余配置仍然生效并持久化
+func (r *AdapterRegistry) Configure(defaultID string, fallbackIDs ...string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -41,11 +64,13 @@ func (r *AdapterRegistry) Configure(defaultID, fallbackID string) error {
 	}
 
 	if defaultID != "" {
-		if _, ok := r.adapters[defaultID]; ok {
-			r.defaultID = defaultID
-		} else {
+		if _, ok := r.adapters[defaultID]; !ok {
 			return fmt.Errorf("default adapter %s not registered", defaultID)
 		}
+		if r.disabled[defaultID] {
+			return fmt.Errorf("default adapter %s is disabled", defaultID)
+		}
+		r.defaultID = defaultID
 	}
 
 	if r.defaultID == "" {
@@ -55,15 +80,27 @@ func (r *AdapterRegistry) Configure(defaultID, fallbackID string) error {
 		}
 	}
 
-	if fallbackID != "" && fallbackID != r.defaultID {
-		if _, ok := r.adapters[fallbackID]; ok {
-			r.fallbackID = fallbackID
-		} else {
-			return fmt.Errorf("fallback adapter %s not registered", fallbackID)
+	var chain []string
+	var missing []string
+	for _, id := range fallbackIDs {
+		if id == "" || id == r.defaultID || slices.Contains(chain, id) {
+			continue
 		}
+		if _, ok := r.adapters[id]; !ok {
+			missing = append(missing, id)
+			continue
+		}
+		chain = append(chain, id)
 	}
+	r.fallbackIDs = chain
+	r.routingChanged = r.settingsPath != ""
 
-	return nil
+	// 未注册的备用适配器被忽略，其余配置照常生效并写入设置文件
+	err := r.persistLocked()
+	if len(missing) > 0 {
+		return errors.Join(fmt.Errorf("fallback adapters %s not registered", strings.Join(missing, ", ")), err)
+	}
+	return err
 }
 
 // Get 获取指定 ID 的适配器
@@ -92,15 +129,50 @@ func (r *AdapterRegistry) DefaultID() string {
 	return r.defaultID
 }
 
-// Fallback 获取备用适配器（排除指定 ID）
-func (r *AdapterRegistry) Fallback(excludeID string) (models.Adapter, bool) {
+// FallbackIDs 返回配置的备用适配器链
+func (r *AdapterRegistry) FallbackIDs() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	if r.fallbackID == "" || r.fallbackID == excludeID {
-		return nil, false
+	return append([]string(nil), r.fallbackIDs...)
+}
+
+// Fallbacks 按顺序返回可用的备用适配器（排除指定 ID 以及已禁用、熔断中的适配器）
+func (r *AdapterRegistry) Fallbacks(excludeID string) []models.Adapter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var fallbacks []models.Adapter
+	for _, id := range r.fallbackIDs {
+		if id == excludeID || r.disabled[id] || r.openLocked(id) {
+			continue
+		}
+		if adapter, ok := r.adapters[id]; ok {
+			fallbacks = append(fallbacks, adapter)
+		}
 	}
-	adapter, ok := r.adapters[r.fallbackID]
-	return adapter, ok
+	return fallbacks
+}
+
+// IDs 返回所有已注册适配器的 ID，默认适配器排在最前
+func (r *AdapterRegistry) IDs() []string {
+	infos := r.List()
+	ids := make([]string, 0, len(infos))
+	for _, info := range infos {
+		ids = append(ids, info.ID)
+	}
+	return ids
+}
+
+// EnabledIDs 返回所有未禁用适配器的 ID，默认适配器排在最前
+func (r *AdapterRegistry) EnabledIDs() []string {
+	infos := r.List()
+	ids := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if info.Enabled {
+			ids = append(ids, info.ID)
+		}
+	}
+	return ids
 }
 
 // List 返回所有适配器的信息列表
@@ -110,14 +182,22 @@ func (r *AdapterRegistry) List() []models.AdapterInfo {
 
 	infos := make([]models.AdapterInfo, 0, len(r.adapters))
 	for id, adapter := range r.adapters {
-		infos = append(infos, models.AdapterInfo{
-			ID:          id,
-			Name:        adapter.Name(),
-			Description: adapter.Description(),
-			Endpoint:    adapter.Endpoint(),
-			Default:     id == r.defaultID,
-			Fallback:    id == r.fallbackID,
-		})
+		info := models.AdapterInfo{
+			ID:           id,
+			Name:         adapter.Name(),
+			Description:  adapter.Description(),
+			Endpoint:     adapter.Endpoint(),
+			Enabled:      !r.disabled[id],
+			Default:      id == r.defaultID,
+			Fallback:     slices.Contains(r.fallbackIDs, id),
+			Health:       r.healthLocked(id),
+			Capabilities: CapabilitiesOf(adapter),
+		}
+		if target, ok := As[models.EndpointAdapter](adapter); ok {
+			info.EndpointEditable = true
+			info.Mirrors = target.Mirrors()
+		}
+		infos = append(infos, info)
 	}
 
 	sort.Slice(infos, func(i, j int) bool {
@@ -133,3 +213,27 @@ func (r *AdapterRegistry) List() []models.AdapterInfo {
 	return infos
 }
 
+// CapabilitiesOf 返回适配器声明的功能，未实现 models.CapableAdapter 时返回零值
+func CapabilitiesOf(adapter models.Adapter) models.Capabilities {
+	if capable, ok := adapter.(models.CapableAdapter); ok {
+		return capable.Capabilities()
+	}
+	return models.Capabilities{}
+}
+
+// As 沿装饰器的 Unwrap 链查找实现了接口 T 的适配器
+// 缓存、并发合并等装饰器只转发 models.Adapter 的方法，其他可选接口需要在被包装的适配器上查找
+func As[T any](adapter models.Adapter) (T, bool) {
+	for adapter != nil {
+		if target, ok := adapter.(T); ok {
+			return target, true
+		}
+		wrapper, ok := adapter.(interface{ Unwrap() models.Adapter })
+		if !ok {
+			break
+		}
+		adapter = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// Settings 是可在运行时修改并持久化的注册器配置，只记录运行时修改过的部分
+type Settings struct {
+	Default  string                     `json:"default,omitempty"`
+	Fallback []string                   `json:"fallback,omitempty"`
+	Adapters map[string]AdapterSettings `json:"adapters,omitempty"`
+}
+
+// AdapterSettings 是单个适配器的运行时设置
+type AdapterSettings struct {
+	Disabled bool   `json:"disabled,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// SetEnabled 启用或禁用适配器，禁用的适配器不参与聚合搜索和备用链；默认适配器不能被禁用
+func (r *AdapterRegistry) SetEnabled(id string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.adapters[id]; !ok {
+		return fmt.Errorf("adapter %s not registered", id)
+	}
+	if !enabled && id == r.defaultID {
+		return fmt.Errorf("default adapter %s cannot be disabled", id)
+	}
+	if enabled {
+		delete(r.disabled, id)
+	} else {
+		r.disabled[id] = true
+	}
+	return r.persistLocked()
+}
+
+// Enabled 返回适配器是否已注册且未被禁用
+func (r *AdapterRegistry) Enabled(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.adapters[id]
+	return ok && !r.disabled[id]
+}
+
+// SetEndpoint 修改适配器的上游地址，逗号分隔的多个地址按顺序作为镜像；适配器需要实现 models.EndpointAdapter
+// 修改成功后清除该适配器的缓存结果
+func (r *AdapterRegistry) SetEndpoint(id, endpoint string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	adapter, ok := r.adapters[id]
+	if !ok {
+		return fmt.Errorf("adapter %s not registered", id)
+	}
+	target, ok := As[models.EndpointAdapter](adapter)
+	if !ok {
+		return fmt.Errorf("adapter %s does not support changing its endpoint", id)
+	}
+	if err := target.SetEndpoint(endpoint); err != nil {
+		return err
+	}
+	// 缓存中是旧地址返回的结果，修改地址后立即清除
+	if cached, ok := As[purger](adapter); ok {
+		cached.Purge()
+	}
+	r.endpoints[id] = strings.Join(target.Mirrors(), ",")
+	return r.persistLocked()
+}
+
+// purger 由搜索结果缓存装饰器实现，清除适配器已缓存的结果
+type purger interface {
+	Purge()
+}
+
+// Settings 返回当前的运行时设置
+func (r *AdapterRegistry) Settings() Settings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.settingsLocked()
+}
+
+func (r *AdapterRegistry) settingsLocked() Settings {
+	settings := Settings{Adapters: make(map[string]AdapterSettings)}
+	if r.routingChanged {
+		settings.Default = r.defaultID
+		settings.Fallback = append([]string(nil), r.fallbackIDs...)
+	}
+	for _, id := range slices.Sorted(maps.Keys(r.adapters)) {
+		adapter := AdapterSettings{Disabled: r.disabled[id], Endpoint: r.endpoints[id]}
+		if adapter != (AdapterSettings{}) {
+			settings.Adapters[id] = adapter
+		}
+	}
+	return settings
+}
+
+// UseSettingsFile 从文件加载运行时设置并覆盖启动配置，之后的修改都会写回该文件
+// 文件不存在时只记录路径；文件中引用的未注册适配器会被忽略并在错误中报告
+func (r *AdapterRegistry) UseSettingsFile(path string) error {
+	var settings Settings
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("read adapter settings: %w", err)
+	default:
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("parse adapter settings: %w", err)
+		}
+	}
+
+	// 先切换默认适配器，避免禁用启动配置中的默认适配器时失败
+	var errs []error
+	routing := settings.Default != "" || len(settings.Fallback) > 0
+	if routing {
+		if err := r.Configure(settings.Default, settings.Fallback...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(settings.Adapters)) {
+		adapter := settings.Adapters[id]
+		if adapter.Endpoint != "" {
+			if err := r.SetEndpoint(id, adapter.Endpoint); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if adapter.Disabled {
+			if err := r.SetEnabled(id, false); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.settingsPath = path
+	r.routingChanged = routing
+	r.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// persistLocked 在设置了持久化文件时写入当前设置
+func (r *AdapterRegistry) persistLocked() error {
+	if r.settingsPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.settingsLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode adapter settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.settingsPath), 0o755); err != nil {
+		return fmt.Errorf("ensure adapter settings dir: %w", err)
+	}
+	tmpPath := r.settingsPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("write adapter settings: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.settingsPath); err != nil {
+		return fmt.Errorf("replace adapter settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// endpointAdapter 是可修改上游地址的测试适配器
+type endpointAdapter struct {
+	fakeAdapter
+	endpoint string
+}
+
+func (a *endpointAdapter) Endpoint() string { return a.endpoint }
+
+func (a *endpointAdapter) Mirrors() []string { return []string{a.endpoint} }
+
+func (a *endpointAdapter) SetEndpoint(endpoint string) error {
+	if endpoint == "" {
+		return errors.New("empty endpoint")
+	}
+	a.endpoint = endpoint
+	return nil
+}
+
+// wrapper 模拟缓存、合并等装饰器
+type wrapper struct{ models.Adapter }
+
+func (w wrapper) Unwrap() models.Adapter { return w.Adapter }
+
+func newSettingsRegistry(t *testing.T) *AdapterRegistry {
+	t.Helper()
+	r := New()
+	r.Register(fakeAdapter{id: "sample"})
+	r.Register(wrapper{&endpointAdapter{fakeAdapter: fakeAdapter{id: "nyaa"}, endpoint: "https://nyaa.si"}})
+	r.Register(fakeAdapter{id: "sukebei"})
+	if err := r.Configure("sample", "nyaa", "sukebei"); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSetEnabled(t *testing.T) {
+	r := newSettingsRegistry(t)
+
+	if err := r.SetEnabled("sample", false); err == nil {
+		t.Error("disabling the default adapter succeeded")
+	}
+	if err := r.SetEnabled("nyaa", false); err != nil {
+		t.Fatal(err)
+	}
+	if r.Enabled("nyaa") {
+		t.Error("nyaa still enabled")
+	}
+	if got := r.EnabledIDs(); slices.Contains(got, "nyaa") {
+		t.Errorf("EnabledIDs() = %v, want nyaa excluded", got)
+	}
+	var fallbacks []string
+	for _, adapter := range r.Fallbacks("sample") {
+		fallbacks = append(fallbacks, adapter.ID())
+	}
+	if !slices.Equal(fallbacks, []string{"sukebei"}) {
+		t.Errorf("Fallbacks() = %v, want [sukebei]", fallbacks)
+	}
+	if err := r.Configure("nyaa"); err == nil {
+		t.Error("disabled adapter accepted as default")
+	}
+
+	if err := r.SetEnabled("nyaa", true); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Enabled("nyaa") {
+		t.Error("nyaa not re-enabled")
+	}
+}
+
+func TestSetEndpointThroughWrapper(t *testing.T) {
+	r := newSettingsRegistry(t)
+
+	if err := r.SetEndpoint("nyaa", "https://nyaa.example"); err != nil {
+		t.Fatal(err)
+	}
+	adapter, _ := r.Get("nyaa")
+	if got := adapter.Endpoint(); got != "https://nyaa.example" {
+		t.Errorf("Endpoint() = %q, want https://nyaa.example", got)
+	}
+	if err := r.SetEndpoint("sample", "https://sample.example"); err == nil {
+		t.Error("adapter without SetEndpoint accepted a new endpoint")
+	}
+
+	for _, info := range r.List() {
+		if editable := info.ID == "nyaa"; info.EndpointEditable != editable {
+			t.Errorf("%s EndpointEditable = %v, want %v", info.ID, info.EndpointEditable, editable)
+		}
+	}
+}
+
+// purgingWrapper 模拟搜索结果缓存装饰器
+type purgingWrapper struct {
+	wrapper
+	purged *int
+}
+
+func (w purgingWrapper) Purge() { *w.purged++ }
+
+func TestSetEndpointPurgesCache(t *testing.T) {
+	purged := 0
+	r := New()
+	r.Register(purgingWrapper{wrapper{&endpointAdapter{fakeAdapter: fakeAdapter{id: "nyaa"}, endpoint: "https://nyaa.si"}}, &purged})
+	if err := r.Configure("nyaa"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetEndpoint("nyaa", ""); err == nil || purged != 0 {
+		t.Fatalf("invalid endpoint: err = %v, purged %d times", err, purged)
+	}
+	if err := r.SetEndpoint("nyaa", "https://nyaa.example"); err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Errorf("cache purged %d times, want 1", purged)
+	}
+}
+
+func TestSettingsFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adapters.json")
+
+	r := newSettingsRegistry(t)
+	if err := r.UseSettingsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("settings file written before any change: %v", err)
+	}
+	if err := r.SetEndpoint("nyaa", "https://nyaa.example"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Configure("nyaa", "sample"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetEnabled("sukebei", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后加载设置文件，覆盖启动配置
+	restored := newSettingsRegistry(t)
+	if err := restored.UseSettingsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.DefaultID(); got != "nyaa" {
+		t.Errorf("DefaultID() = %q, want nyaa", got)
+	}
+	if got := restored.FallbackIDs(); !slices.Equal(got, []string{"sample"}) {
+		t.Errorf("FallbackIDs() = %v, want [sample]", got)
+	}
+	if restored.Enabled("sukebei") {
+		t.Error("sukebei not disabled after restore")
+	}
+	adapter, _ := restored.Get("nyaa")
+	if got := adapter.Endpoint(); got != "https://nyaa.example" {
+		t.Errorf("Endpoint() = %q, want https://nyaa.example", got)
+	}
+}
+
+func TestConfigurePersistsWithMissingFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adapters.json")
+
+	r := newSettingsRegistry(t)
+	if err := r.UseSettingsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Configure("nyaa", "missing", "sample"); err == nil {
+		t.Fatal("Configure accepted an unregistered fallback adapter")
+	}
+
+	// 有效的部分已经生效，重启后也要保留
+	restored := newSettingsRegistry(t)
+	if err := restored.UseSettingsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.DefaultID(); got != "nyaa" {
+		t.Errorf("DefaultID() = %q, want nyaa", got)
+	}
+	if got := restored.FallbackIDs(); !slices.Equal(got, []string{"sample"}) {
+		t.Errorf("FallbackIDs() = %v, want [sample]", got)
+	}
+}
+
+func TestSettingsFileCanDisableStartupDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adapters.json")
+	data := `{"default":"nyaa","adapters":{"sample":{"disabled":true}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newSettingsRegistry(t)
+	if err := r.UseSettingsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.DefaultID(); got != "nyaa" {
+		t.Errorf("DefaultID() = %q, want nyaa", got)
+	}
+	if r.Enabled("sample") {
+		t.Error("sample still enabled")
+	}
+}
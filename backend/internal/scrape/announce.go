@@ -0,0 +1,133 @@
+package scrape
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/seedmanage/backend/internal/bencode"
+	"github.com/seedmanage/backend/internal/httpclient"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+	// announcePort 是 announce 中声明的端口，服务本身不接受 peer 连接
+	announcePort = 6881
+	// announceNumWant 是向 tracker 请求的 peer 数
+	announceNumWant = 200
+	// announceLeft 是声明的剩余字节数，种子大小未知，非 0 表示下载者
+	announceLeft = 1
+)
+
+// Announce 向一个 tracker 宣告 info hash 并返回 tracker 给出的 peer 地址
+// 不发送 started 事件，tracker 不会把服务记为 swarm 中的 peer
+func (s *Scraper) Announce(ctx context.Context, tracker, infoHash string) ([]netip.AddrPort, error) {
+	raw, err := decodeInfoHash(utils.NormalizeInfoHash(infoHash))
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(tracker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracker url: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	switch u.Scheme {
+	case "udp":
+		return announceUDP(ctx, u.Host, raw, s.peerID)
+	case "http", "https":
+		return s.announceHTTP(ctx, u, raw)
+	default:
+		return nil, ErrUnsupportedTracker
+	}
+}
+
+func (s *Scraper) announceHTTP(ctx context.Context, announce *url.URL, infoHash [20]byte) ([]netip.AddrPort, error) {
+	u := *announce
+	params := []string{
+		"info_hash=" + url.QueryEscape(string(infoHash[:])),
+		"peer_id=" + url.QueryEscape(string(s.peerID[:])),
+		"port=" + strconv.Itoa(announcePort),
+		"uploaded=0",
+		"downloaded=0",
+		"left=" + strconv.Itoa(announceLeft),
+		"compact=1",
+		"numwant=" + strconv.Itoa(announceNumWant),
+	}
+	if u.RawQuery != "" {
+		params = append([]string{u.RawQuery}, params...)
+	}
+	u.RawQuery = strings.Join(params, "&")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = httpclient.DefaultHeaders()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tracker returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpResponseLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := bencode.Decode(body)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := decoded.(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid announce response")
+	}
+	if reason, ok := dict["failure reason"].(string); ok {
+		return nil, fmt.Errorf("tracker error: %s", reason)
+	}
+
+	var peers []netip.AddrPort
+	switch list := dict["peers"].(type) {
+	case string:
+		peers = parseCompactPeers([]byte(list), 6)
+	case []any:
+		// 非紧凑格式：[{"ip": "...", "port": 6881}, ...]
+		for _, item := range list {
+			entry, _ := item.(map[string]any)
+			ip, _ := entry["ip"].(string)
+			port, _ := entry["port"].(int64)
+			addr, err := netip.ParseAddr(ip)
+			if err == nil && port > 0 && port < 1<<16 {
+				peers = append(peers, netip.AddrPortFrom(addr.Unmap(), uint16(port)))
+			}
+		}
+	}
+	if list, ok := dict["peers6"].(string); ok {
+		peers = append(peers, parseCompactPeers([]byte(list), 18)...)
+	}
+	return peers, nil
+}
+
+// parseCompactPeers 解析紧凑格式的 peer 列表，每个 peer 为 4 或 16 字节地址加 2 字节端口
+func parseCompactPeers(data []byte, size int) []netip.AddrPort {
+	peers := make([]netip.AddrPort, 0, len(data)/size)
+	for offset := 0; offset+size <= len(data); offset += size {
+		addr, ok := netip.AddrFromSlice(data[offset : offset+size-2])
+		port := binary.BigEndian.Uint16(data[offset+size-2:])
+		if ok && port != 0 && !addr.IsUnspecified() {
+			peers = append(peers, netip.AddrPortFrom(addr.Unmap(), port))
+		}
+	}
+	return peers
+}
@@ -0,0 +1,118 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/seedmanage/backend/internal/bencode"
+	"github.com/seedmanage/backend/internal/httpclient"
+)
+
+const (
+	// httpMaxHashes 是单个 HTTP scrape 请求携带的 info hash 数，避免 URL 过长
+	httpMaxHashes = 50
+	// httpResponseLimit 是 scrape 响应的最大字节数
+	httpResponseLimit = 1 << 20
+)
+
+// scrapeURL 按 BEP 48 的约定由 announce 地址得到 scrape 地址：
+// 路径最后一段以 announce 开头时替换为 scrape，否则 tracker 不支持 scrape
+func scrapeURL(announce *url.URL) (*url.URL, error) {
+	i := strings.LastIndex(announce.Path, "/")
+	last := announce.Path[i+1:]
+	if !strings.HasPrefix(last, "announce") {
+		return nil, ErrUnsupportedTracker
+	}
+	u := *announce
+	u.Path = announce.Path[:i+1] + "scrape" + strings.TrimPrefix(last, "announce")
+	u.RawPath = ""
+	return &u, nil
+}
+
+// scrapeHTTP 分批请求 HTTP tracker 的 scrape 地址并解析 bencode 响应
+func (s *Scraper) scrapeHTTP(ctx context.Context, announce *url.URL, hashes [][20]byte) (map[[20]byte]Swarm, error) {
+	endpoint, err := scrapeURL(announce)
+	if err != nil {
+		return nil, err
+	}
+
+	swarms := make(map[[20]byte]Swarm, len(hashes))
+	for start := 0; start < len(hashes); start += httpMaxHashes {
+		u := *endpoint
+		var query strings.Builder
+		query.WriteString(u.RawQuery)
+		for _, hash := range hashes[start:min(start+httpMaxHashes, len(hashes))] {
+			if query.Len() > 0 {
+				query.WriteByte('&')
+			}
+			query.WriteString("info_hash=")
+			query.WriteString(url.QueryEscape(string(hash[:])))
+		}
+		u.RawQuery = query.String()
+
+		if err := s.fetchHTTP(ctx, u.String(), swarms); err != nil {
+			return nil, err
+		}
+	}
+	return swarms, nil
+}
+
+func (s *Scraper) fetchHTTP(ctx context.Context, endpoint string, swarms map[[20]byte]Swarm) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = httpclient.DefaultHeaders()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("tracker returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpResponseLimit))
+	if err != nil {
+		return err
+	}
+
+	decoded, err := bencode.Decode(body)
+	if err != nil {
+		return err
+	}
+	dict, ok := decoded.(map[string]any)
+	if !ok {
+		return errors.New("invalid scrape response")
+	}
+	if reason, ok := dict["failure reason"].(string); ok {
+		return fmt.Errorf("tracker error: %s", reason)
+	}
+	files, ok := dict["files"].(map[string]any)
+	if !ok {
+		return errors.New("scrape response has no files")
+	}
+	for key, value := range files {
+		entry, ok := value.(map[string]any)
+		if len(key) != 20 || !ok {
+			continue
+		}
+		var hash [20]byte
+		copy(hash[:], key)
+		swarms[hash] = Swarm{
+			Seeders:   intField(entry, "complete"),
+			Leechers:  intField(entry, "incomplete"),
+			Completed: intField(entry, "downloaded"),
+		}
+	}
+	return nil
+}
+
+func intField(dict map[string]any, key string) int {
+	n, _ := dict[key].(int64)
+	return int(max(n, 0))
+}
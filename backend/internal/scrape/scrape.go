@@ -0,0 +1,239 @@
+// Package scrape 通过 tracker 的 scrape 接口查询种子的实时做种数和下载数，并通过 announce 获取 peer 列表
+// 支持 UDP tracker 协议（BEP 15）和 HTTP /scrape 约定（BEP 48）
+package scrape
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seedmanage/backend/internal/httpclient"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+	// DefaultTimeout 是单个 tracker 的默认超时
+	DefaultTimeout = 10 * time.Second
+	// maxConcurrentTrackers 是同时查询的 tracker 数
+	maxConcurrentTrackers = 8
+	// peerIDPrefix 是 announce 使用的 peer ID 前缀（Azureus 风格），其余字节随机生成
+	peerIDPrefix = "-SM0100-"
+)
+
+// ErrUnsupportedTracker 表示 tracker 的协议或地址不支持 scrape
+var ErrUnsupportedTracker = errors.New("tracker does not support scrape")
+
+// Swarm 是一个 tracker 报告的种子统计
+type Swarm struct {
+	Seeders   int
+	Leechers  int
+	Completed int
+}
+
+// Stats 是一个 info hash 在所有 tracker 上的统计，各项取响应 tracker 中的最大值
+// Trackers 为返回了该种子统计的 tracker 数，为 0 时各项计数未知
+type Stats struct {
+	InfoHash  string    `json:"infoHash"`
+	Seeders   int       `json:"seeders"`
+	Leechers  int       `json:"leechers"`
+	Completed int       `json:"completed"`
+	Trackers  int       `json:"trackers"`
+	ScrapedAt time.Time `json:"scrapedAt"`
+}
+
+// Report 是一次 scrape 的结果，Results 与请求的 info hash 顺序一致，Errors 按 tracker 记录失败原因
+type Report struct {
+	Results []Stats           `json:"results"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// Lookup 返回指定 info hash 的统计
+func (r Report) Lookup(infoHash string) (Stats, bool) {
+	infoHash = utils.NormalizeInfoHash(infoHash)
+	for _, stats := range r.Results {
+		if stats.InfoHash == infoHash {
+			return stats, true
+		}
+	}
+	return Stats{}, false
+}
+
+// Target 是待查询的种子及其磁力链接中携带的 tracker
+type Target struct {
+	InfoHash string
+	Trackers []string
+}
+
+// Scraper 向 tracker 发送 scrape 和 announce 请求，基础 tracker 会附加到每个种子的 tracker 列表中
+type Scraper struct {
+	baseTrackers []string
+	timeout      time.Duration
+	client       *http.Client
+	peerID       [20]byte
+}
+
+// New 创建 Scraper，timeout 为单个 tracker 的超时，不大于 0 时使用 DefaultTimeout
+func New(baseTrackers []string, timeout time.Duration) *Scraper {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	s := &Scraper{
+		baseTrackers: append([]string(nil), baseTrackers...),
+		timeout:      timeout,
+		client:       httpclient.MustNewClient(httpclient.Config{}, timeout),
+	}
+	copy(s.peerID[:], peerIDPrefix)
+	cryptorand.Read(s.peerID[len(peerIDPrefix):])
+	return s
+}
+
+// ParseMagnet 从磁力链接中提取 scrape 目标
+func ParseMagnet(magnet string) (Target, error) {
+	parsed, _, err := utils.ParseMagnetLink(magnet)
+	if err != nil {
+		return Target{}, err
+	}
+	return Target{InfoHash: utils.NormalizeInfoHash(parsed.InfoHash), Trackers: parsed.Trackers}, nil
+}
+
+// Scrape 查询所有目标在各自 tracker 和基础 tracker 上的统计
+// 同一 tracker 上的多个种子合并为一次请求；部分 tracker 失败时仍返回其余 tracker 的结果
+// ctx 超过截止时间时返回已完成的 tracker 的结果，未完成的 tracker 记为失败；ctx 被取消时返回错误
+func (s *Scraper) Scrape(ctx context.Context, targets []Target) (Report, error) {
+	var order []string
+	hashes := make(map[string][20]byte)
+	byTracker := make(map[string][]string)
+	for _, target := range targets {
+		infoHash := utils.NormalizeInfoHash(target.InfoHash)
+		raw, err := decodeInfoHash(infoHash)
+		if err != nil {
+			return Report{}, err
+		}
+		if _, seen := hashes[infoHash]; !seen {
+			hashes[infoHash] = raw
+			order = append(order, infoHash)
+		}
+		for _, tracker := range s.Trackers(target) {
+			if !slices.Contains(byTracker[tracker], infoHash) {
+				byTracker[tracker] = append(byTracker[tracker], infoHash)
+			}
+		}
+	}
+
+	trackers := make([]string, 0, len(byTracker))
+	for tracker := range byTracker {
+		trackers = append(trackers, tracker)
+	}
+	sort.Strings(trackers)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	swarms := make(map[string][]Swarm)
+	errs := make(map[string]string)
+	sem := make(chan struct{}, maxConcurrentTrackers)
+	for _, tracker := range trackers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				errs[tracker] = ctx.Err().Error()
+				mu.Unlock()
+				return
+			}
+
+			infoHashes := byTracker[tracker]
+			raw := make([][20]byte, len(infoHashes))
+			for i, infoHash := range infoHashes {
+				raw[i] = hashes[infoHash]
+			}
+			trackerCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+			found, err := s.scrapeTracker(trackerCtx, tracker, raw)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[tracker] = err.Error()
+				return
+			}
+			for i, infoHash := range infoHashes {
+				if swarm, ok := found[raw[i]]; ok {
+					swarms[infoHash] = append(swarms[infoHash], swarm)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); errors.Is(err, context.Canceled) {
+		return Report{}, err
+	}
+
+	now := time.Now().UTC()
+	report := Report{Results: make([]Stats, 0, len(order))}
+	for _, infoHash := range order {
+		stats := Stats{InfoHash: infoHash, Trackers: len(swarms[infoHash]), ScrapedAt: now}
+		for _, swarm := range swarms[infoHash] {
+			stats.Seeders = max(stats.Seeders, swarm.Seeders)
+			stats.Leechers = max(stats.Leechers, swarm.Leechers)
+			stats.Completed = max(stats.Completed, swarm.Completed)
+		}
+		report.Results = append(report.Results, stats)
+	}
+	if len(errs) > 0 {
+		report.Errors = errs
+	}
+	return report, nil
+}
+
+// Trackers 返回目标自身的 tracker 和基础 tracker 去重后的列表，目标的 tracker 在前
+func (s *Scraper) Trackers(target Target) []string {
+	var trackers []string
+	for _, tracker := range append(append([]string(nil), target.Trackers...), s.baseTrackers...) {
+		tracker = strings.TrimSpace(tracker)
+		if tracker != "" && !slices.Contains(trackers, tracker) {
+			trackers = append(trackers, tracker)
+		}
+	}
+	return trackers
+}
+
+// scrapeTracker 按 tracker 地址的协议选择 UDP 或 HTTP scrape
+func (s *Scraper) scrapeTracker(ctx context.Context, tracker string, hashes [][20]byte) (map[[20]byte]Swarm, error) {
+	u, err := url.Parse(tracker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracker url: %w", err)
+	}
+	switch u.Scheme {
+	case "udp":
+		return scrapeUDP(ctx, u.Host, hashes)
+	case "http", "https":
+		return s.scrapeHTTP(ctx, u, hashes)
+	default:
+		return nil, ErrUnsupportedTracker
+	}
+}
+
+// decodeInfoHash 把 40 位十六进制 info hash 转换为 20 字节
+func decodeInfoHash(infoHash string) ([20]byte, error) {
+	var raw [20]byte
+	if len(infoHash) != 40 {
+		return raw, fmt.Errorf("invalid info hash %q", infoHash)
+	}
+	if _, err := hex.Decode(raw[:], []byte(infoHash)); err != nil {
+		return raw, fmt.Errorf("invalid info hash %q", infoHash)
+	}
+	return raw, nil
+}
@@ -0,0 +1,305 @@
+package scrape
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	hashA = "0123456789ABCDEF0123456789ABCDEF01234567"
+	hashB = "89ABCDEF0123456789ABCDEF0123456789ABCDEF"
+)
+
+// fakeUDPTracker 是进程内的 UDP tracker，按 BEP 15 响应连接、announce 和 scrape 请求
+// announce 总是返回 announcePeers
+type fakeUDPTracker struct {
+	conn     net.PacketConn
+	swarms   map[[20]byte]Swarm
+	scrapes  atomic.Int32
+	failWith string
+}
+
+// failWith 不为空时对 scrape 请求返回错误响应
+func newFakeUDPTracker(t *testing.T, swarms map[string]Swarm, failWith string) *fakeUDPTracker {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := &fakeUDPTracker{conn: conn, swarms: make(map[[20]byte]Swarm), failWith: failWith}
+	for infoHash, swarm := range swarms {
+		raw, _ := decodeInfoHash(infoHash)
+		tracker.swarms[raw] = swarm
+	}
+	t.Cleanup(func() { conn.Close() })
+	go tracker.serve()
+	return tracker
+}
+
+// announcePeers 是 fakeUDPTracker 对 announce 返回的紧凑 peer 列表，端口为 0 的条目应被忽略
+var announcePeers = []byte{10, 0, 0, 1, 0x1a, 0xe1, 10, 0, 0, 2, 0, 0, 192, 168, 1, 9, 0xc8, 0xd5}
+
+func (f *fakeUDPTracker) url() string {
+	return "udp://" + f.conn.LocalAddr().String() + "/announce"
+}
+
+func (f *fakeUDPTracker) serve() {
+	const connectionID = 0x1122334455667788
+	buf := make([]byte, 2048)
+	for {
+		n, addr, err := f.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 16 {
+			continue
+		}
+		action := binary.BigEndian.Uint32(buf[8:])
+		txn := binary.BigEndian.Uint32(buf[12:])
+		response := binary.BigEndian.AppendUint32(nil, action)
+		response = binary.BigEndian.AppendUint32(response, txn)
+
+		switch {
+		case action == udpActionConnect && binary.BigEndian.Uint64(buf) == udpProtocolID:
+			response = binary.BigEndian.AppendUint64(response, connectionID)
+		case action == udpActionAnnounce && binary.BigEndian.Uint64(buf) == connectionID && n >= 98:
+			swarm := f.swarms[[20]byte(buf[16:36])]
+			response = binary.BigEndian.AppendUint32(response, 1800)
+			response = binary.BigEndian.AppendUint32(response, uint32(swarm.Leechers))
+			response = binary.BigEndian.AppendUint32(response, uint32(swarm.Seeders))
+			response = append(response, announcePeers...)
+		case action == udpActionScrape && binary.BigEndian.Uint64(buf) == connectionID && f.failWith != "":
+			binary.BigEndian.PutUint32(response, udpActionError)
+			response = append(response, f.failWith...)
+		case action == udpActionScrape && binary.BigEndian.Uint64(buf) == connectionID:
+			f.scrapes.Add(1)
+			for offset := 16; offset+20 <= n; offset += 20 {
+				swarm := f.swarms[[20]byte(buf[offset:offset+20])]
+				response = binary.BigEndian.AppendUint32(response, uint32(swarm.Seeders))
+				response = binary.BigEndian.AppendUint32(response, uint32(swarm.Completed))
+				response = binary.BigEndian.AppendUint32(response, uint32(swarm.Leechers))
+			}
+		default:
+			continue
+		}
+		f.conn.WriteTo(response, addr)
+	}
+}
+
+// newFakeHTTPTracker 返回 bencode 编码的 scrape 响应，只认识 swarms 中的 info hash
+func newFakeHTTPTracker(t *testing.T, swarms map[string]Swarm) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scrape" || r.URL.Query().Get("passkey") != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+		var body strings.Builder
+		body.WriteString("d5:filesd")
+		for _, raw := range r.URL.Query()["info_hash"] {
+			for infoHash, swarm := range swarms {
+				if hash, _ := decodeInfoHash(infoHash); string(hash[:]) == raw {
+					fmt.Fprintf(&body, "20:%sd8:completei%de10:downloadedi%de10:incompletei%dee", raw, swarm.Seeders, swarm.Completed, swarm.Leechers)
+				}
+			}
+		}
+		body.WriteString("ee")
+		w.Write([]byte(body.String()))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScrapeMergesTrackers(t *testing.T) {
+	udpTracker := newFakeUDPTracker(t, map[string]Swarm{hashA: {Seeders: 10, Leechers: 2, Completed: 50}}, "")
+	httpTracker := newFakeHTTPTracker(t, map[string]Swarm{
+		hashA: {Seeders: 7, Leechers: 4, Completed: 30},
+		hashB: {Seeders: 1, Leechers: 0, Completed: 3},
+	})
+	scraper := New([]string{httpTracker.URL + "/announce?passkey=secret"}, 5*time.Second)
+
+	target, err := ParseMagnet("magnet:?xt=urn:btih:" + strings.ToLower(hashA) + "&tr=" + url.QueryEscape(udpTracker.url()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := scraper.Scrape(context.Background(), []Target{target, {InfoHash: hashB}})
+	if err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if len(report.Errors) != 0 {
+		t.Errorf("unexpected errors: %v", report.Errors)
+	}
+
+	a, _ := report.Lookup(hashA)
+	if a.Seeders != 10 || a.Leechers != 4 || a.Completed != 50 || a.Trackers != 2 {
+		t.Errorf("hash A = %+v, want maxima from both trackers", a)
+	}
+	b, _ := report.Lookup(hashB)
+	if b.Seeders != 1 || b.Completed != 3 || b.Trackers != 1 {
+		t.Errorf("hash B = %+v, want HTTP tracker stats", b)
+	}
+}
+
+func TestScrapeUDPBatches(t *testing.T) {
+	tracker := newFakeUDPTracker(t, map[string]Swarm{hashA: {Seeders: 3}}, "")
+	targets := []Target{{InfoHash: hashA, Trackers: []string{tracker.url()}}}
+	for i := range 99 {
+		targets = append(targets, Target{InfoHash: fmt.Sprintf("%040X", i+1), Trackers: []string{tracker.url()}})
+	}
+
+	report, err := New(nil, 5*time.Second).Scrape(context.Background(), targets)
+	if err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if got := tracker.scrapes.Load(); got != 2 {
+		t.Errorf("tracker received %d scrape packets, want 2", got)
+	}
+	if len(report.Results) != 100 || report.Results[0].Seeders != 3 || report.Results[99].Trackers != 1 {
+		t.Errorf("unexpected results: %d, first %+v", len(report.Results), report.Results[0])
+	}
+}
+
+func TestScrapeTrackerFailures(t *testing.T) {
+	failing := newFakeUDPTracker(t, nil, "torrent not registered")
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("d14:failure reason6:bannede"))
+	}))
+	defer rejecting.Close()
+
+	trackers := []string{
+		failing.url(),
+		"udp://" + silent.LocalAddr().String(),
+		rejecting.URL + "/announce",
+		rejecting.URL + "/tracker.php",
+		"wss://tracker.example/announce",
+	}
+	started := time.Now()
+	report, err := New(trackers, 300*time.Millisecond).Scrape(context.Background(), []Target{{InfoHash: hashA}})
+	if err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 3*time.Second {
+		t.Errorf("scrape took %s, want per-tracker timeout", elapsed)
+	}
+	if len(report.Errors) != len(trackers) {
+		t.Errorf("got %d tracker errors, want %d: %v", len(report.Errors), len(trackers), report.Errors)
+	}
+	if !strings.Contains(report.Errors[failing.url()], "torrent not registered") || !strings.Contains(report.Errors[rejecting.URL+"/announce"], "banned") {
+		t.Errorf("tracker error messages not reported: %v", report.Errors)
+	}
+	if stats := report.Results[0]; stats.Trackers != 0 {
+		t.Errorf("stats = %+v, want no responding trackers", stats)
+	}
+
+	if _, err := New(nil, time.Second).Scrape(context.Background(), []Target{{InfoHash: "not-a-hash"}}); err == nil {
+		t.Error("invalid info hash accepted")
+	}
+}
+
+func TestScrapeDeadlineReturnsPartialResults(t *testing.T) {
+	responding := newFakeUDPTracker(t, map[string]Swarm{hashA: {Seeders: 4}}, "")
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	scraper := New([]string{responding.url(), "udp://" + silent.LocalAddr().String()}, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	report, err := scraper.Scrape(ctx, []Target{{InfoHash: hashA}})
+	if err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if stats := report.Results[0]; stats.Trackers != 1 || stats.Seeders != 4 {
+		t.Errorf("stats = %+v, want the responding tracker only", stats)
+	}
+	if _, ok := report.Errors["udp://"+silent.LocalAddr().String()]; !ok || len(report.Errors) != 1 {
+		t.Errorf("errors = %v, want the silent tracker", report.Errors)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := scraper.Scrape(canceled, []Target{{InfoHash: hashA}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled scrape err = %v", err)
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	udpTracker := newFakeUDPTracker(t, map[string]Swarm{hashA: {Seeders: 2}}, "")
+	httpTracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("passkey") != "secret" || query.Get("compact") != "1" || len(query.Get("peer_id")) != 20 {
+			w.Write([]byte("d14:failure reason11:bad requeste"))
+			return
+		}
+		// 非紧凑格式的 peers 加上 IPv6 紧凑格式的 peers6
+		peers6 := string(append(net.ParseIP("2001:db8::1"), 0x1a, 0xe1))
+		fmt.Fprintf(w, "d5:peersld2:ip8:10.0.0.74:porti51413eed2:ip7:invalid4:porti1eee6:peers618:%se", peers6)
+	}))
+	t.Cleanup(httpTracker.Close)
+	scraper := New(nil, 5*time.Second)
+
+	peers, err := scraper.Announce(context.Background(), udpTracker.url(), strings.ToLower(hashA))
+	if err != nil {
+		t.Fatalf("UDP announce: %v", err)
+	}
+	if fmt.Sprint(peers) != "[10.0.0.1:6881 192.168.1.9:51413]" {
+		t.Errorf("UDP peers = %v", peers)
+	}
+
+	peers, err = scraper.Announce(context.Background(), httpTracker.URL+"/announce?passkey=secret", hashA)
+	if err != nil {
+		t.Fatalf("HTTP announce: %v", err)
+	}
+	if fmt.Sprint(peers) != "[10.0.0.7:51413 [2001:db8::1]:6881]" {
+		t.Errorf("HTTP peers = %v", peers)
+	}
+
+	if _, err := scraper.Announce(context.Background(), httpTracker.URL+"/announce", hashA); err == nil || !strings.Contains(err.Error(), "bad request") {
+		t.Errorf("tracker failure: err = %v", err)
+	}
+	if _, err := scraper.Announce(context.Background(), "wss://tracker.example/announce", hashA); !errors.Is(err, ErrUnsupportedTracker) {
+		t.Errorf("wss tracker: err = %v, want ErrUnsupportedTracker", err)
+	}
+}
+
+func TestScrapeURL(t *testing.T) {
+	tests := []struct {
+		announce string
+		want     string
+	}{
+		{"http://tracker.example/announce", "http://tracker.example/scrape"},
+		{"http://tracker.example/x/announce.php?passkey=1", "http://tracker.example/x/scrape.php?passkey=1"},
+		{"https://tracker.example/announce/", ""},
+		{"http://tracker.example/a/announce/b", ""},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.announce)
+		got, err := scrapeURL(u)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("scrapeURL(%q) = %s, want unsupported", tt.announce, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("scrapeURL(%q) = %v, %v; want %s", tt.announce, got, err, tt.want)
+		}
+	}
+}
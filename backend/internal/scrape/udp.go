@@ -0,0 +1,174 @@
+package scrape
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// UDP tracker 协议常量（BEP 15）
+const (
+	udpProtocolID     = 0x41727101980
+	udpActionConnect  = 0
+	udpActionAnnounce = 1
+	udpActionScrape   = 2
+	udpActionError    = 3
+	// udpMaxHashes 是单个 scrape 数据包能携带的 info hash 数
+	udpMaxHashes = 74
+	// udpBufferSize 是接收缓冲区大小，足以容纳 numWant 个 IPv6 peer 的 announce 响应
+	udpBufferSize = 4096
+	// udpRetransmit 是未收到响应时重发请求的间隔，UDP 数据包可能丢失
+	udpRetransmit = 2 * time.Second
+)
+
+// udpConnect 连接 UDP tracker 并取得连接 ID
+func udpConnect(ctx context.Context, host string) (net.Conn, uint64, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", host)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response, err := udpRoundTrip(ctx, conn, udpActionConnect, func(txn uint32) []byte {
+		packet := make([]byte, 16)
+		binary.BigEndian.PutUint64(packet[0:], udpProtocolID)
+		binary.BigEndian.PutUint32(packet[8:], udpActionConnect)
+		binary.BigEndian.PutUint32(packet[12:], txn)
+		return packet
+	})
+	if err == nil && len(response) < 8 {
+		err = errors.New("short response")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, 0, fmt.Errorf("connect: %w", err)
+	}
+	return conn, binary.BigEndian.Uint64(response), nil
+}
+
+// scrapeUDP 先取得连接 ID，再分批发送 scrape 请求
+func scrapeUDP(ctx context.Context, host string, hashes [][20]byte) (map[[20]byte]Swarm, error) {
+	conn, connectionID, err := udpConnect(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	swarms := make(map[[20]byte]Swarm, len(hashes))
+	for start := 0; start < len(hashes); start += udpMaxHashes {
+		batch := hashes[start:min(start+udpMaxHashes, len(hashes))]
+		response, err := udpRoundTrip(ctx, conn, udpActionScrape, func(txn uint32) []byte {
+			packet := make([]byte, 16, 16+20*len(batch))
+			binary.BigEndian.PutUint64(packet[0:], connectionID)
+			binary.BigEndian.PutUint32(packet[8:], udpActionScrape)
+			binary.BigEndian.PutUint32(packet[12:], txn)
+			for _, hash := range batch {
+				packet = append(packet, hash[:]...)
+			}
+			return packet
+		})
+		if err != nil {
+			return nil, fmt.Errorf("scrape: %w", err)
+		}
+		if len(response) < 12*len(batch) {
+			return nil, errors.New("scrape: short response")
+		}
+		// 每个种子依次为做种数、完成数、下载数
+		for i, hash := range batch {
+			entry := response[12*i:]
+			swarms[hash] = Swarm{
+				Seeders:   int(binary.BigEndian.Uint32(entry[0:])),
+				Completed: int(binary.BigEndian.Uint32(entry[4:])),
+				Leechers:  int(binary.BigEndian.Uint32(entry[8:])),
+			}
+		}
+	}
+	return swarms, nil
+}
+
+// announceUDP 发送 announce 请求并返回 tracker 给出的 peer
+func announceUDP(ctx context.Context, host string, infoHash, peerID [20]byte) ([]netip.AddrPort, error) {
+	conn, connectionID, err := udpConnect(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	response, err := udpRoundTrip(ctx, conn, udpActionAnnounce, func(txn uint32) []byte {
+		packet := make([]byte, 98)
+		binary.BigEndian.PutUint64(packet[0:], connectionID)
+		binary.BigEndian.PutUint32(packet[8:], udpActionAnnounce)
+		binary.BigEndian.PutUint32(packet[12:], txn)
+		copy(packet[16:], infoHash[:])
+		copy(packet[36:], peerID[:])
+		// downloaded、uploaded 为 0，left 非 0 使 tracker 优先返回做种者；event 为 0（none）
+		binary.BigEndian.PutUint64(packet[64:], announceLeft)
+		binary.BigEndian.PutUint32(packet[88:], rand.Uint32())
+		binary.BigEndian.PutUint32(packet[92:], announceNumWant)
+		binary.BigEndian.PutUint16(packet[96:], announcePort)
+		return packet
+	})
+	if err != nil {
+		return nil, fmt.Errorf("announce: %w", err)
+	}
+	if len(response) < 12 {
+		return nil, errors.New("announce: short response")
+	}
+	// 响应中 peer 地址的长度取决于与 tracker 通信使用的地址族
+	peerSize := 6
+	if addr, ok := conn.RemoteAddr().(*net.UDPAddr); ok && addr.IP.To4() == nil {
+		peerSize = 18
+	}
+	return parseCompactPeers(response[12:], peerSize), nil
+}
+
+// udpRoundTrip 发送请求并等待事务 ID 匹配的响应，超过重发间隔未收到时重发，直到 ctx 结束
+// 返回响应中动作和事务 ID 之后的部分
+func udpRoundTrip(ctx context.Context, conn net.Conn, action uint32, build func(txn uint32) []byte) ([]byte, error) {
+	txn := rand.Uint32()
+	packet := build(txn)
+	buf := make([]byte, udpBufferSize)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := conn.Write(packet); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(udpRetransmit)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if n < 8 || binary.BigEndian.Uint32(buf[4:]) != txn {
+				continue
+			}
+			switch got := binary.BigEndian.Uint32(buf); got {
+			case action:
+				return append([]byte(nil), buf[8:n]...), nil
+			case udpActionError:
+				return nil, fmt.Errorf("tracker error: %s", buf[8:n])
+			default:
+				return nil, fmt.Errorf("unexpected action %d", got)
+			}
+		}
+	}
+}
@@ -0,0 +1,607 @@
+// Package selector 实现一个精简的 CSS 选择器引擎，用于在 golang.org/x/net/html 解析树上查找节点
+//
+// 支持的语法：
+//   - 标签、通配符 *、#id、.class
+//   - 属性 [attr]、[attr=v]、[attr^=v]、[attr$=v]、[attr*=v]、[attr~=v]
+//   - 伪类 :first-child、:last-child、:nth-child(an+b)、:nth-of-type(an+b)、:not(...)、:contains("text")
+//   - 组合器：后代（空格）、子代 >、相邻兄弟 +、通用兄弟 ~，以及逗号分组
+package selector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector 是编译后的 CSS 选择器
+type Selector struct {
+	source string
+	groups []complexSelector
+}
+
+type complexSelector struct {
+	parts       []compound
+	combinators []byte
+}
+
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrMatcher
+	pseudos []pseudo
+}
+
+type attrMatcher struct {
+	key string
+	op  string
+	val string
+}
+
+type pseudo struct {
+	kind     string
+	a, b     int
+	text     string
+	negation *compound
+}
+
+// Compile 编译 CSS 选择器
+func Compile(source string) (*Selector, error) {
+	p := &parser{src: strings.TrimSpace(source)}
+	if p.src == "" {
+		return nil, fmt.Errorf("selector: empty selector")
+	}
+
+	sel := &Selector{source: source}
+	for {
+		group, err := p.parseComplex()
+		if err != nil {
+			return nil, fmt.Errorf("selector: %q: %w", source, err)
+		}
+		sel.groups = append(sel.groups, group)
+
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+		if p.peek() != ',' {
+			return nil, fmt.Errorf("selector: %q: unexpected %q at %d", source, p.peek(), p.pos)
+		}
+		p.pos++
+		p.skipSpace()
+	}
+	return sel, nil
+}
+
+// MustCompile 编译选择器，失败时 panic
+func MustCompile(source string) *Selector {
+	sel, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// String 返回原始选择器文本
+func (s *Selector) String() string { return s.source }
+
+// Match 判断节点是否匹配选择器
+func (s *Selector) Match(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+	for _, group := range s.groups {
+		if group.matchAt(n, len(group.parts)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAll 按文档顺序返回 root 的所有匹配后代节点
+func (s *Selector) MatchAll(root *html.Node) []*html.Node {
+	var matches []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if s.Match(n) {
+			matches = append(matches, n)
+		}
+		return true
+	})
+	return matches
+}
+
+// MatchFirst 返回 root 的第一个匹配后代节点
+func (s *Selector) MatchFirst(root *html.Node) *html.Node {
+	var found *html.Node
+	walk(root, func(n *html.Node) bool {
+		if s.Match(n) {
+			found = n
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// Attr 返回节点的属性值
+func Attr(n *html.Node, key string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// Text 返回节点内所有文本，空白折叠为单个空格
+func Text(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// walk 按文档顺序遍历 root 的后代元素，visit 返回 false 时停止
+func walk(root *html.Node, visit func(*html.Node) bool) bool {
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && !visit(c) {
+			return false
+		}
+		if !walk(c, visit) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c complexSelector) matchAt(n *html.Node, i int) bool {
+	if !c.parts[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	switch c.combinators[i-1] {
+	case '>':
+		p := n.Parent
+		return p != nil && p.Type == html.ElementNode && c.matchAt(p, i-1)
+	case '+':
+		prev := prevElement(n)
+		return prev != nil && c.matchAt(prev, i-1)
+	case '~':
+		for prev := prevElement(n); prev != nil; prev = prevElement(prev) {
+			if c.matchAt(prev, i-1) {
+				return true
+			}
+		}
+		return false
+	default:
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.Type == html.ElementNode && c.matchAt(p, i-1) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c *compound) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && c.tag != "*" && !strings.EqualFold(n.Data, c.tag) {
+		return false
+	}
+	if c.id != "" {
+		if id, _ := Attr(n, "id"); id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := Attr(n, "class")
+		fields := strings.Fields(class)
+		for _, want := range c.classes {
+			if !containsField(fields, want) {
+				return false
+			}
+		}
+	}
+	for _, attr := range c.attrs {
+		if !attr.match(n) {
+			return false
+		}
+	}
+	for _, p := range c.pseudos {
+		if !p.match(n) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m attrMatcher) match(n *html.Node) bool {
+	val, ok := Attr(n, m.key)
+	if !ok {
+		return false
+	}
+	switch m.op {
+	case "":
+		return true
+	case "=":
+		return val == m.val
+	case "^=":
+		return m.val != "" && strings.HasPrefix(val, m.val)
+	case "$=":
+		return m.val != "" && strings.HasSuffix(val, m.val)
+	case "*=":
+		return m.val != "" && strings.Contains(val, m.val)
+	case "~=":
+		return containsField(strings.Fields(val), m.val)
+	case "|=":
+		return val == m.val || strings.HasPrefix(val, m.val+"-")
+	default:
+		return false
+	}
+}
+
+func (p pseudo) match(n *html.Node) bool {
+	switch p.kind {
+	case "first-child":
+		return prevElement(n) == nil
+	case "last-child":
+		return nextElement(n) == nil
+	case "nth-child":
+		return nthMatch(p.a, p.b, elementIndex(n, false))
+	case "nth-of-type":
+		return nthMatch(p.a, p.b, elementIndex(n, true))
+	case "not":
+		return !p.negation.match(n)
+	case "contains":
+		return strings.Contains(Text(n), p.text)
+	default:
+		return false
+	}
+}
+
+func nthMatch(a, b, index int) bool {
+	if a == 0 {
+		return index == b
+	}
+	diff := index - b
+	return diff/a >= 0 && diff%a == 0
+}
+
+func elementIndex(n *html.Node, sameType bool) int {
+	index := 1
+	for prev := prevElement(n); prev != nil; prev = prevElement(prev) {
+		if !sameType || prev.Data == n.Data {
+			index++
+		}
+	}
+	return index
+}
+
+func prevElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func nextElement(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func containsField(fields []string, want string) bool {
+	for _, f := range fields {
+		if f == want {
+			return true
+		}
+	}
+	return false
+}
+
+// parser 是一个简单的递归下降选择器解析器
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) eof() bool  { return p.pos >= len(p.src) }
+func (p *parser) peek() byte { return p.src[p.pos] }
+
+func (p *parser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && isSpace(p.peek()) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *parser) parseComplex() (complexSelector, error) {
+	var sel complexSelector
+
+	first, err := p.parseCompound()
+	if err != nil {
+		return sel, err
+	}
+	sel.parts = append(sel.parts, first)
+
+	for {
+		spaced := p.skipSpace()
+		if p.eof() || p.peek() == ',' || p.peek() == ')' {
+			return sel, nil
+		}
+
+		combinator := byte(' ')
+		switch p.peek() {
+		case '>', '+', '~':
+			combinator = p.peek()
+			p.pos++
+			p.skipSpace()
+		default:
+			if !spaced {
+				return sel, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+			}
+		}
+
+		next, err := p.parseCompound()
+		if err != nil {
+			return sel, err
+		}
+		sel.combinators = append(sel.combinators, combinator)
+		sel.parts = append(sel.parts, next)
+	}
+}
+
+func (p *parser) parseCompound() (compound, error) {
+	var c compound
+	start := p.pos
+
+	if !p.eof() && p.peek() == '*' {
+		c.tag = "*"
+		p.pos++
+	} else if ident := p.parseIdent(); ident != "" {
+		c.tag = strings.ToLower(ident)
+	}
+
+	for !p.eof() {
+		switch p.peek() {
+		case '#':
+			p.pos++
+			if c.id = p.parseIdent(); c.id == "" {
+				return c, fmt.Errorf("expected id at %d", p.pos)
+			}
+		case '.':
+			p.pos++
+			class := p.parseIdent()
+			if class == "" {
+				return c, fmt.Errorf("expected class at %d", p.pos)
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			attr, err := p.parseAttr()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, attr)
+		case ':':
+			ps, err := p.parsePseudo()
+			if err != nil {
+				return c, err
+			}
+			c.pseudos = append(c.pseudos, ps)
+		default:
+			if p.pos == start {
+				return c, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+			}
+			return c, nil
+		}
+	}
+
+	if p.pos == start {
+		return c, fmt.Errorf("unexpected end of selector")
+	}
+	return c, nil
+}
+
+func (p *parser) parseAttr() (attrMatcher, error) {
+	var m attrMatcher
+	p.pos++ // [
+	p.skipSpace()
+	if m.key = p.parseIdent(); m.key == "" {
+		return m, fmt.Errorf("expected attribute name at %d", p.pos)
+	}
+	p.skipSpace()
+	if p.eof() {
+		return m, fmt.Errorf("unterminated attribute selector")
+	}
+
+	if p.peek() != ']' {
+		for _, op := range []string{"=", "^=", "$=", "*=", "~=", "|="} {
+			if strings.HasPrefix(p.src[p.pos:], op) {
+				m.op = op
+				p.pos += len(op)
+				break
+			}
+		}
+		if m.op == "" {
+			return m, fmt.Errorf("unknown attribute operator at %d", p.pos)
+		}
+		p.skipSpace()
+		val, err := p.parseValue()
+		if err != nil {
+			return m, err
+		}
+		m.val = val
+		p.skipSpace()
+	}
+
+	if p.eof() || p.peek() != ']' {
+		return m, fmt.Errorf("expected ] at %d", p.pos)
+	}
+	p.pos++
+	return m, nil
+}
+
+func (p *parser) parsePseudo() (pseudo, error) {
+	var ps pseudo
+	p.pos++ // :
+	ps.kind = strings.ToLower(p.parseIdent())
+
+	switch ps.kind {
+	case "first-child", "last-child":
+		return ps, nil
+	case "nth-child", "nth-of-type":
+		arg, err := p.parseParenArg()
+		if err != nil {
+			return ps, err
+		}
+		ps.a, ps.b, err = parseNth(arg)
+		return ps, err
+	case "contains":
+		arg, err := p.parseParenArg()
+		if err != nil {
+			return ps, err
+		}
+		ps.text = strings.Trim(strings.TrimSpace(arg), `"'`)
+		return ps, nil
+	case "not":
+		if p.eof() || p.peek() != '(' {
+			return ps, fmt.Errorf("expected ( at %d", p.pos)
+		}
+		p.pos++
+		p.skipSpace()
+		inner, err := p.parseCompound()
+		if err != nil {
+			return ps, err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != ')' {
+			return ps, fmt.Errorf("expected ) at %d", p.pos)
+		}
+		p.pos++
+		ps.negation = &inner
+		return ps, nil
+	default:
+		return ps, fmt.Errorf("unsupported pseudo-class :%s", ps.kind)
+	}
+}
+
+func (p *parser) parseParenArg() (string, error) {
+	if p.eof() || p.peek() != '(' {
+		return "", fmt.Errorf("expected ( at %d", p.pos)
+	}
+	end := strings.IndexByte(p.src[p.pos:], ')')
+	if end < 0 {
+		return "", fmt.Errorf("unterminated ( at %d", p.pos)
+	}
+	arg := p.src[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+	return arg, nil
+}
+
+func (p *parser) parseValue() (string, error) {
+	if p.eof() {
+		return "", fmt.Errorf("expected value")
+	}
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		if val := p.parseIdent(); val != "" {
+			return val, nil
+		}
+		return "", fmt.Errorf("expected value at %d", p.pos)
+	}
+	end := strings.IndexByte(p.src[p.pos+1:], quote)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at %d", p.pos)
+	}
+	val := p.src[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+	return val, nil
+}
+
+func (p *parser) parseIdent() string {
+	start := p.pos
+	for !p.eof() {
+		ch := p.peek()
+		if ch == '-' || ch == '_' || ch >= 0x80 ||
+			(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// parseNth 解析 an+b 形式的参数，也支持 odd、even
+func parseNth(arg string) (int, int, error) {
+	arg = strings.ToLower(strings.ReplaceAll(arg, " ", ""))
+	switch arg {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+
+	idx := strings.IndexByte(arg, 'n')
+	if idx < 0 {
+		b, err := strconv.Atoi(arg)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid nth expression %q", arg)
+		}
+		return 0, b, nil
+	}
+
+	var a, b int
+	switch coef := arg[:idx]; coef {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		v, err := strconv.Atoi(coef)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid nth expression %q", arg)
+		}
+		a = v
+	}
+	if rest := arg[idx+1:]; rest != "" {
+		v, err := strconv.Atoi(rest)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid nth expression %q", arg)
+		}
+		b = v
+	}
+	return a, b, nil
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f'
+}
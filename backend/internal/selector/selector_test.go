@@ -0,0 +1,91 @@
+package selector
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const testDoc = `<html><body>
+<div id="main" class="wrap">
+  <table class="torrent-list data">
+    <tr class="default"><td>1</td><td><a class="comments" title="3 comments">3</a><a href="/view/1" title="First">First</a></td><td><a href="magnet:?xt=urn:btih:AAA">m</a></td></tr>
+    <tr class="success"><td>2</td><td><a href="/view/2" title="Second">Second</a></td><td><a href="/download/2.torrent">t</a></td></tr>
+    <tr class="default"><td>3</td><td><a href="/view/3" title="Third">Third  title</a></td><td></td></tr>
+  </table>
+  <ul class="pagination"><li>1</li><li>2</li><li class="next">&raquo;</li></ul>
+</div>
+</body></html>`
+
+func parse(t *testing.T) *html.Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestMatchAll(t *testing.T) {
+	doc := parse(t)
+
+	tests := []struct {
+		selector string
+		want     int
+	}{
+		{"tr", 3},
+		{"table.torrent-list tr.default", 2},
+		{"#main > table > tbody > tr", 3},
+		{"div > tr", 0},
+		{"a[href^='magnet:']", 1},
+		{"a[href$=\".torrent\"]", 1},
+		{"a[title]", 4},
+		{"td:nth-child(2) a:not(.comments)", 3},
+		{"tr:nth-child(odd)", 2},
+		{"tr:nth-child(-n+2)", 2},
+		{"tr:first-child td:last-child a", 1},
+		{"ul.pagination li:not(.next)", 2},
+		{"td + td + td", 3},
+		{"tr.default ~ tr", 2},
+		{"a:contains(\"Third\")", 1},
+		{"li.next, tr.success", 2},
+		{"*[class~=data]", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := Compile(tt.selector)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			if got := len(sel.MatchAll(doc)); got != tt.want {
+				t.Errorf("MatchAll(%q) = %d nodes, want %d", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchFirstAndText(t *testing.T) {
+	doc := parse(t)
+
+	row := MustCompile("tr:nth-child(3)").MatchFirst(doc)
+	if row == nil {
+		t.Fatal("row not found")
+	}
+	link := MustCompile("td:nth-child(2) a").MatchFirst(row)
+	if got := Text(link); got != "Third title" {
+		t.Errorf("Text = %q, want %q", got, "Third title")
+	}
+	if got, _ := Attr(link, "href"); got != "/view/3" {
+		t.Errorf("href = %q", got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, bad := range []string{"", "a[href", "a:hover", "td:nth-child(x)", "a >", ".", "a,"} {
+		if _, err := Compile(bad); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+)
+
+// adminPayload 返回适配器管理接口的当前状态
+func (s *APIService) adminPayload() map[string]any {
+    return map[string]any{
+        "adapters":         s.registry.List(),
+        "defaultAdapter":   s.registry.DefaultID(),
+        "fallbackAdapters": s.registry.FallbackIDs(),
+    }
+}
+
+// handleAdminAdapters 查看适配器配置，或通过 PATCH 切换默认适配器和备用适配器链
+// PATCH 请求体：{"defaultAdapter": "nyaa", "fallbackAdapters": ["sukebei", "sample"]}，省略的字段保持不变
+func (s *APIService) handleAdminAdapters(w http.ResponseWriter, r *http.Request) error {
+    switch r.Method {
+    case http.MethodGet:
+        return s.writeJSON(w, s.adminPayload(), http.StatusOK)
+
+    case http.MethodPatch:
+        var body struct {
+            DefaultAdapter   *string   `json:"defaultAdapter"`
+            FallbackAdapters *[]string `json:"fallbackAdapters"`
+        }
+        if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+            return ClientError{Message: "请提供有效的JSON数据。"}
+        }
+
+        defaultID := s.registry.DefaultID()
+        if body.DefaultAdapter != nil {
+            defaultID = strings.TrimSpace(*body.DefaultAdapter)
+            if !s.registry.Enabled(defaultID) {
+                return ClientError{Message: fmt.Sprintf("默认适配器不存在或已禁用: %s", defaultID)}
+            }
+        }
+        fallbackIDs := s.registry.FallbackIDs()
+        if body.FallbackAdapters != nil {
+            fallbackIDs = *body.FallbackAdapters
+            for _, id := range fallbackIDs {
+                if _, ok := s.registry.Get(id); !ok {
+                    return ClientError{Message: fmt.Sprintf("未知的适配器: %s", id)}
+                }
+            }
+        }
+
+        if err := s.registry.Configure(defaultID, fallbackIDs...); err != nil {
+            return fmt.Errorf("保存适配器配置失败: %w", err)
+        }
+        return s.writeJSON(w, s.adminPayload(), http.StatusOK)
+
+    default:
+        return NewMethodNotAllowedError(r.Method)
+    }
+}
+
+// handleAdminAdapter 通过 PATCH /api/admin/adapters/{id} 启用、禁用适配器或修改其上游地址
+// 请求体：{"enabled": false} 或 {"endpoint": "https://nyaa.example/"}
+func (s *APIService) handleAdminAdapter(w http.ResponseWriter, r *http.Request) error {
+    if r.Method != http.MethodPatch {
+        return NewMethodNotAllowedError(r.Method)
+    }
+
+    id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/adapters/"), "/")
+    if _, ok := s.registry.Get(id); !ok {
+        return ClientError{Message: fmt.Sprintf("未知的适配器: %s", id)}
+    }
+
+    var body struct {
+        Enabled  *bool   `json:"enabled"`
+        Endpoint *string `json:"endpoint"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+        return ClientError{Message: "请提供有效的JSON数据。"}
+    }
+
+    // 先校验整个请求再修改，避免部分修改已经生效（并持久化、清除缓存）后才返回错误
+    // 地址的校验和修改在 SetEndpoint 中一起完成，校验失败时不会改变任何状态
+    if body.Enabled != nil && !*body.Enabled && id == s.registry.DefaultID() {
+        return ClientError{Message: "不能禁用默认适配器，请先切换默认适配器。"}
+    }
+
+    if body.Endpoint != nil {
+        if err := s.registry.SetEndpoint(id, *body.Endpoint); err != nil {
+            return ClientError{Message: fmt.Sprintf("无法修改适配器 %s 的地址: %v", id, err)}
+        }
+    }
+    if body.Enabled != nil {
+        if err := s.registry.SetEnabled(id, *body.Enabled); err != nil {
+            return fmt.Errorf("保存适配器配置失败: %w", err)
+        }
+    }
+
+    for _, info := range s.registry.List() {
+        if info.ID == id {
+            return s.writeJSON(w, map[string]any{"adapter": info}, http.StatusOK)
+        }
+    }
+    return s.writeJSON(w, map[string]any{}, http.StatusOK)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/models"
+)
+
+func adminRequest(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminDisableAdapter(t *testing.T) {
+	svc := newTestService(t,
+		&stubAdapter{id: "a", results: []models.SearchResult{{Title: "one", InfoHash: "AAA"}}},
+		&stubAdapter{id: "b", results: []models.SearchResult{{Title: "two", InfoHash: "BBB"}}},
+	)
+	routes := svc.Routes()
+
+	if rec := adminRequest(t, routes, http.MethodPatch, "/api/admin/adapters/a", `{"enabled":false}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("disabling default adapter: status = %d, want 400", rec.Code)
+	}
+	rec := adminRequest(t, routes, http.MethodPatch, "/api/admin/adapters/b", `{"enabled":false}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var payload struct {
+		Adapter models.AdapterInfo `json:"adapter"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if payload.Adapter.ID != "b" || payload.Adapter.Enabled {
+		t.Errorf("adapter = %+v, want b disabled", payload.Adapter)
+	}
+
+	// 聚合搜索跳过禁用的适配器，显式指定则返回错误
+	_, meta, err := svc.search(context.Background(), "all", models.SearchOptions{Query: "x", Page: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(meta.Adapters) != 1 || meta.Adapters[0].Adapter != "a" {
+		t.Errorf("aggregate adapters = %+v, want only a", meta.Adapters)
+	}
+	if _, _, err := svc.search(context.Background(), "b", models.SearchOptions{Query: "x", Page: 1}); err == nil {
+		t.Error("search on disabled adapter succeeded")
+	}
+}
+
+func TestAdminSwitchDefaultAdapter(t *testing.T) {
+	svc := newTestService(t, &stubAdapter{id: "a"}, &stubAdapter{id: "b"})
+	routes := svc.Routes()
+
+	rec := adminRequest(t, routes, http.MethodPatch, "/api/admin/adapters", `{"defaultAdapter":"b","fallbackAdapters":["a"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if got := svc.registry.DefaultID(); got != "b" {
+		t.Errorf("default = %q, want b", got)
+	}
+	if got := svc.registry.FallbackIDs(); len(got) != 1 || got[0] != "a" {
+		t.Errorf("fallbacks = %v, want [a]", got)
+	}
+
+	if rec := adminRequest(t, routes, http.MethodPatch, "/api/admin/adapters", `{"defaultAdapter":"missing"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown default: status = %d, want 400", rec.Code)
+	}
+	if rec := adminRequest(t, routes, http.MethodPatch, "/api/admin/adapters/a", `{"endpoint":"https://a.example"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("endpoint on non-editable adapter: status = %d, want 400", rec.Code)
+	}
+}
+
+// endpointStub 是可修改上游地址的测试适配器
+type endpointStub struct {
+	stubAdapter
+	endpoint string
+}
+
+func (a *endpointStub) Endpoint() string  { return a.endpoint }
+func (a *endpointStub) Mirrors() []string { return []string{a.endpoint} }
+
+func (a *endpointStub) SetEndpoint(endpoint string) error {
+	a.endpoint = endpoint
+	return nil
+}
+
+func TestAdminAdapterValidatesBeforeApplying(t *testing.T) {
+	adapter := &endpointStub{stubAdapter: stubAdapter{id: "a"}, endpoint: "https://a.example"}
+	svc := newTestService(t, adapter, &stubAdapter{id: "b"})
+	routes := svc.Routes()
+
+	// 禁用默认适配器无效，同一请求中的地址修改也不能生效
+	rec := adminRequest(t, routes, http.MethodPatch, "/api/admin/adapters/a", `{"endpoint":"https://new.example","enabled":false}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	if adapter.endpoint != "https://a.example" {
+		t.Errorf("endpoint = %q, changed by a rejected request", adapter.endpoint)
+	}
+
+	rec = adminRequest(t, routes, http.MethodPatch, "/api/admin/adapters/a", `{"endpoint":"https://new.example","enabled":true}`)
+	if rec.Code != http.StatusOK || adapter.endpoint != "https://new.example" {
+		t.Errorf("status = %d, endpoint = %q", rec.Code, adapter.endpoint)
+	}
+}
@@ -23,3 +23,10 @@ func NewMethodNotAllowedError(method string) error {
 	return MethodNotAllowed{Method: method}
 }
 
+
+// NotFound 表示请求的资源不存在
+type NotFound struct {
+	Message string
+}
+
+func (e NotFound) Error() string { return e.Message }
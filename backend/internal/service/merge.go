@@ -0,0 +1,130 @@
+package service
+
+import (
+    "slices"
+
+    "github.com/seedmanage/backend/internal/magnet"
+    "github.com/seedmanage/backend/internal/models"
+    "github.com/seedmanage/backend/internal/utils"
+)
+
+// mergeResults 按规范化的 info hash 合并重复结果
+// 合并后的结果保留首次出现的位置，合并 tracker 列表，取最大的做种/下载人数，并记录所有来源适配器
+func mergeResults(results []models.SearchResult) []models.SearchResult {
+    merged := make([]models.SearchResult, 0, len(results))
+    index := make(map[string]int, len(results))
+
+    for _, result := range results {
+        key := resultKey(result)
+        if key == "" {
+            merged = append(merged, withSources(result))
+            continue
+        }
+
+        i, ok := index[key]
+        if !ok {
+            result = withSources(result)
+            result.InfoHash = key
+            index[key] = len(merged)
+            merged = append(merged, result)
+            continue
+        }
+
+        mergeInto(&merged[i], result)
+    }
+
+    return merged
+}
+
+// resultKey 返回用于去重的规范化 info hash，必要时从磁力链接的 xt 参数中提取
+func resultKey(result models.SearchResult) string {
+    if result.InfoHash != "" {
+        return utils.NormalizeInfoHash(result.InfoHash)
+    }
+    return magnet.InfoHash(result.Magnet)
+}
+
+// withSources 复制结果并初始化来源列表
+func withSources(result models.SearchResult) models.SearchResult {
+    sources := append([]string(nil), result.Sources...)
+    if result.Source != "" && !slices.Contains(sources, result.Source) {
+        sources = append(sources, result.Source)
+    }
+    result.Sources = sources
+    result.Trackers = append([]string(nil), result.Trackers...)
+    return result
+}
+
+// mergeInto 将重复结果合并到目标结果中
+func mergeInto(dst *models.SearchResult, src models.SearchResult) {
+    for _, source := range withSources(src).Sources {
+        if !slices.Contains(dst.Sources, source) {
+            dst.Sources = append(dst.Sources, source)
+        }
+    }
+
+    for _, tracker := range src.Trackers {
+        if !slices.Contains(dst.Trackers, tracker) {
+            dst.Trackers = append(dst.Trackers, tracker)
+        }
+    }
+    dst.Magnet = mergeMagnet(*dst, src)
+
+    dst.Seeders = maxInt(dst.Seeders, src.Seeders)
+    dst.Leechers = maxInt(dst.Leechers, src.Leechers)
+
+    if dst.Size == nil && src.Size != nil {
+        dst.Size = src.Size
+        dst.SizeLabel = src.SizeLabel
+    }
+    if dst.Uploaded == nil && src.Uploaded != nil {
+        dst.Uploaded = src.Uploaded
+    }
+    if (dst.Category == "" || dst.Category == "未知") && src.Category != "" {
+        dst.Category = src.Category
+    }
+}
+
+// mergeMagnet 返回带有合并后 tracker 集合的磁力链接
+// 解析目标的磁力链接（没有时使用来源的链接，都没有时用 info hash 构建），加入两个结果的 tracker 和来源链接中的 tracker，
+// tracker 按解码后的值去重；没有新增 tracker 时保留原链接，无法解析的链接原样返回
+func mergeMagnet(dst, src models.SearchResult) string {
+    raw := utils.Coalesce(dst.Magnet, src.Magnet)
+    if raw == "" && dst.InfoHash == "" {
+        return ""
+    }
+    m := magnet.Magnet{InfoHash: dst.InfoHash, Name: dst.Title}
+    if raw != "" {
+        parsed, err := magnet.ParseLenient(raw)
+        if err != nil {
+            return raw
+        }
+        m = parsed
+    }
+
+    trackers := slices.Concat(dst.Trackers, src.Trackers)
+    if parsed, err := magnet.ParseLenient(src.Magnet); err == nil {
+        trackers = append(trackers, parsed.Trackers...)
+    }
+    added := false
+    for _, tracker := range trackers {
+        if tracker != "" && !slices.Contains(m.Trackers, tracker) {
+            m.Trackers = append(m.Trackers, tracker)
+            added = true
+        }
+    }
+    if raw != "" && !added {
+        return raw
+    }
+    return utils.BuildMagnetLink(m)
+}
+
+func maxInt(a, b *int) *int {
+    if a == nil {
+        return b
+    }
+    if b == nil || *a >= *b {
+        return a
+    }
+    return b
+}
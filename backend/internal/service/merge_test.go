@@ -0,0 +1,116 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+func TestMergeResults(t *testing.T) {
+	results := []models.SearchResult{
+		{
+			Title:    "Show S01",
+			Magnet:   "magnet:?xt=urn:btih:F257AF31A6204CD734D2BAECB8331637850B7B44",
+			InfoHash: "F257AF31A6204CD734D2BAECB8331637850B7B44",
+			Trackers: []string{"udp://a"},
+			Seeders:  utils.PtrInt(5),
+			Leechers: utils.PtrInt(9),
+			Category: "未知",
+			Source:   "apibay",
+		},
+		{
+			Title:    "Other",
+			InfoHash: "0000000000000000000000000000000000000000",
+			Source:   "apibay",
+		},
+		{
+			Title:    "Show S01 (nyaa)",
+			Magnet:   "magnet:?xt=urn:btih:f257af31a6204cd734d2baecb8331637850b7b44",
+			Trackers: []string{"udp://a", "udp://b"},
+			Seeders:  utils.PtrInt(12),
+			Leechers: utils.PtrInt(3),
+			Size:     utils.PtrInt64(1024),
+			Category: "Anime",
+			Source:   "nyaa",
+		},
+		{
+			Title:    "Show S01 (base32)",
+			InfoHash: "6JL26MNGEBGNONGSXLWLQMYWG6CQW62E",
+			Source:   "htmlsukebei",
+		},
+	}
+
+	merged := mergeResults(results)
+	if len(merged) != 2 {
+		t.Fatalf("got %d results, want 2", len(merged))
+	}
+
+	got := merged[0]
+	if got.Title != "Show S01" {
+		t.Errorf("title = %q, want first occurrence", got.Title)
+	}
+	if want := []string{"apibay", "nyaa", "htmlsukebei"}; !reflect.DeepEqual(got.Sources, want) {
+		t.Errorf("sources = %v, want %v", got.Sources, want)
+	}
+	if want := []string{"udp://a", "udp://b"}; !reflect.DeepEqual(got.Trackers, want) {
+		t.Errorf("trackers = %v, want %v", got.Trackers, want)
+	}
+	if *got.Seeders != 12 || *got.Leechers != 9 {
+		t.Errorf("seeders/leechers = %d/%d, want 12/9", *got.Seeders, *got.Leechers)
+	}
+	if got.Size == nil || *got.Size != 1024 || got.Category != "Anime" {
+		t.Errorf("missing fields were not filled: size=%v category=%q", got.Size, got.Category)
+	}
+	if merged[1].Sources[0] != "apibay" {
+		t.Errorf("single-source result sources = %v", merged[1].Sources)
+	}
+}
+
+func TestMergeResultsMagnetTrackers(t *testing.T) {
+	const hash = "F257AF31A6204CD734D2BAECB8331637850B7B44"
+	tests := []struct {
+		name   string
+		first  models.SearchResult
+		second models.SearchResult
+		want   string
+	}{
+		{
+			// 已有的 tracker 按解码后的值比较，不同的编码不会重复添加
+			name:   "existing tracker in other encoding",
+			first:  models.SearchResult{Magnet: "magnet:?xt=urn:btih:" + hash + "&dn=Show&tr=udp://a.example:80&xl=10", Trackers: []string{"udp://a.example:80"}},
+			second: models.SearchResult{InfoHash: hash, Trackers: []string{"udp://a.example:80", "udp://b.example:80"}},
+			want:   "magnet:?xt=urn:btih:" + hash + "&dn=Show&xl=10&tr=udp%3A%2F%2Fa.example%3A80&tr=udp%3A%2F%2Fb.example%3A80",
+		},
+		{
+			name:   "target without magnet",
+			first:  models.SearchResult{Title: "Show", InfoHash: hash},
+			second: models.SearchResult{InfoHash: hash, Trackers: []string{"udp://b.example:80"}},
+			want:   "magnet:?xt=urn:btih:" + hash + "&dn=Show&tr=udp%3A%2F%2Fb.example%3A80",
+		},
+		{
+			name:   "trackers from the source magnet",
+			first:  models.SearchResult{InfoHash: hash},
+			second: models.SearchResult{Magnet: "magnet:?xt=urn:btih:" + hash + "&tr=udp%3A%2F%2Fc.example%3A80"},
+			want:   "magnet:?xt=urn:btih:" + hash + "&tr=udp%3A%2F%2Fc.example%3A80",
+		},
+		{
+			name:   "no new trackers",
+			first:  models.SearchResult{Magnet: "magnet:?xt=urn:btih:" + hash + "&tr=udp%3A%2F%2Fa.example%3A80", Trackers: []string{"udp://a.example:80"}},
+			second: models.SearchResult{InfoHash: hash, Trackers: []string{"udp://a.example:80"}},
+			want:   "magnet:?xt=urn:btih:" + hash + "&tr=udp%3A%2F%2Fa.example%3A80",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeResults([]models.SearchResult{tt.first, tt.second})
+			if len(merged) != 1 {
+				t.Fatalf("got %d results, want 1", len(merged))
+			}
+			if merged[0].Magnet != tt.want {
+				t.Errorf("magnet = %s\nwant %s", merged[0].Magnet, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+    "context"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/seedmanage/backend/internal/metadata"
+    "github.com/seedmanage/backend/internal/models"
+    "github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+    // metadataConcurrency 是 metadata=true 时同时获取元数据的种子数
+    metadataConcurrency = 4
+    // metadataAttachTimeout 是 metadata=true 时为一次请求获取元数据的总时长，超时的条目不附带元数据
+    metadataAttachTimeout = 15 * time.Second
+    // metadataWriteMargin 是获取元数据之后写出响应预留的时间
+    metadataWriteMargin = 5 * time.Second
+)
+
+// UseMetadataCache 把种子元数据缓存保存到目录中，默认只缓存在内存
+func (s *APIService) UseMetadataCache(dir string) error {
+    store, err := metadata.NewStore(dir)
+    if err != nil {
+        return err
+    }
+    s.metadata = metadata.NewFetcher(store, s.scraper, metadata.DefaultTimeout)
+    return nil
+}
+
+// handleMetadata 获取种子的文件列表、总大小和分块信息
+// 参数：magnet 为磁力链接；或 hash 为 info hash，tr 为可重复的 tracker 地址
+func (s *APIService) handleMetadata(w http.ResponseWriter, r *http.Request) error {
+    if r.Method != http.MethodGet {
+        return NewMethodNotAllowedError(r.Method)
+    }
+
+    var req metadata.Request
+    if magnet := strings.TrimSpace(r.URL.Query().Get("magnet")); magnet != "" {
+        parsed, err := metadata.ParseMagnet(magnet)
+        if err != nil {
+            return ClientError{Message: err.Error()}
+        }
+        req = parsed
+    } else {
+        req.InfoHash = strings.TrimSpace(r.URL.Query().Get("hash"))
+        req.Trackers = r.URL.Query()["tr"]
+    }
+    if req.InfoHash == "" {
+        return ClientError{Message: "请提供磁力链接或 info hash。"}
+    }
+
+    // 获取元数据可能超过服务器的 WriteTimeout
+    extendWriteDeadline(w, metadata.DefaultTimeout+metadataWriteMargin)
+    info, err := s.metadata.Fetch(r.Context(), req)
+    if err != nil {
+        if r.Context().Err() != nil {
+            return err
+        }
+        return ClientError{Message: err.Error()}
+    }
+    return s.writeJSON(w, info, http.StatusOK)
+}
+
+// extendWriteDeadline 延长当前响应的写超时，ResponseWriter 不支持时忽略
+func extendWriteDeadline(w http.ResponseWriter, d time.Duration) {
+    _ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d))
+}
+
+// lookupMetadata 返回各 info hash 对应的元数据，已缓存的直接使用，其余并发获取
+// 整个过程不超过 metadataAttachTimeout，获取失败的种子不出现在结果中
+func (s *APIService) lookupMetadata(ctx context.Context, requests []metadata.Request) map[string]*models.TorrentInfo {
+    found := make(map[string]*models.TorrentInfo)
+    var pending []metadata.Request
+    for _, req := range requests {
+        req.InfoHash = utils.NormalizeInfoHash(req.InfoHash)
+        if len(req.InfoHash) != 40 {
+            continue
+        }
+        if _, ok := found[req.InfoHash]; ok {
+            continue
+        }
+        if info, ok := s.metadata.Cached(req.InfoHash); ok {
+            found[req.InfoHash] = &info
+            continue
+        }
+        found[req.InfoHash] = nil
+        pending = append(pending, req)
+    }
+
+    ctx, cancel := context.WithTimeout(ctx, metadataAttachTimeout)
+    defer cancel()
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    semaphore := make(chan struct{}, metadataConcurrency)
+    for _, req := range pending {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            select {
+            case semaphore <- struct{}{}:
+                defer func() { <-semaphore }()
+            case <-ctx.Done():
+                return
+            }
+            info, err := s.metadata.Fetch(ctx, req)
+            if err != nil {
+                return
+            }
+            mu.Lock()
+            found[req.InfoHash] = &info
+            mu.Unlock()
+        }()
+    }
+    wg.Wait()
+    return found
+}
+
+// attachMetadata 为搜索结果附带元数据，结果缺少大小时使用元数据中的总大小
+func (s *APIService) attachMetadata(ctx context.Context, results []models.SearchResult) {
+    requests := make([]metadata.Request, len(results))
+    for i, result := range results {
+        requests[i] = metadata.Request{InfoHash: result.InfoHash, Trackers: result.Trackers}
+    }
+    found := s.lookupMetadata(ctx, requests)
+    for i := range results {
+        info := found[utils.NormalizeInfoHash(results[i].InfoHash)]
+        if info == nil {
+            continue
+        }
+        results[i].Metadata = info
+        if results[i].Size == nil {
+            results[i].Size = utils.PtrInt64(info.Size)
+            results[i].SizeLabel = info.SizeLabel
+        }
+    }
+}
+
+// attachItemMetadata 为集合条目附带元数据，不修改保存的集合
+func (s *APIService) attachItemMetadata(ctx context.Context, items []models.CollectionItem) {
+    requests := make([]metadata.Request, 0, len(items))
+    hashes := make([]string, len(items))
+    for i, item := range items {
+        if req, err := metadata.ParseMagnet(item.Magnet); err == nil {
+            requests = append(requests, req)
+            hashes[i] = req.InfoHash
+        }
+    }
+    found := s.lookupMetadata(ctx, requests)
+    for i := range items {
+        if hashes[i] != "" {
+            items[i].Metadata = found[hashes[i]]
+        }
+    }
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/bencode"
+	"github.com/seedmanage/backend/internal/metadata"
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/scrape"
+)
+
+// newMetadataService 返回一个不访问公共 tracker 的服务，其元数据缓存中已有一个种子
+func newMetadataService(t *testing.T, adapters ...models.Adapter) (*APIService, string) {
+	t.Helper()
+	raw, err := bencode.Encode(map[string]any{
+		"name":         "ubuntu.iso",
+		"piece length": 1 << 20,
+		"pieces":       strings.Repeat("x", 60),
+		"length":       3 << 20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infoHash := metadata.InfoHash(raw)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, infoHash+".info"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := newTestService(t, adapters...)
+	svc.scraper = scrape.New(nil, time.Second)
+	if err := svc.UseMetadataCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	return svc, infoHash
+}
+
+func TestMetadataEndpoint(t *testing.T) {
+	svc, infoHash := newMetadataService(t, &stubAdapter{id: "a"})
+
+	rec := adminRequest(t, svc.Routes(), http.MethodGet, "/api/metadata?hash="+strings.ToLower(infoHash), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var info models.TorrentInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.InfoHash != infoHash || info.Name != "ubuntu.iso" || info.Pieces != 3 || len(info.Files) != 1 {
+		t.Errorf("metadata = %+v", info)
+	}
+
+	for _, path := range []string{
+		"/api/metadata",
+		"/api/metadata?hash=xyz",
+		"/api/metadata?magnet=http://example.com",
+		"/api/metadata?hash=" + strings.Repeat("AB", 20),
+	} {
+		if rec := adminRequest(t, svc.Routes(), http.MethodGet, path, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status = %d, want 400", path, rec.Code)
+		}
+	}
+}
+
+func TestSearchAttachesMetadata(t *testing.T) {
+	adapter := &stubAdapter{id: "a"}
+	svc, infoHash := newMetadataService(t, adapter)
+	adapter.results = []models.SearchResult{
+		{Title: "cached", InfoHash: infoHash, Magnet: "magnet:?xt=urn:btih:" + infoHash},
+		{Title: "unknown", InfoHash: strings.Repeat("CD", 20), Magnet: "magnet:?xt=urn:btih:" + strings.Repeat("CD", 20)},
+	}
+
+	rec := adminRequest(t, svc.Routes(), http.MethodGet, "/api/search?q=ubuntu&metadata=true&norecord=true", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var response models.SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(response.Results))
+	}
+	for _, result := range response.Results {
+		switch result.Title {
+		case "cached":
+			if result.Metadata == nil || result.Size == nil || *result.Size != 3<<20 {
+				t.Errorf("cached result = %+v", result)
+			}
+		case "unknown":
+			if result.Metadata != nil {
+				t.Errorf("unknown result has metadata %+v", result.Metadata)
+			}
+		}
+	}
+
+	rec = adminRequest(t, svc.Routes(), http.MethodGet, "/api/search?q=ubuntu&norecord=true", "")
+	if strings.Contains(rec.Body.String(), `"metadata"`) {
+		t.Errorf("metadata attached without metadata=true: %s", rec.Body)
+	}
+}
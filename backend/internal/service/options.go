@@ -0,0 +1,114 @@
+package service
+
+import (
+    "fmt"
+    "net/url"
+    "slices"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/seedmanage/backend/internal/models"
+    "github.com/seedmanage/backend/internal/registry"
+)
+
+// parseSearchOptions 从查询参数中解析搜索选项：q、page、category、sort、order、filter
+// query 由调用方单独校验，这里只负责分页、分类、排序和过滤参数
+func parseSearchOptions(params url.Values, query string) (models.SearchOptions, error) {
+    options := models.SearchOptions{
+        Query:    query,
+        Page:     1,
+        Category: strings.TrimSpace(params.Get("category")),
+        Sort:     strings.ToLower(strings.TrimSpace(params.Get("sort"))),
+        Order:    strings.ToLower(strings.TrimSpace(params.Get("order"))),
+        Filter:   strings.ToLower(strings.TrimSpace(params.Get("filter"))),
+    }
+
+    if pageStr := params.Get("page"); pageStr != "" {
+        if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+            options.Page = p
+        }
+    }
+
+    switch options.Sort {
+    case "", models.SortSeeders, models.SortLeechers, models.SortSize, models.SortDate, models.SortDownloads:
+    default:
+        return options, ClientError{Message: fmt.Sprintf("不支持的排序字段: %s", options.Sort)}
+    }
+    switch options.Order {
+    case "", models.OrderAsc, models.OrderDesc:
+    default:
+        return options, ClientError{Message: fmt.Sprintf("不支持的排序方向: %s", options.Order)}
+    }
+    switch options.Filter {
+    case models.FilterNone, models.FilterNoRemakes, models.FilterTrusted:
+    default:
+        return options, ClientError{Message: fmt.Sprintf("不支持的过滤器: %s", options.Filter)}
+    }
+
+    return options, nil
+}
+
+// categoryIgnored 报告适配器是否无法按请求的分类过滤
+// 实现了 models.CategoryAdapter 的适配器自行判断（可以支持上游原生的分类代码），其他适配器以声明的通用分类为准
+func categoryIgnored(adapter models.Adapter, category string) bool {
+    category = strings.TrimSpace(category)
+    if category == "" {
+        return false
+    }
+    if filter, ok := registry.As[models.CategoryAdapter](adapter); ok {
+        return !filter.SupportsCategory(category)
+    }
+    return !slices.Contains(registry.CapabilitiesOf(adapter).Categories, strings.ToLower(category))
+}
+
+// sortResults 按请求的字段对合并后的结果排序
+// 上游不支持排序的适配器以及聚合搜索依赖这里保证顺序，缺失该字段的结果排在最后
+func sortResults(results []models.SearchResult, options models.SearchOptions) {
+    var value func(models.SearchResult) (int64, bool)
+    switch options.Sort {
+    case models.SortSeeders:
+        value = func(r models.SearchResult) (int64, bool) {
+            if r.Seeders == nil {
+                return 0, false
+            }
+            return int64(*r.Seeders), true
+        }
+    case models.SortLeechers:
+        value = func(r models.SearchResult) (int64, bool) {
+            if r.Leechers == nil {
+                return 0, false
+            }
+            return int64(*r.Leechers), true
+        }
+    case models.SortSize:
+        value = func(r models.SearchResult) (int64, bool) {
+            if r.Size == nil {
+                return 0, false
+            }
+            return *r.Size, true
+        }
+    case models.SortDate:
+        value = func(r models.SearchResult) (int64, bool) {
+            if r.Uploaded == nil {
+                return 0, false
+            }
+            return r.Uploaded.Unix(), true
+        }
+    default:
+        return
+    }
+
+    asc := options.Order == models.OrderAsc
+    sort.SliceStable(results, func(i, j int) bool {
+        a, okA := value(results[i])
+        b, okB := value(results[j])
+        if okA != okB {
+            return okA
+        }
+        if asc {
+            return a < b
+        }
+        return a > b
+    })
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+func TestParseSearchOptions(t *testing.T) {
+	params := url.Values{
+		"page":     {"3"},
+		"category": {"anime"},
+		"sort":     {"Seeders"},
+		"order":    {"asc"},
+		"filter":   {"trusted"},
+	}
+	options, err := parseSearchOptions(params, "frieren")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := models.SearchOptions{
+		Query:    "frieren",
+		Page:     3,
+		Category: "anime",
+		Sort:     models.SortSeeders,
+		Order:    models.OrderAsc,
+		Filter:   models.FilterTrusted,
+	}
+	if options != want {
+		t.Errorf("options = %+v, want %+v", options, want)
+	}
+
+	for _, bad := range []url.Values{
+		{"sort": {"name"}},
+		{"order": {"up"}},
+		{"filter": {"remakes-only"}},
+	} {
+		if _, err := parseSearchOptions(bad, "x"); err == nil {
+			t.Errorf("parseSearchOptions(%v) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestSortResults(t *testing.T) {
+	results := []models.SearchResult{
+		{Title: "unknown"},
+		{Title: "low", Seeders: utils.PtrInt(1)},
+		{Title: "high", Seeders: utils.PtrInt(50)},
+	}
+
+	sortResults(results, models.SearchOptions{Sort: models.SortSeeders})
+	if results[0].Title != "high" || results[1].Title != "low" || results[2].Title != "unknown" {
+		t.Errorf("desc order = %s, %s, %s", results[0].Title, results[1].Title, results[2].Title)
+	}
+
+	sortResults(results, models.SearchOptions{Sort: models.SortSeeders, Order: models.OrderAsc})
+	if results[0].Title != "low" || results[1].Title != "high" || results[2].Title != "unknown" {
+		t.Errorf("asc order = %s, %s, %s", results[0].Title, results[1].Title, results[2].Title)
+	}
+}
+
+func TestSearchMarksIgnoredCategory(t *testing.T) {
+	results := []models.SearchResult{{Title: "one", InfoHash: "AAA"}}
+	filtering := &capableStub{stubAdapter{id: "filtering", results: results}, models.Capabilities{Categories: []string{models.CategoryAnime}}}
+	plain := &stubAdapter{id: "plain", results: results}
+	svc := newTestService(t, filtering, plain)
+
+	tests := []struct {
+		adapter  string
+		category string
+		want     bool
+	}{
+		{"filtering", "anime", false},
+		{"filtering", "Movies", true},
+		{"plain", "anime", true},
+		{"plain", "", false},
+		{"all", "anime", true},
+	}
+	for _, tt := range tests {
+		_, meta, err := svc.search(context.Background(), tt.adapter, models.SearchOptions{Query: "x", Page: 1, Category: tt.category})
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		if meta.CategoryIgnored != tt.want {
+			t.Errorf("%s/%q: categoryIgnored = %v, want %v", tt.adapter, tt.category, meta.CategoryIgnored, tt.want)
+		}
+	}
+}
+
+func TestMagnetSearchIgnoresOptions(t *testing.T) {
+	svc := newTestService(t, &stubAdapter{id: "a"})
+	magnet := url.QueryEscape("magnet:?xt=urn:btih:0123456789ABCDEF0123456789ABCDEF01234567")
+	rec := httptest.NewRecorder()
+	svc.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?norecord=true&sort=name&q="+magnet, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, body %s", rec.Code, rec.Body)
+	}
+}
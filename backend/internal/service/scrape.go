@@ -0,0 +1,161 @@
+package service
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/seedmanage/backend/internal/models"
+    "github.com/seedmanage/backend/internal/scrape"
+    "github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+    // maxScrapeTargets 是单次 scrape 请求允许的种子数
+    maxScrapeTargets = 500
+    // scrapeRequestTimeout 是一次请求中 tracker scrape 的总时长，超时未响应的 tracker 记为失败
+    // 响应的写超时相应延长 scrapeRequestTimeout+metadataWriteMargin，慢 tracker 不会导致连接被重置
+    scrapeRequestTimeout = scrape.DefaultTimeout + 5*time.Second
+)
+
+// handleScrape 查询磁力链接或 info hash 的实时做种数和下载数
+// GET 使用可重复的 magnet 和 hash 参数；POST 请求体：{"magnets": [...], "infoHashes": [...]}
+func (s *APIService) handleScrape(w http.ResponseWriter, r *http.Request) error {
+    var magnets, infoHashes []string
+    switch r.Method {
+    case http.MethodGet:
+        magnets = r.URL.Query()["magnet"]
+        infoHashes = r.URL.Query()["hash"]
+
+    case http.MethodPost:
+        var body struct {
+            Magnets    []string `json:"magnets"`
+            InfoHashes []string `json:"infoHashes"`
+        }
+        if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+            return ClientError{Message: "请提供有效的JSON数据。"}
+        }
+        magnets = body.Magnets
+        infoHashes = body.InfoHashes
+
+    default:
+        return NewMethodNotAllowedError(r.Method)
+    }
+
+    targets := make([]scrape.Target, 0, len(magnets)+len(infoHashes))
+    for _, magnet := range magnets {
+        target, err := scrape.ParseMagnet(strings.TrimSpace(magnet))
+        if err != nil {
+            return ClientError{Message: err.Error()}
+        }
+        targets = append(targets, target)
+    }
+    for _, infoHash := range infoHashes {
+        targets = append(targets, scrape.Target{InfoHash: strings.TrimSpace(infoHash)})
+    }
+    if len(targets) == 0 {
+        return ClientError{Message: "请提供磁力链接或 info hash。"}
+    }
+    if len(targets) > maxScrapeTargets {
+        return ClientError{Message: fmt.Sprintf("单次最多查询 %d 个种子。", maxScrapeTargets)}
+    }
+
+    report, err := s.scrapeWithin(r.Context(), w, targets)
+    if err != nil {
+        if r.Context().Err() != nil {
+            return err
+        }
+        return ClientError{Message: err.Error()}
+    }
+    return s.writeJSON(w, report, http.StatusOK)
+}
+
+// handleCollectionScrape 刷新集合中所有条目的做种数和下载数并保存
+// 没有 tracker 返回统计的条目保留原有数据
+func (s *APIService) handleCollectionScrape(w http.ResponseWriter, r *http.Request, collectionID string) error {
+    if r.Method != http.MethodPost {
+        return NewMethodNotAllowedError(r.Method)
+    }
+
+    cf, err := s.collections.Get(collectionID)
+    if err != nil {
+        return ClientError{Message: err.Error()}
+    }
+    targets := make([]scrape.Target, 0, len(cf.Items))
+    for _, item := range cf.Items {
+        if target, err := scrape.ParseMagnet(item.Magnet); err == nil && len(target.InfoHash) == 40 {
+            targets = append(targets, target)
+        }
+    }
+
+    report := scrape.Report{Results: []scrape.Stats{}}
+    if len(targets) > 0 {
+        report, err = s.scrapeWithin(r.Context(), w, targets)
+        if err != nil {
+            return err
+        }
+    }
+
+    updated := 0
+    cf, err = s.collections.UpdateItems(collectionID, func(item *models.CollectionItem) {
+        target, err := scrape.ParseMagnet(item.Magnet)
+        if err != nil {
+            return
+        }
+        stats, ok := report.Lookup(target.InfoHash)
+        if !ok || stats.Trackers == 0 {
+            return
+        }
+        item.Seeders = utils.PtrInt(stats.Seeders)
+        item.Leechers = utils.PtrInt(stats.Leechers)
+        scrapedAt := stats.ScrapedAt
+        item.ScrapedAt = &scrapedAt
+        updated++
+    })
+    if err != nil {
+        return ClientError{Message: err.Error()}
+    }
+
+    payload := map[string]any{
+        "message":    fmt.Sprintf("已刷新 %d 个条目", updated),
+        "updated":    updated,
+        "collection": cf,
+        "errors":     report.Errors,
+    }
+    return s.writeJSON(w, payload, http.StatusOK)
+}
+
+// scrapeWithin 在 scrapeRequestTimeout 内完成 scrape，并延长响应的写超时
+func (s *APIService) scrapeWithin(ctx context.Context, w http.ResponseWriter, targets []scrape.Target) (scrape.Report, error) {
+    extendWriteDeadline(w, scrapeRequestTimeout+metadataWriteMargin)
+    ctx, cancel := context.WithTimeout(ctx, scrapeRequestTimeout)
+    defer cancel()
+    return s.scraper.Scrape(ctx, targets)
+}
+
+// refreshSwarms 用 tracker scrape 的结果更新搜索结果的做种数和下载数
+func (s *APIService) refreshSwarms(ctx context.Context, w http.ResponseWriter, results []models.SearchResult) {
+    targets := make([]scrape.Target, 0, len(results))
+    for _, result := range results {
+        if hash := utils.NormalizeInfoHash(result.InfoHash); len(hash) == 40 {
+            targets = append(targets, scrape.Target{InfoHash: hash, Trackers: result.Trackers})
+        }
+    }
+    if len(targets) == 0 || len(targets) > maxScrapeTargets {
+        return
+    }
+
+    report, err := s.scrapeWithin(ctx, w, targets)
+    if err != nil {
+        return
+    }
+    for i := range results {
+        if stats, ok := report.Lookup(results[i].InfoHash); ok && stats.Trackers > 0 {
+            results[i].Seeders = utils.PtrInt(stats.Seeders)
+            results[i].Leechers = utils.PtrInt(stats.Leechers)
+        }
+    }
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/collections"
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/scrape"
+)
+
+func TestCollectionScrapeRefreshesItems(t *testing.T) {
+	const known = "0123456789ABCDEF0123456789ABCDEF01234567"
+	raw, _ := hex.DecodeString(known)
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := "d5:filesd"
+		for _, hash := range r.URL.Query()["info_hash"] {
+			if hash == string(raw) {
+				body += fmt.Sprintf("20:%sd8:completei12e10:downloadedi90e10:incompletei5ee", raw)
+			}
+		}
+		fmt.Fprint(w, body+"ee")
+	}))
+	defer tracker.Close()
+
+	collStore, err := collections.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := collStore.Create("linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	magnet := "magnet:?xt=urn:btih:" + known + "&tr=" + url.QueryEscape(tracker.URL+"/announce")
+	if _, err := collStore.AddItems(meta.ID, []models.CollectionItem{
+		{Magnet: magnet, Title: "known"},
+		{Magnet: "magnet:?xt=urn:btih:89ABCDEF0123456789ABCDEF0123456789ABCDEF", Title: "unknown"},
+		{Magnet: "not a magnet", Title: "broken"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := newTestService(t, &stubAdapter{id: "a"})
+	svc.collections = collStore
+	svc.scraper = scrape.New([]string{tracker.URL + "/announce"}, 2*time.Second)
+
+	rec := adminRequest(t, svc.Routes(), http.MethodPost, "/api/collections/"+meta.ID+"/scrape", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var payload struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if payload.Updated != 1 {
+		t.Errorf("updated = %d, want 1", payload.Updated)
+	}
+
+	cf, err := collStore.Get(meta.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := cf.Items[0]; item.Seeders == nil || *item.Seeders != 12 || *item.Leechers != 5 || item.ScrapedAt == nil {
+		t.Errorf("known item not refreshed: %+v", item)
+	}
+	for _, item := range cf.Items[1:] {
+		if item.ScrapedAt != nil {
+			t.Errorf("item without tracker stats refreshed: %+v", item)
+		}
+	}
+}
+
+func TestScrapeEndpointValidation(t *testing.T) {
+	svc := newTestService(t, &stubAdapter{id: "a"})
+	routes := svc.Routes()
+
+	if rec := adminRequest(t, routes, http.MethodGet, "/api/scrape", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty request: status = %d, want 400", rec.Code)
+	}
+	if rec := adminRequest(t, routes, http.MethodPost, "/api/scrape", `{"infoHashes":["xyz"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid hash: status = %d, want 400", rec.Code)
+	}
+}
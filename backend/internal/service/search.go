@@ -0,0 +1,291 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/seedmanage/backend/internal/httpclient"
+    "github.com/seedmanage/backend/internal/models"
+    "github.com/seedmanage/backend/internal/registry"
+    "github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+    // aggregateAll 表示在所有已注册的适配器上执行聚合搜索
+    aggregateAll = "all"
+    // aggregateTimeout 是聚合搜索中所有适配器共享的截止时间
+    aggregateTimeout = 12 * time.Second
+)
+
+// search 根据 adapter 参数执行单适配器搜索或多适配器聚合搜索
+func (s *APIService) search(ctx context.Context, adapterParam string, options models.SearchOptions) ([]models.SearchResult, models.SearchMeta, error) {
+    ids, aggregate, err := s.resolveAdapters(adapterParam)
+    if err != nil {
+        return nil, models.SearchMeta{}, err
+    }
+    if aggregate {
+        results, meta := s.searchAggregate(ctx, ids, options)
+        return results, meta, nil
+    }
+    results, meta := s.searchSingle(ctx, ids[0], options)
+    return results, meta, nil
+}
+
+// resolveAdapters 解析 adapter 参数，支持单个 ID、"all" 以及逗号分隔的列表
+func (s *APIService) resolveAdapters(adapterParam string) ([]string, bool, error) {
+    adapterParam = strings.TrimSpace(adapterParam)
+    if adapterParam == "" {
+        adapterParam = s.registry.DefaultID()
+    }
+
+    if strings.EqualFold(adapterParam, aggregateAll) {
+        ids := s.registry.EnabledIDs()
+        if len(ids) == 0 {
+            return nil, false, ClientError{Message: "没有可用的适配器。"}
+        }
+        return ids, true, nil
+    }
+
+    aggregate := strings.Contains(adapterParam, ",")
+    seen := make(map[string]bool)
+    var ids []string
+    for _, id := range strings.Split(adapterParam, ",") {
+        id = strings.TrimSpace(id)
+        if id == "" || seen[id] {
+            continue
+        }
+        if _, ok := s.registry.Get(id); !ok {
+            return nil, false, ClientError{Message: fmt.Sprintf("未知的适配器: %s", id)}
+        }
+        if !s.registry.Enabled(id) {
+            return nil, false, ClientError{Message: fmt.Sprintf("适配器已禁用: %s", id)}
+        }
+        seen[id] = true
+        ids = append(ids, id)
+    }
+    if len(ids) == 0 {
+        return nil, false, ClientError{Message: "请提供有效的适配器。"}
+    }
+
+    return ids, aggregate, nil
+}
+
+// searchSingle 使用单个适配器搜索，失败、无结果或熔断时尝试备用适配器
+func (s *APIService) searchSingle(ctx context.Context, adapterID string, options models.SearchOptions) ([]models.SearchResult, models.SearchMeta) {
+    adapter, _ := s.registry.Get(adapterID)
+
+    page, status := s.runAdapter(ctx, adapter, options)
+    results := page.Results
+
+    meta := models.SearchMeta{
+        Mode:               "search",
+        Adapter:            adapter.ID(),
+        AdapterName:        adapter.Name(),
+        AdapterDescription: adapter.Description(),
+        AdapterEndpoint:    utils.Coalesce(page.Endpoint, adapter.Endpoint()),
+        AdapterError:       status.Error,
+        CurrentPage:        options.Page,
+        HasPrevPage:        options.Page > 1,
+    }
+
+    setPagination(&meta, adapter, page, options.Page)
+    setCached(&meta, page)
+    meta.CategoryIgnored = status.CategoryIgnored
+
+    // 如果主适配器失败、熔断或无结果，按顺序尝试备用适配器链，直到某个返回结果
+    meta.Attempts = []models.AdapterStatus{status}
+    if status.Status != models.AdapterStatusOK {
+        for _, fallback := range s.registry.Fallbacks(adapter.ID()) {
+            if ctx.Err() != nil {
+                break
+            }
+            fallbackPage, fallbackStatus := s.runAdapter(ctx, fallback, options)
+            meta.Attempts = append(meta.Attempts, fallbackStatus)
+            if fallbackStatus.Status == models.AdapterStatusOK {
+                results = fallbackPage.Results
+                meta.FallbackUsed = true
+                meta.FallbackAdapter = fallback.ID()
+                meta.FallbackAdapterName = fallback.Name()
+                // 分页信息以实际返回结果的适配器为准
+                setPagination(&meta, fallback, fallbackPage, options.Page)
+                setCached(&meta, fallbackPage)
+                meta.CategoryIgnored = fallbackStatus.CategoryIgnored
+                break
+            }
+        }
+    }
+
+    results = mergeResults(results)
+    sortResults(results, options)
+    meta.ResultCount = len(results)
+
+    return results, meta
+}
+
+// setPagination 根据适配器返回的结果页设置分页信息
+// 备用适配器会覆盖主适配器设置的值，因此每个字段都需要显式赋值
+func setPagination(meta *models.SearchMeta, adapter models.Adapter, page models.ResultPage, current int) {
+    meta.TotalPages, meta.HasNextPage = pagination(registry.CapabilitiesOf(adapter), page, current)
+    meta.TotalResults = page.Total
+}
+
+// setCached 根据实际返回结果的结果页设置缓存标记
+func setCached(meta *models.SearchMeta, page models.ResultPage) {
+    meta.Cached = !page.CachedAt.IsZero()
+    meta.CachedAt = nil
+    if meta.Cached {
+        cachedAt := page.CachedAt
+        meta.CachedAt = &cachedAt
+    }
+}
+
+// pagination 计算总页数和是否还有下一页，总页数为 0 表示未知
+// 优先使用上游报告的总页数，其次根据总结果数和页大小计算；都没有时：
+// 不支持分页的适配器一次返回全部结果，总页数为 1；支持分页的适配器在返回满一页
+// （页大小未知时为本页有结果）时认为还有下一页
+func pagination(caps models.Capabilities, page models.ResultPage, current int) (int, bool) {
+    if !caps.Pagination {
+        return 1, false
+    }
+
+    totalPages := page.TotalPages
+    if totalPages == 0 && page.Total > 0 && caps.PageSize > 0 {
+        totalPages = (page.Total + caps.PageSize - 1) / caps.PageSize
+    }
+    if totalPages > 0 {
+        return totalPages, current < totalPages
+    }
+
+    if caps.PageSize > 0 {
+        return 0, len(page.Results) >= caps.PageSize
+    }
+    return 0, len(page.Results) > 0
+}
+
+// searchAggregate 在多个适配器上并发搜索，共享同一截止时间并合并结果
+func (s *APIService) searchAggregate(ctx context.Context, ids []string, options models.SearchOptions) ([]models.SearchResult, models.SearchMeta) {
+    ctx, cancel := context.WithTimeout(ctx, aggregateTimeout)
+    defer cancel()
+
+    pages := make([]models.ResultPage, len(ids))
+    statuses := make([]models.AdapterStatus, len(ids))
+
+    var wg sync.WaitGroup
+    for i, id := range ids {
+        adapter, _ := s.registry.Get(id)
+        wg.Add(1)
+        go func(i int, adapter models.Adapter) {
+            defer wg.Done()
+            pages[i], statuses[i] = s.runAdapter(ctx, adapter, options)
+        }(i, adapter)
+    }
+    wg.Wait()
+
+    meta := models.SearchMeta{
+        Mode:        "aggregate",
+        Adapter:     strings.Join(ids, ","),
+        AdapterName: "聚合搜索",
+        CurrentPage: options.Page,
+        HasPrevPage: options.Page > 1,
+        Adapters:    statuses,
+    }
+
+    // 聚合结果的总页数取各适配器中最大的值，任一适配器还有下一页即可继续翻页
+    // 所有返回结果的适配器都命中缓存时，聚合结果才标记为来自缓存；任一返回结果的适配器忽略了分类时标记为忽略
+    var results []models.SearchResult
+    var failed []string
+    var cachedAt []time.Time
+    for i, page := range pages {
+        results = append(results, page.Results...)
+        if len(page.Results) > 0 {
+            cachedAt = append(cachedAt, page.CachedAt)
+            meta.CategoryIgnored = meta.CategoryIgnored || statuses[i].CategoryIgnored
+        }
+        adapter, _ := s.registry.Get(ids[i])
+        totalPages, hasNext := pagination(registry.CapabilitiesOf(adapter), page, options.Page)
+        meta.TotalPages = max(meta.TotalPages, totalPages)
+        meta.HasNextPage = meta.HasNextPage || hasNext
+        if statuses[i].Error != "" {
+            failed = append(failed, fmt.Sprintf("%s: %s", statuses[i].Adapter, statuses[i].Error))
+        }
+    }
+    if len(failed) == len(ids) {
+        meta.AdapterError = strings.Join(failed, "; ")
+    }
+    if len(cachedAt) > 0 && !slices.ContainsFunc(cachedAt, time.Time.IsZero) {
+        setCached(&meta, models.ResultPage{CachedAt: slices.MinFunc(cachedAt, time.Time.Compare)})
+    }
+    results = mergeResults(results)
+    sortResults(results, options)
+    meta.ResultCount = len(results)
+
+    return results, meta
+}
+
+// runAdapter 执行一次适配器搜索，记录耗时与状态并上报给注册器的熔断器
+// 熔断中的适配器会被直接跳过
+// 实现了 models.PagedAdapter 的适配器会同时返回总结果数和总页数
+// 适配器发出的 HTTP 请求数和重试次数通过 context 中的计数器记录到状态中
+func (s *APIService) runAdapter(ctx context.Context, adapter models.Adapter, options models.SearchOptions) (models.ResultPage, models.AdapterStatus) {
+    status := models.AdapterStatus{
+        Adapter:     adapter.ID(),
+        AdapterName: adapter.Name(),
+    }
+
+    if !s.registry.Allow(adapter.ID()) {
+        status.Status = models.AdapterStatusSkipped
+        status.Error = "适配器连续失败，熔断中已跳过"
+        return models.ResultPage{}, status
+    }
+
+    ctx, attempts := httpclient.WithAttempts(ctx)
+    started := time.Now()
+    var page models.ResultPage
+    var err error
+    if paged, ok := adapter.(models.PagedAdapter); ok {
+        page, err = paged.SearchPage(ctx, options)
+    } else {
+        page.Results, err = adapter.SearchWithOptions(ctx, options)
+    }
+    latency := time.Since(started)
+
+    status.LatencyMs = latency.Milliseconds()
+    status.ResultCount = len(page.Results)
+    status.Requests = attempts.Requests()
+    status.Retries = attempts.Retries()
+    status.Cached = !page.CachedAt.IsZero()
+    status.Shared = page.Shared
+    status.Endpoint = page.Endpoint
+    status.CategoryIgnored = categoryIgnored(adapter, options.Category)
+
+    switch {
+    case errors.Is(err, httpclient.ErrRateLimited):
+        status.Status = models.AdapterStatusRateLimited
+        status.Error = err.Error()
+    case err != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded)):
+        status.Status = models.AdapterStatusTimeout
+        status.Error = err.Error()
+    case err != nil:
+        status.Status = models.AdapterStatusError
+        status.Error = err.Error()
+    case len(page.Results) == 0:
+        status.Status = models.AdapterStatusEmpty
+    default:
+        status.Status = models.AdapterStatusOK
+    }
+
+    // 客户端主动取消、本地限速、命中缓存以及共享其他请求结果的调用不计入适配器健康统计，
+    // 共享的上游调用只由发起它的请求记录一次
+    if errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, httpclient.ErrRateLimited) || status.Cached || status.Shared {
+        s.registry.Abandon(adapter.ID())
+    } else {
+        s.registry.RecordResult(adapter.ID(), latency, err)
+    }
+
+    return page, status
+}
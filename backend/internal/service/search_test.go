@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/adapters"
+	"github.com/seedmanage/backend/internal/cache"
+	"github.com/seedmanage/backend/internal/httpclient"
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/registry"
+)
+
+type stubAdapter struct {
+	id      string
+	results []models.SearchResult
+	err     error
+	delay   time.Duration
+}
+
+func (a *stubAdapter) ID() string          { return a.id }
+func (a *stubAdapter) Name() string        { return a.id }
+func (a *stubAdapter) Description() string { return "stub" }
+func (a *stubAdapter) Endpoint() string    { return "stub://" + a.id }
+
+func (a *stubAdapter) Search(ctx context.Context, term string) ([]models.SearchResult, error) {
+	return a.SearchWithOptions(ctx, models.SearchOptions{Query: term, Page: 1})
+}
+
+func (a *stubAdapter) SearchWithOptions(ctx context.Context, options models.SearchOptions) ([]models.SearchResult, error) {
+	if a.delay > 0 {
+		select {
+		case <-time.After(a.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return a.results, a.err
+}
+
+func newTestService(t *testing.T, adapters ...models.Adapter) *APIService {
+	t.Helper()
+	reg := registry.New()
+	for _, a := range adapters {
+		reg.Register(a)
+	}
+	if err := reg.Configure(adapters[0].ID(), ""); err != nil {
+		t.Fatalf("configure registry: %v", err)
+	}
+	return New(reg, nil, nil)
+}
+
+func TestSearchAggregate(t *testing.T) {
+	svc := newTestService(t,
+		&stubAdapter{id: "a", results: []models.SearchResult{{Title: "one", InfoHash: "AAA"}}},
+		&stubAdapter{id: "b", results: []models.SearchResult{{Title: "two", InfoHash: "BBB"}}},
+		&stubAdapter{id: "c", err: errors.New("boom")},
+	)
+
+	results, meta, err := svc.search(context.Background(), "all", models.SearchOptions{Query: "x", Page: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if meta.Mode != "aggregate" {
+		t.Errorf("mode = %q, want aggregate", meta.Mode)
+	}
+	if len(results) != 2 || meta.ResultCount != 2 {
+		t.Errorf("got %d results (meta %d), want 2", len(results), meta.ResultCount)
+	}
+	if len(meta.Adapters) != 3 {
+		t.Fatalf("got %d adapter statuses, want 3", len(meta.Adapters))
+	}
+	for _, status := range meta.Adapters {
+		want := models.AdapterStatusOK
+		if status.Adapter == "c" {
+			want = models.AdapterStatusError
+		}
+		if status.Status != want {
+			t.Errorf("adapter %s status = %q, want %q", status.Adapter, status.Status, want)
+		}
+	}
+	if meta.AdapterError != "" {
+		t.Errorf("unexpected adapter error %q", meta.AdapterError)
+	}
+}
+
+func TestSearchAggregateList(t *testing.T) {
+	svc := newTestService(t,
+		&stubAdapter{id: "a", results: []models.SearchResult{{Title: "one"}}},
+		&stubAdapter{id: "b", results: []models.SearchResult{{Title: "two"}}},
+	)
+
+	_, meta, err := svc.search(context.Background(), "b, b", models.SearchOptions{Query: "x", Page: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if meta.Mode != "aggregate" || len(meta.Adapters) != 1 || meta.Adapters[0].Adapter != "b" {
+		t.Errorf("unexpected meta: %+v", meta)
+	}
+
+	if _, _, err := svc.search(context.Background(), "a,missing", models.SearchOptions{Query: "x", Page: 1}); err == nil {
+		t.Error("expected error for unknown adapter")
+	}
+}
+
+func TestSearchFallbackChain(t *testing.T) {
+	svc := newTestService(t,
+		&stubAdapter{id: "primary", err: errors.New("down")},
+		&stubAdapter{id: "first"},
+		&stubAdapter{id: "second", err: errors.New("also down")},
+		&stubAdapter{id: "third", results: []models.SearchResult{{Title: "found"}}},
+		&stubAdapter{id: "unused", results: []models.SearchResult{{Title: "never"}}},
+	)
+	if err := svc.registry.Configure("primary", "first", "primary", "second", "third", "unused"); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+
+	results, meta, err := svc.search(context.Background(), "", models.SearchOptions{Query: "x", Page: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "found" {
+		t.Fatalf("results = %+v", results)
+	}
+	if !meta.FallbackUsed || meta.FallbackAdapter != "third" {
+		t.Errorf("fallback = %v/%q, want third", meta.FallbackUsed, meta.FallbackAdapter)
+	}
+
+	want := []string{"primary", "first", "second", "third"}
+	if len(meta.Attempts) != len(want) {
+		t.Fatalf("got %d attempts, want %d", len(meta.Attempts), len(want))
+	}
+	for i, attempt := range meta.Attempts {
+		if attempt.Adapter != want[i] {
+			t.Errorf("attempt %d = %s, want %s", i, attempt.Adapter, want[i])
+		}
+	}
+	if meta.Attempts[2].Error != "also down" {
+		t.Errorf("attempt error = %q", meta.Attempts[2].Error)
+	}
+}
+
+type capableStub struct {
+	stubAdapter
+	caps models.Capabilities
+}
+
+func (a *capableStub) Capabilities() models.Capabilities { return a.caps }
+
+func TestSearchPaginationFromCapabilities(t *testing.T) {
+	page := []models.SearchResult{{Title: "one", InfoHash: "AAA"}, {Title: "two", InfoHash: "BBB"}}
+	tests := []struct {
+		name      string
+		adapter   models.Adapter
+		wantNext  bool
+		wantTotal int
+	}{
+		{"full page", &capableStub{stubAdapter{id: "p", results: page}, models.Capabilities{Pagination: true, PageSize: 2}}, true, 0},
+		{"short page", &capableStub{stubAdapter{id: "p", results: page}, models.Capabilities{Pagination: true, PageSize: 50}}, false, 0},
+		{"unknown page size", &capableStub{stubAdapter{id: "p", results: page}, models.Capabilities{Pagination: true}}, true, 0},
+		{"no pagination", &capableStub{stubAdapter{id: "p", results: page}, models.Capabilities{PageSize: 2}}, false, 1},
+		{"undeclared", &stubAdapter{id: "p", results: page}, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, tt.adapter)
+			_, meta, err := svc.search(context.Background(), "", models.SearchOptions{Query: "x", Page: 1})
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if meta.HasNextPage != tt.wantNext || meta.TotalPages != tt.wantTotal {
+				t.Errorf("hasNextPage = %v, totalPages = %d, want %v, %d", meta.HasNextPage, meta.TotalPages, tt.wantNext, tt.wantTotal)
+			}
+		})
+	}
+}
+
+type pagedStub struct {
+	capableStub
+	page models.ResultPage
+}
+
+func (a *pagedStub) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	return a.page, a.err
+}
+
+func TestSearchPaginationFromResultPage(t *testing.T) {
+	results := []models.SearchResult{{Title: "one", InfoHash: "AAA"}}
+	caps := models.Capabilities{Pagination: true, PageSize: 75}
+	tests := []struct {
+		name      string
+		page      models.ResultPage
+		current   int
+		wantPages int
+		wantNext  bool
+	}{
+		{"reported pages", models.ResultPage{Results: results, Total: 1000, TotalPages: 14}, 1, 14, true},
+		{"last page", models.ResultPage{Results: results, Total: 1000, TotalPages: 14}, 14, 14, false},
+		{"pages from total", models.ResultPage{Results: results, Total: 160}, 2, 3, true},
+		{"unknown totals", models.ResultPage{Results: results}, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, &pagedStub{capableStub{stubAdapter{id: "p"}, caps}, tt.page})
+			_, meta, err := svc.search(context.Background(), "", models.SearchOptions{Query: "x", Page: tt.current})
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if meta.TotalPages != tt.wantPages || meta.HasNextPage != tt.wantNext || meta.TotalResults != tt.page.Total {
+				t.Errorf("totalPages = %d, hasNextPage = %v, totalResults = %d; want %d, %v, %d",
+					meta.TotalPages, meta.HasNextPage, meta.TotalResults, tt.wantPages, tt.wantNext, tt.page.Total)
+			}
+		})
+	}
+}
+
+func TestSearchRecordsHTTPRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`[{"name":"retried","info_hash":"AAA","seeders":"1","leechers":"0","size":"1","added":"0","category":"200"}]`))
+	}))
+	defer srv.Close()
+
+	apibay := adapters.NewAPIBay(srv.URL, nil)
+	if err := apibay.(adapters.NetworkConfigurable).ConfigureNetwork(httpclient.Config{
+		Retry: httpclient.RetryConfig{BaseDelay: httpclient.Duration(time.Millisecond)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	svc := newTestService(t, apibay)
+
+	results, meta, err := svc.search(context.Background(), "", models.SearchOptions{Query: "x", Page: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || meta.AdapterError != "" {
+		t.Fatalf("got %d results, error %q; want 1 result after retry", len(results), meta.AdapterError)
+	}
+	if status := meta.Attempts[0]; status.Requests != 2 || status.Retries != 1 {
+		t.Errorf("requests = %d, retries = %d; want 2, 1", status.Requests, status.Retries)
+	}
+}
+
+func TestSearchReportsRateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	apibay := adapters.NewAPIBay(srv.URL, nil)
+	if err := apibay.(adapters.NetworkConfigurable).ConfigureNetwork(httpclient.Config{
+		RateLimit: httpclient.RateLimitConfig{Rate: 0.1, Mode: httpclient.RateLimitFail},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	svc := newTestService(t, apibay)
+	options := models.SearchOptions{Query: "x", Page: 1}
+
+	if _, meta, _ := svc.search(context.Background(), "", options); meta.Attempts[0].Status != models.AdapterStatusEmpty {
+		t.Fatalf("first search status = %q, want empty", meta.Attempts[0].Status)
+	}
+	_, meta, err := svc.search(context.Background(), "", options)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if status := meta.Attempts[0]; status.Status != models.AdapterStatusRateLimited || meta.AdapterError == "" {
+		t.Errorf("status = %q, adapterError = %q; want rate-limited", status.Status, meta.AdapterError)
+	}
+	// 本地限速不计入熔断器的失败次数
+	if failures := svc.registry.Health(apibay.ID()).ConsecutiveFailures; failures != 0 {
+		t.Errorf("consecutive failures = %d, want 0", failures)
+	}
+}
+
+func TestSearchReportsMirrorEndpoint(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name":"mirrored","info_hash":"AAA","seeders":"1","leechers":"0","size":"1","added":"0","category":"200"}]`))
+	}))
+	defer healthy.Close()
+
+	apibay := adapters.NewAPIBay(broken.URL+","+healthy.URL, nil)
+	svc := newTestService(t, apibay)
+
+	results, meta, err := svc.search(context.Background(), "", models.SearchOptions{Query: "x", Page: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || meta.FallbackUsed {
+		t.Fatalf("got %d results, fallback %v; want 1 result from the same adapter", len(results), meta.FallbackUsed)
+	}
+	if meta.AdapterEndpoint != healthy.URL || meta.Attempts[0].Endpoint != healthy.URL {
+		t.Errorf("endpoint = %q (status %q), want mirror %q", meta.AdapterEndpoint, meta.Attempts[0].Endpoint, healthy.URL)
+	}
+}
+
+func TestSearchReportsCachedResults(t *testing.T) {
+	store, err := cache.NewStore(time.Minute, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := newTestService(t,
+		cache.Wrap(&stubAdapter{id: "a", results: []models.SearchResult{{Title: "one", InfoHash: "AAA"}}}, store),
+		cache.Wrap(&stubAdapter{id: "b", results: []models.SearchResult{{Title: "two", InfoHash: "BBB"}}}, store),
+	)
+	options := models.SearchOptions{Query: "x", Page: 1}
+
+	_, meta, _ := svc.search(context.Background(), "a", options)
+	if meta.Cached || meta.CachedAt != nil {
+		t.Fatalf("first search cached = %v, want upstream result", meta.Cached)
+	}
+	_, meta, _ = svc.search(context.Background(), "a", options)
+	if !meta.Cached || meta.CachedAt == nil || !meta.Attempts[0].Cached {
+		t.Errorf("second search cached = %v, cachedAt = %v; want cached", meta.Cached, meta.CachedAt)
+	}
+	_, meta, _ = svc.search(cache.WithBypass(context.Background()), "a", options)
+	if meta.Cached {
+		t.Error("nocache search served from cache")
+	}
+
+	// 聚合搜索只有在所有适配器都命中缓存时才标记为缓存结果
+	_, meta, _ = svc.search(context.Background(), "a,b", options)
+	if meta.Cached {
+		t.Error("aggregate with uncached adapter marked cached")
+	}
+	_, meta, _ = svc.search(context.Background(), "a,b", options)
+	if !meta.Cached {
+		t.Error("fully cached aggregate not marked cached")
+	}
+}
+
+func TestSearchMagnetMode(t *testing.T) {
+	svc := newTestService(t, &stubAdapter{id: "a"})
+	magnetLink := "magnet:?xt=urn:btih:6JL26MNGEBGNONGSXLWLQMYWG6CQW62E&dn=Demo&xl=2048&tr=udp%3A%2F%2Ftracker.example%3A1337"
+
+	rec := adminRequest(t, svc.Routes(), http.MethodGet, "/api/search?norecord=true&q="+url.QueryEscape(magnetLink), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var response models.SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Meta.Mode != "magnet" || len(response.Results) != 1 {
+		t.Fatalf("response = %+v", response)
+	}
+	result := response.Results[0]
+	if result.InfoHash != "F257AF31A6204CD734D2BAECB8331637850B7B44" || result.Title != "Demo" || result.Size == nil || *result.Size != 2048 {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Trackers) != 1 || result.Trackers[0] != "udp://tracker.example:1337" {
+		t.Errorf("trackers = %v", result.Trackers)
+	}
+
+	// 基线版本接受的链接（没有 xt、非标准的 btih、无效的 xl）仍然可以搜索，不返回 400
+	for raw, wantHash := range map[string]string{
+		"magnet:?dn=no+hash":                                         "",
+		"magnet:?xt=urn:btih:ABC123&dn=short":                        "ABC123",
+		"magnet:?xt=urn:btih:" + strings.Repeat("ab", 20) + "&xl=-1": strings.Repeat("AB", 20),
+	} {
+		rec = adminRequest(t, svc.Routes(), http.MethodGet, "/api/search?norecord=true&q="+url.QueryEscape(raw), "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, body %s", raw, rec.Code, rec.Body)
+			continue
+		}
+		response = models.SearchResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Results) != 1 || response.Results[0].InfoHash != wantHash || response.Results[0].Magnet != raw {
+			t.Errorf("%s: results = %+v, want info hash %q", raw, response.Results, wantHash)
+		}
+	}
+}
@@ -1,20 +1,23 @@
 package service
 
 import (
+    "context"
     "encoding/json"
     "fmt"
     "io"
     "log"
     "net/http"
-    "strconv"
     "strings"
     "time"
 
+    "github.com/seedmanage/backend/internal/cache"
     "github.com/seedmanage/backend/internal/collections"
     "github.com/seedmanage/backend/internal/config"
     "github.com/seedmanage/backend/internal/history"
+    "github.com/seedmanage/backend/internal/metadata"
     "github.com/seedmanage/backend/internal/models"
     "github.com/seedmanage/backend/internal/registry"
+    "github.com/seedmanage/backend/internal/scrape"
     "github.com/seedmanage/backend/internal/utils"
 )
 
@@ -23,14 +26,21 @@ type APIService struct {
     registry    *registry.AdapterRegistry
     history     *history.Store
     collections *collections.Store
+    scraper     *scrape.Scraper
+    metadata    *metadata.Fetcher
 }
 
 // New 创建一个新的 API 服务
 func New(reg *registry.AdapterRegistry, historyStore *history.Store, collStore *collections.Store) *APIService {
+    scraper := scrape.New(config.BaseTrackers, scrape.DefaultTimeout)
+    // 只使用内存的 Store 不会返回错误
+    metadataStore, _ := metadata.NewStore("")
     return &APIService{
         registry:    reg,
         history:     historyStore,
         collections: collStore,
+        scraper:     scraper,
+        metadata:    metadata.NewFetcher(metadataStore, scraper, metadata.DefaultTimeout),
     }
 }
 
@@ -41,8 +51,13 @@ func (s *APIService) Routes() http.Handler {
     mux.HandleFunc("/api/adapters", s.withJSON(s.handleAdapters))
     mux.HandleFunc("/api/search", s.withJSON(s.handleSearch))
     mux.HandleFunc("/api/history", s.withJSON(s.handleHistory))
+    mux.HandleFunc("/api/scrape", s.withJSON(s.handleScrape))
+    mux.HandleFunc("/api/metadata", s.withJSON(s.handleMetadata))
+    mux.HandleFunc("/api/torrent/", s.withJSON(s.handleTorrentFile))
     mux.HandleFunc("/api/collections", s.withJSON(s.handleCollections))
     mux.HandleFunc("/api/collections/", s.withJSON(s.handleCollectionByID))
+    mux.HandleFunc("/api/admin/adapters", s.withJSON(s.handleAdminAdapters))
+    mux.HandleFunc("/api/admin/adapters/", s.withJSON(s.handleAdminAdapter))
     return s.cors(mux)
 }
 
@@ -81,12 +96,23 @@ func (s *APIService) handleHealth(w http.ResponseWriter, r *http.Request) error
     if r.Method != http.MethodGet {
         return NewMethodNotAllowedError(r.Method)
     }
+    adapters := s.registry.List()
+
+    // 有适配器处于熔断状态时标记为降级
+    status := "ok"
+    for _, adapter := range adapters {
+        if adapter.Health.State != registry.CircuitClosed {
+            status = "degraded"
+            break
+        }
+    }
+
     payload := map[string]any{
-        "status":         "ok",
+        "status":         status,
         "version":        config.Version,
         "time":           time.Now().UTC(),
         "defaultAdapter": s.registry.DefaultID(),
-        "adapters":       s.registry.List(),
+        "adapters":       adapters,
     }
     return s.writeJSON(w, payload, http.StatusOK)
 }
@@ -97,8 +123,9 @@ func (s *APIService) handleAdapters(w http.ResponseWriter, r *http.Request) erro
         return NewMethodNotAllowedError(r.Method)
     }
     payload := map[string]any{
-        "adapters":       s.registry.List(),
-        "defaultAdapter": s.registry.DefaultID(),
+        "adapters":         s.registry.List(),
+        "defaultAdapter":   s.registry.DefaultID(),
+        "fallbackAdapters": s.registry.FallbackIDs(),
     }
     return s.writeJSON(w, payload, http.StatusOK)
 }
@@ -143,8 +170,11 @@ func (s *APIService) handleHistory(w http.ResponseWriter, r *http.Request) error
     }
 }
 
-// handleSearch 处理搜索请求
+// handleSearch 处理搜索请求，POST 请求上传 .torrent 文件
 func (s *APIService) handleSearch(w http.ResponseWriter, r *http.Request) error {
+    if r.Method == http.MethodPost {
+        return s.handleTorrentUpload(w, r)
+    }
     if r.Method != http.MethodGet {
         return NewMethodNotAllowedError(r.Method)
     }
@@ -154,23 +184,19 @@ func (s *APIService) handleSearch(w http.ResponseWriter, r *http.Request) error
         return ClientError{Message: "请提供搜索关键字或磁力链接。"}
     }
 
-    // 解析分页参数
-    page := 1
-    if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-        if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-            page = p
-        }
-    }
-
     // 如果是磁力链接，直接解析并返回
     if strings.HasPrefix(strings.ToLower(query), "magnet:?") {
-        result, err := utils.ParseMagnetLink(query)
+        result, _, err := utils.ParseMagnetLink(query)
         if err != nil {
             return ClientError{Message: err.Error()}
         }
+        results := []models.SearchResult{result}
+        if r.URL.Query().Get("scrape") == "true" {
+            s.refreshSwarms(r.Context(), w, results)
+        }
         response := models.SearchResponse{
             Query:   query,
-            Results: []models.SearchResult{result},
+            Results: results,
             Meta: models.SearchMeta{
                 Mode:        "magnet",
                 ResultCount: 1,
@@ -179,71 +205,28 @@ func (s *APIService) handleSearch(w http.ResponseWriter, r *http.Request) error
         if r.URL.Query().Get("norecord") != "true" {
             s.recordHistory(response)
         }
+        if r.URL.Query().Get("metadata") == "true" {
+            extendWriteDeadline(w, metadataAttachTimeout+metadataWriteMargin)
+            s.attachMetadata(r.Context(), response.Results)
+        }
         return s.writeJSON(w, response, http.StatusOK)
     }
 
-    // 获取适配器并执行搜索
-    adapterID := strings.TrimSpace(r.URL.Query().Get("adapter"))
-    if adapterID == "" {
-        adapterID = s.registry.DefaultID()
-    }
-
-    adapter, ok := s.registry.Get(adapterID)
-    if !ok {
-        return ClientError{Message: fmt.Sprintf("未知的适配器: %s", adapterID)}
-    }
-
-    searchOptions := models.SearchOptions{
-        Query: query,
-        Page:  page,
+    // 解析分页、分类、排序和过滤参数，磁力链接不使用这些参数
+    searchOptions, err := parseSearchOptions(r.URL.Query(), query)
+    if err != nil {
+        return err
     }
 
-    results, err := adapter.SearchWithOptions(r.Context(), searchOptions)
-
-    meta := models.SearchMeta{
-        Mode:               "search",
-        Adapter:            adapter.ID(),
-        AdapterName:        adapter.Name(),
-        AdapterDescription: adapter.Description(),
-        AdapterEndpoint:    adapter.Endpoint(),
-        ResultCount:        len(results),
-        CurrentPage:        page,
-        HasPrevPage:        page > 1,
+    // 执行搜索，adapter 可以是单个 ID、"all" 或逗号分隔的列表
+    results, meta, err := s.search(searchContext(r), r.URL.Query().Get("adapter"), searchOptions)
+    if err != nil {
+        return err
     }
 
-    if err != nil {
-        meta.AdapterError = err.Error()
-    }
-
-    // 尝试确定是否有下一页（基于返回的结果数量）
-    // 对于支持分页的适配器，如果返回的结果数量等于预期的页面大小，可能还有下一页
-    const expectedPageSize = 10
-    if len(results) >= expectedPageSize {
-        meta.HasNextPage = true
-    }
-
-    // 如果主适配器失败或无结果，尝试备用适配器
-    if (err != nil || len(results) == 0) {
-        if fallback, ok := s.registry.Fallback(adapter.ID()); ok {
-            fallbackResults, fallbackErr := fallback.SearchWithOptions(r.Context(), searchOptions)
-            if fallbackErr == nil && len(fallbackResults) > 0 {
-                results = fallbackResults
-                meta.ResultCount = len(results)
-                meta.FallbackUsed = true
-                meta.FallbackAdapter = fallback.ID()
-                meta.FallbackAdapterName = fallback.Name()
-                // 更新分页信息
-                if len(fallbackResults) >= expectedPageSize {
-                    meta.HasNextPage = true
-                } else {
-                    meta.HasNextPage = false
-                }
-            } else if fallbackErr != nil {
-                meta.FallbackAdapter = fallback.ID()
-                meta.FallbackAdapterName = fallback.Name()
-                meta.FallbackAdapterError = fallbackErr.Error()
-            }
-        }
+    // scrape=true 时向 tracker 查询实时的做种数和下载数
+    if r.URL.Query().Get("scrape") == "true" {
+        s.refreshSwarms(r.Context(), w, results)
     }
 
     response := models.SearchResponse{
@@ -256,9 +239,23 @@ func (s *APIService) handleSearch(w http.ResponseWriter, r *http.Request) error
         s.recordHistory(response)
     }
 
+    // metadata=true 时附带种子文件列表，在记录历史之后进行，历史中不保存元数据
+    if r.URL.Query().Get("metadata") == "true" {
+        extendWriteDeadline(w, metadataAttachTimeout+metadataWriteMargin)
+        s.attachMetadata(r.Context(), response.Results)
+    }
+
     return s.writeJSON(w, response, http.StatusOK)
 }
 
+// searchContext 返回执行搜索使用的 context，nocache=true 时跳过结果缓存
+func searchContext(r *http.Request) context.Context {
+    if r.URL.Query().Get("nocache") == "true" {
+        return cache.WithBypass(r.Context())
+    }
+    return r.Context()
+}
+
 func (s *APIService) recordHistory(response models.SearchResponse) {
     if s.history == nil {
         return
@@ -365,6 +362,12 @@ func (s *APIService) handleCollectionByID(w http.ResponseWriter, r *http.Request
     }
 
     // Check for sub-routes
+    if strings.HasSuffix(id, "/scrape") {
+        return s.handleCollectionScrape(w, r, strings.TrimSuffix(id, "/scrape"))
+    }
+    if strings.HasSuffix(id, "/torrents.zip") {
+        return s.handleCollectionTorrents(w, r, strings.TrimSuffix(id, "/torrents.zip"))
+    }
     if strings.Contains(r.URL.Path, "/search") {
         id = strings.ReplaceAll(id, "/search", "")
         id = strings.ReplaceAll(id, "/items", "")
@@ -388,6 +391,10 @@ func (s *APIService) handleCollectionByID(w http.ResponseWriter, r *http.Request
         if err != nil {
             return ClientError{Message: err.Error()}
         }
+        if r.URL.Query().Get("metadata") == "true" {
+            extendWriteDeadline(w, metadataAttachTimeout+metadataWriteMargin)
+            s.attachItemMetadata(r.Context(), cf.Items)
+        }
         return s.writeJSON(w, cf, http.StatusOK)
 
     case http.MethodDelete:
@@ -423,6 +430,10 @@ func (s *APIService) handleCollectionItems(w http.ResponseWriter, r *http.Reques
             }
             items = filtered
         }
+        if r.URL.Query().Get("metadata") == "true" {
+            extendWriteDeadline(w, metadataAttachTimeout+metadataWriteMargin)
+            s.attachItemMetadata(r.Context(), items)
+        }
 
         // Return only items and total count for this endpoint
         payload := map[string]any{
@@ -574,73 +585,16 @@ func (s *APIService) handleCollectionSearch(w http.ResponseWriter, r *http.Reque
         return ClientError{Message: "请提供搜索关键字。"}
     }
 
-    // Perform search using existing adapters WITHOUT saving to history
-    adapterID := strings.TrimSpace(r.URL.Query().Get("adapter"))
-    if adapterID == "" {
-        adapterID = s.registry.DefaultID()
-    }
-
-    adapter, ok := s.registry.Get(adapterID)
-    if !ok {
-        return ClientError{Message: fmt.Sprintf("未知的适配器: %s", adapterID)}
-    }
-
-    // 解析分页参数
-    page := 1
-    if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-        if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-            page = p
-        }
-    }
-
-    searchOptions := models.SearchOptions{
-        Query: keyword,
-        Page:  page,
-    }
-
-    results, err := adapter.SearchWithOptions(r.Context(), searchOptions)
-
-    meta := models.SearchMeta{
-        Mode:               "search",
-        Adapter:            adapter.ID(),
-        AdapterName:        adapter.Name(),
-        AdapterDescription: adapter.Description(),
-        AdapterEndpoint:    adapter.Endpoint(),
-        ResultCount:        len(results),
-        CurrentPage:        page,
-        HasPrevPage:        page > 1,
+    // 解析分页、分类、排序和过滤参数
+    searchOptions, err := parseSearchOptions(r.URL.Query(), keyword)
+    if err != nil {
+        return err
     }
 
+    // Perform search using existing adapters WITHOUT saving to history
+    results, meta, err := s.search(searchContext(r), r.URL.Query().Get("adapter"), searchOptions)
     if err != nil {
-        meta.AdapterError = err.Error()
-    }
-
-    const expectedPageSize = 10
-    if len(results) >= expectedPageSize {
-        meta.HasNextPage = true
-    }
-
-    // Try fallback if main adapter fails
-    if (err != nil || len(results) == 0) {
-        if fallback, ok := s.registry.Fallback(adapter.ID()); ok {
-            fallbackResults, fallbackErr := fallback.SearchWithOptions(r.Context(), searchOptions)
-            if fallbackErr == nil && len(fallbackResults) > 0 {
-                results = fallbackResults
-                meta.ResultCount = len(results)
-                meta.FallbackUsed = true
-                meta.FallbackAdapter = fallback.ID()
-                meta.FallbackAdapterName = fallback.Name()
-                if len(fallbackResults) >= expectedPageSize {
-                    meta.HasNextPage = true
-                } else {
-                    meta.HasNextPage = false
-                }
-            } else if fallbackErr != nil {
-                meta.FallbackAdapter = fallback.ID()
-                meta.FallbackAdapterName = fallback.Name()
-                meta.FallbackAdapterError = fallbackErr.Error()
-            }
-        }
+        return err
     }
 
     response := models.SearchResponse{
@@ -674,6 +628,9 @@ func (s *APIService) writeJSONError(w http.ResponseWriter, err error) {
     case MethodNotAllowed:
         status = http.StatusMethodNotAllowed
         payload["error"] = e.Error()
+    case NotFound:
+        status = http.StatusNotFound
+        payload["error"] = e.Message
     default:
         if err != nil {
             payload["error"] = err.Error()
@@ -0,0 +1,275 @@
+package service
+
+import (
+    "archive/zip"
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "mime"
+    "net/http"
+    "strings"
+
+    "github.com/seedmanage/backend/internal/config"
+    "github.com/seedmanage/backend/internal/metadata"
+    "github.com/seedmanage/backend/internal/models"
+    "github.com/seedmanage/backend/internal/scrape"
+    "github.com/seedmanage/backend/internal/utils"
+)
+
+const (
+    // maxTorrentFileSize 是单个上传的 .torrent 文件的最大字节数
+    maxTorrentFileSize = 10 << 20
+    // maxTorrentUploadSize 是一次上传请求的最大字节数
+    maxTorrentUploadSize = 64 << 20
+    // maxTorrentFiles 是一次上传的最大文件数
+    maxTorrentFiles = 100
+)
+
+// handleTorrentUpload 解析上传的 .torrent 文件并以搜索结果的形式返回
+// 请求为 multipart/form-data，file 字段可重复；collection 字段为集合 ID 时把结果加入该集合
+// 也可以直接以 application/x-bittorrent 请求体上传单个文件，此时集合 ID 使用 collection 查询参数
+// 任一文件无效时整个请求失败，不会加入集合
+func (s *APIService) handleTorrentUpload(w http.ResponseWriter, r *http.Request) error {
+    r.Body = http.MaxBytesReader(w, r.Body, maxTorrentUploadSize)
+
+    var files []namedTorrent
+    collectionID := r.URL.Query().Get("collection")
+    if strings.Contains(r.Header.Get("Content-Type"), "multipart/form-data") {
+        if err := r.ParseMultipartForm(maxTorrentFileSize); err != nil {
+            return ClientError{Message: "无法读取上传的文件。"}
+        }
+        defer r.MultipartForm.RemoveAll()
+        collectionID = strings.TrimSpace(r.FormValue("collection"))
+
+        headers := r.MultipartForm.File["file"]
+        if len(headers) > maxTorrentFiles {
+            return ClientError{Message: fmt.Sprintf("单次最多上传 %d 个种子文件。", maxTorrentFiles)}
+        }
+        for _, header := range headers {
+            file, err := header.Open()
+            if err != nil {
+                return ClientError{Message: "无法读取上传的文件。"}
+            }
+            data, err := readTorrentFile(file)
+            file.Close()
+            if err != nil {
+                return ClientError{Message: fmt.Sprintf("%s: %v", header.Filename, err)}
+            }
+            files = append(files, namedTorrent{name: header.Filename, data: data})
+        }
+    } else {
+        data, err := readTorrentFile(r.Body)
+        if err != nil {
+            return ClientError{Message: err.Error()}
+        }
+        if len(data) > 0 {
+            files = append(files, namedTorrent{name: "upload.torrent", data: data})
+        }
+    }
+    if len(files) == 0 {
+        return ClientError{Message: "请上传种子文件。"}
+    }
+
+    results := make([]models.SearchResult, 0, len(files))
+    infos := make([]models.TorrentInfo, 0, len(files))
+    names := make([]string, 0, len(files))
+    for _, file := range files {
+        torrent, err := metadata.ParseTorrent(file.data)
+        if err != nil {
+            return ClientError{Message: fmt.Sprintf("%s 不是有效的种子文件: %v", file.name, err)}
+        }
+        if len(torrent.Trackers) == 0 && !torrent.Info.Private {
+            torrent.Trackers = config.BaseTrackers
+        }
+        // 缓存 info 字典，之后获取元数据时不必再连接 peer
+        if err := s.metadata.Put(torrent.RawInfo); err != nil {
+            log.Printf("[backend] 种子元数据未缓存: %v", err)
+        }
+        results = append(results, torrent.SearchResult())
+        infos = append(infos, torrent.Info)
+        names = append(names, file.name)
+    }
+
+    if collectionID != "" {
+        if s.collections == nil {
+            return ClientError{Message: "集合功能不可用。"}
+        }
+        items := make([]models.CollectionItem, len(results))
+        for i, result := range results {
+            items[i] = models.CollectionItem{Magnet: result.Magnet, Title: result.Title}
+        }
+        if _, err := s.collections.AddItems(collectionID, items); err != nil {
+            return ClientError{Message: err.Error()}
+        }
+    }
+
+    response := models.SearchResponse{
+        Query:   strings.Join(names, ", "),
+        Results: results,
+        Meta: models.SearchMeta{
+            Mode:        "torrent",
+            ResultCount: len(results),
+        },
+    }
+    if r.URL.Query().Get("norecord") != "true" {
+        s.recordHistory(response)
+    }
+    // 元数据已从文件中解析，总是附带返回；历史中不保存元数据
+    for i := range results {
+        results[i].Metadata = &infos[i]
+    }
+    return s.writeJSON(w, response, http.StatusOK)
+}
+
+// namedTorrent 是上传的种子文件内容和文件名
+type namedTorrent struct {
+    name string
+    data []byte
+}
+
+// readTorrentFile 读取种子文件，超过 maxTorrentFileSize 时返回错误
+func readTorrentFile(r io.Reader) ([]byte, error) {
+    data, err := io.ReadAll(io.LimitReader(r, maxTorrentFileSize+1))
+    var maxBytesErr *http.MaxBytesError
+    if errors.As(err, &maxBytesErr) {
+        return nil, fmt.Errorf("上传内容超过 %d MB", maxTorrentUploadSize>>20)
+    }
+    if err != nil {
+        return nil, errors.New("无法读取上传的文件")
+    }
+    if len(data) > maxTorrentFileSize {
+        return nil, fmt.Errorf("种子文件超过 %d MB", maxTorrentFileSize>>20)
+    }
+    return data, nil
+}
+
+// handleTorrentFile 用缓存的 info 字典生成 .torrent 文件：GET /api/torrent/{infohash}.torrent
+// tr 参数（可重复）中的 tracker 排在内置基础 tracker 之前；私有种子只使用 tr 参数中的 tracker
+func (s *APIService) handleTorrentFile(w http.ResponseWriter, r *http.Request) error {
+    if r.Method != http.MethodGet {
+        return NewMethodNotAllowedError(r.Method)
+    }
+
+    name := strings.TrimPrefix(r.URL.Path, "/api/torrent/")
+    if !strings.HasSuffix(name, ".torrent") {
+        return ClientError{Message: "请求路径应为 /api/torrent/{infohash}.torrent。"}
+    }
+    infoHash := utils.NormalizeInfoHash(strings.TrimSuffix(name, ".torrent"))
+    data, info, err := s.torrentFile(infoHash, r.URL.Query()["tr"])
+    if err != nil {
+        return err
+    }
+
+    w.Header().Set("Content-Type", "application/x-bittorrent")
+    w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": torrentFileName(info.Name)}))
+    _, err = w.Write(data)
+    return err
+}
+
+// handleCollectionTorrents 把集合中已缓存元数据的种子打包为 zip 下载：GET /api/collections/{id}/torrents.zip
+// fetch=true 时先获取缺少的元数据（时间限制与 metadata=true 相同），仍然缺少的条目列在 zip 内的 missing.txt 中
+func (s *APIService) handleCollectionTorrents(w http.ResponseWriter, r *http.Request, collectionID string) error {
+    if r.Method != http.MethodGet {
+        return NewMethodNotAllowedError(r.Method)
+    }
+
+    cf, err := s.collections.Get(collectionID)
+    if err != nil {
+        return ClientError{Message: err.Error()}
+    }
+    if r.URL.Query().Get("fetch") == "true" {
+        extendWriteDeadline(w, metadataAttachTimeout+metadataWriteMargin)
+        s.attachItemMetadata(r.Context(), cf.Items)
+    }
+
+    var buf bytes.Buffer
+    archive := zip.NewWriter(&buf)
+    names := make(map[string]bool)
+    seen := make(map[string]bool)
+    var missing []string
+    for _, item := range cf.Items {
+        req, err := metadata.ParseMagnet(item.Magnet)
+        if err != nil || len(req.InfoHash) != 40 {
+            missing = append(missing, utils.Coalesce(item.Title, item.Magnet))
+            continue
+        }
+        if seen[req.InfoHash] {
+            continue
+        }
+        seen[req.InfoHash] = true
+
+        data, info, err := s.torrentFile(req.InfoHash, req.Trackers)
+        if err != nil {
+            missing = append(missing, req.InfoHash+" "+item.Title)
+            continue
+        }
+        file, err := archive.Create(uniqueFileName(names, torrentFileName(info.Name)))
+        if err != nil {
+            return err
+        }
+        if _, err := file.Write(data); err != nil {
+            return err
+        }
+    }
+    if len(names) == 0 {
+        return NotFound{Message: "集合中没有已缓存元数据的种子。"}
+    }
+    if len(missing) > 0 {
+        file, err := archive.Create("missing.txt")
+        if err != nil {
+            return err
+        }
+        if _, err := io.WriteString(file, strings.Join(missing, "\n")+"\n"); err != nil {
+            return err
+        }
+    }
+    if err := archive.Close(); err != nil {
+        return err
+    }
+
+    w.Header().Set("Content-Type", "application/zip")
+    w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": utils.Coalesce(cf.Meta.Name, collectionID) + ".zip"}))
+    _, err = w.Write(buf.Bytes())
+    return err
+}
+
+// torrentFile 用缓存的 info 字典生成 .torrent 文件，没有缓存时返回 NotFound
+// 公开种子在 trackers 之后追加内置基础 tracker
+func (s *APIService) torrentFile(infoHash string, trackers []string) ([]byte, models.TorrentInfo, error) {
+    rawInfo, ok := s.metadata.RawInfo(infoHash)
+    if !ok {
+        return nil, models.TorrentInfo{}, NotFound{Message: fmt.Sprintf("没有 %s 的元数据，请先通过 /api/metadata 获取或上传种子文件。", infoHash)}
+    }
+    info, err := metadata.ParseInfo(rawInfo)
+    if err != nil {
+        return nil, models.TorrentInfo{}, err
+    }
+    if !info.Private {
+        trackers = s.scraper.Trackers(scrape.Target{InfoHash: infoHash, Trackers: trackers})
+    }
+    data, err := metadata.BuildTorrent(rawInfo, trackers)
+    return data, info, err
+}
+
+// torrentFileName 把种子名称转换为安全的文件名
+func torrentFileName(name string) string {
+    name = strings.Map(func(r rune) rune {
+        if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+            return '_'
+        }
+        return r
+    }, strings.TrimSpace(name))
+    return utils.Coalesce(name, "torrent") + ".torrent"
+}
+
+// uniqueFileName 在 zip 中出现同名文件时追加序号
+func uniqueFileName(names map[string]bool, name string) string {
+    unique := name
+    for i := 2; names[unique]; i++ {
+        unique = fmt.Sprintf("%s (%d).torrent", strings.TrimSuffix(name, ".torrent"), i)
+    }
+    names[unique] = true
+    return unique
+}
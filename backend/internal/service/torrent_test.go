@@ -0,0 +1,224 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/bencode"
+	"github.com/seedmanage/backend/internal/collections"
+	"github.com/seedmanage/backend/internal/metadata"
+	"github.com/seedmanage/backend/internal/models"
+)
+
+// testTorrentFile 返回一个单文件种子的 .torrent 文件内容
+func testTorrentFile(t *testing.T, name string, private bool) []byte {
+	t.Helper()
+	info := map[string]any{
+		"name":         name,
+		"piece length": 1 << 18,
+		"pieces":       strings.Repeat("p", 40),
+		"length":       300 << 10,
+	}
+	torrent := map[string]any{"info": info}
+	if private {
+		info["private"] = 1
+		torrent["announce"] = "https://tracker.example/announce?passkey=secret"
+	}
+	data, err := bencode.Encode(torrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// uploadTorrents 以 multipart/form-data 上传种子文件
+func uploadTorrents(t *testing.T, handler http.Handler, fields map[string]string, files map[string][]byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	for name, data := range files {
+		part, _ := form.CreateFormFile("file", name)
+		part.Write(data)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/search?norecord=true", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTorrentUpload(t *testing.T) {
+	collStore, err := collections.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta, err := collStore.Create("uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := newTestService(t, &stubAdapter{id: "a"})
+	svc.collections = collStore
+
+	rec := uploadTorrents(t, svc.Routes(), map[string]string{"collection": meta.ID}, map[string][]byte{
+		"public.torrent":  testTorrentFile(t, "public.iso", false),
+		"private.torrent": testTorrentFile(t, "private.iso", true),
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var response models.SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Meta.Mode != "torrent" || len(response.Results) != 2 {
+		t.Fatalf("response = %+v", response)
+	}
+	for _, result := range response.Results {
+		if result.Metadata == nil || result.Size == nil || *result.Size != 300<<10 || len(result.InfoHash) != 40 {
+			t.Errorf("result = %+v", result)
+		}
+		if _, ok := svc.metadata.Cached(result.InfoHash); !ok {
+			t.Errorf("%s: metadata not cached", result.Title)
+		}
+		switch result.Title {
+		case "public.iso":
+			// 没有 tracker 的公开种子使用内置基础 tracker
+			if len(result.Trackers) == 0 {
+				t.Error("public torrent has no trackers")
+			}
+		case "private.iso":
+			if len(result.Trackers) != 1 || !strings.Contains(result.Magnet, "passkey%3Dsecret") {
+				t.Errorf("private torrent trackers = %v, magnet %s", result.Trackers, result.Magnet)
+			}
+		}
+	}
+
+	cf, err := collStore.Get(meta.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cf.Items) != 2 || cf.Items[0].Magnet == "" {
+		t.Errorf("collection items = %+v", cf.Items)
+	}
+
+	// 原始请求体上传单个文件
+	req := httptest.NewRequest(http.MethodPost, "/api/search?norecord=true", bytes.NewReader(testTorrentFile(t, "raw.iso", false)))
+	req.Header.Set("Content-Type", "application/x-bittorrent")
+	rec = httptest.NewRecorder()
+	svc.Routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"raw.iso"`) {
+		t.Errorf("raw upload: status = %d, body %s", rec.Code, rec.Body)
+	}
+}
+
+func TestTorrentUploadRejectsInvalidFiles(t *testing.T) {
+	collStore, _ := collections.NewStore(t.TempDir())
+	meta, _ := collStore.Create("uploads")
+	svc := newTestService(t, &stubAdapter{id: "a"})
+	svc.collections = collStore
+
+	rec := uploadTorrents(t, svc.Routes(), map[string]string{"collection": meta.ID}, map[string][]byte{
+		"good.torrent": testTorrentFile(t, "good.iso", false),
+		"bad.torrent":  []byte("d4:infoi1ee"),
+	})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "bad.torrent") {
+		t.Errorf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if cf, _ := collStore.Get(meta.ID); len(cf.Items) != 0 {
+		t.Errorf("collection modified by failed upload: %+v", cf.Items)
+	}
+
+	if rec := uploadTorrents(t, svc.Routes(), nil, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty upload: status = %d", rec.Code)
+	}
+	rec = uploadTorrents(t, svc.Routes(), map[string]string{"collection": "missing"}, map[string][]byte{"good.torrent": testTorrentFile(t, "good.iso", false)})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("missing collection: status = %d", rec.Code)
+	}
+}
+
+func TestTorrentFileExport(t *testing.T) {
+	collStore, _ := collections.NewStore(t.TempDir())
+	meta, _ := collStore.Create("exports")
+	svc := newTestService(t, &stubAdapter{id: "a"})
+	svc.collections = collStore
+
+	upload := testTorrentFile(t, "a/b.iso", true)
+	rec := uploadTorrents(t, svc.Routes(), map[string]string{"collection": meta.ID}, map[string][]byte{"private.torrent": upload})
+	var response models.SearchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil || len(response.Results) != 1 {
+		t.Fatalf("upload: %s", rec.Body)
+	}
+	infoHash := response.Results[0].InfoHash
+	original, _ := metadata.ParseTorrent(upload)
+
+	rec = adminRequest(t, svc.Routes(), http.MethodGet, "/api/torrent/"+strings.ToLower(infoHash)+".torrent?tr="+url.QueryEscape("https://other.example/announce"), "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-bittorrent" {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "filename=a_b.iso.torrent") {
+		t.Errorf("Content-Disposition = %q", rec.Header().Get("Content-Disposition"))
+	}
+	exported, err := metadata.ParseTorrent(rec.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exported.RawInfo, original.RawInfo) {
+		t.Error("exported info dictionary differs from the upload")
+	}
+	// 私有种子不追加基础 tracker
+	if strings.Join(exported.Trackers, ",") != "https://other.example/announce" {
+		t.Errorf("trackers = %v", exported.Trackers)
+	}
+
+	missing := strings.Repeat("EF", 20)
+	if rec := adminRequest(t, svc.Routes(), http.MethodGet, "/api/torrent/"+missing+".torrent", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("uncached: status = %d", rec.Code)
+	}
+	if rec := adminRequest(t, svc.Routes(), http.MethodGet, "/api/torrent/"+infoHash, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("no extension: status = %d", rec.Code)
+	}
+
+	collStore.AddItems(meta.ID, []models.CollectionItem{
+		{Magnet: "magnet:?xt=urn:btih:" + missing, Title: "not cached"},
+		{Magnet: response.Results[0].Magnet, Title: "duplicate"},
+	})
+	rec = adminRequest(t, svc.Routes(), http.MethodGet, "/api/collections/"+meta.ID+"/torrents.zip", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("zip: status = %d, body %s", rec.Code, rec.Body)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	if strings.Join(names, ",") != "a_b.iso.torrent,missing.txt" {
+		t.Errorf("zip entries = %v", names)
+	}
+	file, _ := archive.Open("a_b.iso.torrent")
+	data, _ := io.ReadAll(file)
+	if torrent, err := metadata.ParseTorrent(data); err != nil || torrent.Trackers[0] != "https://tracker.example/announce?passkey=secret" {
+		t.Errorf("zipped torrent = %+v, %v", torrent.Trackers, err)
+	}
+
+	empty, _ := collStore.Create("empty")
+	if rec := adminRequest(t, svc.Routes(), http.MethodGet, "/api/collections/"+empty.ID+"/torrents.zip", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("empty collection: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+    "context"
+    "crypto/subtle"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/seedmanage/backend/internal/config"
+    "github.com/seedmanage/backend/internal/models"
+    "github.com/seedmanage/backend/internal/registry"
+    "github.com/seedmanage/backend/internal/torznab"
+)
+
+const (
+    // torznabDefaultLimit 和 torznabMaxLimit 是 Torznab 单次返回结果数的默认值和上限
+    torznabDefaultLimit = 50
+    torznabMaxLimit     = 100
+)
+
+// TorznabHandler 返回实现 Torznab 索引器 API 的处理器，供 Sonarr/Radarr 等工具使用
+// 该接口使用独立的 API Key 认证（apikey 参数），apiKey 为空时接口禁用
+func (s *APIService) TorznabHandler(apiKey string) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodGet {
+            s.writeTorznabError(w, http.StatusMethodNotAllowed, torznab.ErrNoSuchFunction, "method not allowed")
+            return
+        }
+
+        params := r.URL.Query()
+        if apiKey == "" {
+            s.writeTorznabError(w, http.StatusForbidden, torznab.ErrAPIDisabled, "torznab api is disabled")
+            return
+        }
+        if subtle.ConstantTimeCompare([]byte(params.Get("apikey")), []byte(apiKey)) != 1 {
+            s.writeTorznabError(w, http.StatusUnauthorized, torznab.ErrIncorrectCredentials, "incorrect user credentials")
+            return
+        }
+
+        switch fn := params.Get("t"); fn {
+        case "caps":
+            s.writeTorznabCaps(w)
+        case "search", "tvsearch", "movie":
+            s.handleTorznabSearch(w, r, fn)
+        case "":
+            s.writeTorznabError(w, http.StatusBadRequest, torznab.ErrMissingParameter, "missing parameter t")
+        default:
+            s.writeTorznabError(w, http.StatusBadRequest, torznab.ErrNoSuchFunction, fmt.Sprintf("no such function %s", fn))
+        }
+    })
+}
+
+// handleTorznabSearch 将 Torznab 搜索转换为注册器搜索并以 RSS 输出
+func (s *APIService) handleTorznabSearch(w http.ResponseWriter, r *http.Request, fn string) {
+    params := r.URL.Query()
+
+    query := strings.TrimSpace(params.Get("q"))
+    if fn == "tvsearch" {
+        if season, err := strconv.Atoi(params.Get("season")); err == nil {
+            query = strings.TrimSpace(fmt.Sprintf("%s S%02d", query, season))
+            if ep, err := strconv.Atoi(params.Get("ep")); err == nil {
+                query += fmt.Sprintf("E%02d", ep)
+            }
+        }
+    }
+
+    limit := torznabDefaultLimit
+    if l, err := strconv.Atoi(params.Get("limit")); err == nil && l > 0 {
+        limit = min(l, torznabMaxLimit)
+    }
+    offset := 0
+    if o, err := strconv.Atoi(params.Get("offset")); err == nil && o > 0 {
+        offset = o
+    }
+
+    // 没有关键字的请求（Sonarr/Radarr 的 RSS 同步和索引器测试）返回适配器最新发布的一页，
+    // 由适配器按空关键字查询（如 nyaa 的 RSS 订阅），并按发布时间倒序
+    options := models.SearchOptions{Query: query}
+    if query == "" {
+        options.Sort, options.Order = models.SortDate, models.OrderDesc
+    }
+    found, upstreamTotal, hasMore, err := s.searchTorznabRange(r.Context(), params.Get("adapter"), options, offset, limit)
+    if err != nil {
+        s.writeTorznabError(w, http.StatusBadRequest, torznab.ErrIncorrectParameter, err.Error())
+        return
+    }
+    // 上游报告了总结果数时直接使用；否则还有更多结果时至少多报告一个，让客户端继续翻页
+    total := offset + len(found)
+    switch {
+    case upstreamTotal > 0:
+        total = upstreamTotal
+    case hasMore:
+        total++
+    }
+    results := filterTorznabCategories(found, params.Get("cat"))
+
+    items := make([]torznab.Item, 0, len(results))
+    for _, result := range results {
+        items = append(items, torznabItem(result))
+    }
+
+    w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+    w.WriteHeader(http.StatusOK)
+    torznab.WriteFeed(w, "seedManage", &torznab.Response{Offset: offset, Total: total}, items)
+}
+
+// searchTorznabRange 按 options（页码由偏移量换算）返回上游结果中从 offset 开始的至多 limit 个结果、上游报告的总结果数（0 表示未知）
+// 以及之后是否还有结果
+// Torznab 的 offset/limit 与适配器的页大小无关：单个支持分页且声明了页大小的适配器按页大小换算出起始页，
+// 从 offset%pageSize 处开始截取，并依次请求后续页直到凑满 limit；聚合搜索以及页大小未知或不支持分页的
+// 适配器只请求第一页并在其中截取
+func (s *APIService) searchTorznabRange(ctx context.Context, adapterParam string, options models.SearchOptions, offset, limit int) ([]models.SearchResult, int, bool, error) {
+    pageSize := 0
+    if ids, aggregate, err := s.resolveAdapters(adapterParam); err == nil && !aggregate {
+        adapter, _ := s.registry.Get(ids[0])
+        if caps := registry.CapabilitiesOf(adapter); caps.Pagination {
+            pageSize = caps.PageSize
+        }
+    }
+    page, skip := 1, offset
+    if pageSize > 0 {
+        page, skip = offset/pageSize+1, offset%pageSize
+    }
+
+    var results []models.SearchResult
+    for {
+        options.Page = page
+        found, meta, err := s.search(ctx, adapterParam, options)
+        if err != nil {
+            return nil, 0, false, err
+        }
+        if skip < len(found) {
+            results = append(results, found[skip:]...)
+        }
+        skip = 0
+
+        hasMore := len(results) > limit || (pageSize > 0 && meta.HasNextPage)
+        if pageSize == 0 || len(results) >= limit || !meta.HasNextPage || len(found) == 0 {
+            return results[:min(len(results), limit)], meta.TotalResults, hasMore, nil
+        }
+        page++
+    }
+}
+
+// writeTorznabCaps 输出索引器能力描述
+func (s *APIService) writeTorznabCaps(w http.ResponseWriter) {
+    caps := torznab.Caps{
+        Server: torznab.CapsServer{Version: config.Version, Title: "seedManage"},
+        Limits: torznab.CapsLimits{Max: torznabMaxLimit, Default: torznabDefaultLimit},
+        Searching: torznab.CapsSearching{
+            Search:      torznab.CapsFunction{Available: "yes", SupportedParams: "q"},
+            TVSearch:    torznab.CapsFunction{Available: "yes", SupportedParams: "q,season,ep"},
+            MovieSearch: torznab.CapsFunction{Available: "yes", SupportedParams: "q"},
+        },
+        Categories: torznab.Categories(),
+    }
+
+    w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+    w.WriteHeader(http.StatusOK)
+    torznab.WriteCaps(w, caps)
+}
+
+func (s *APIService) writeTorznabError(w http.ResponseWriter, status, code int, description string) {
+    w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+    w.WriteHeader(status)
+    torznab.WriteError(w, code, description)
+}
+
+// torznabItem 将搜索结果转换为 Torznab 条目
+func torznabItem(result models.SearchResult) torznab.Item {
+    category := strconv.Itoa(torznabCategory(result))
+    item := torznab.Item{
+        Title:      result.Title,
+        GUID:       result.Magnet,
+        Link:       result.Magnet,
+        Categories: []string{category},
+        Attrs: []torznab.Attr{
+            {Name: torznab.AttrCategory, Value: category},
+            {Name: torznab.AttrMagnetURL, Value: result.Magnet},
+            {Name: torznab.AttrDownloadVF, Value: "1"},
+            {Name: torznab.AttrUploadVF, Value: "1"},
+        },
+    }
+
+    if result.InfoHash != "" {
+        item.GUID = result.InfoHash
+        item.Attrs = append(item.Attrs, torznab.Attr{Name: torznab.AttrInfoHash, Value: result.InfoHash})
+    }
+    if result.Uploaded != nil {
+        item.PubDate = result.Uploaded.UTC().Format(time.RFC1123Z)
+    }
+
+    var length int64
+    if result.Size != nil {
+        length = *result.Size
+        item.Size = length
+        item.Attrs = append(item.Attrs, torznab.Attr{Name: torznab.AttrSize, Value: strconv.FormatInt(length, 10)})
+    }
+    item.Enclosure = &torznab.Enclosure{URL: result.Magnet, Length: length, Type: "application/x-bittorrent"}
+
+    if result.Seeders != nil {
+        peers := *result.Seeders
+        if result.Leechers != nil {
+            peers += *result.Leechers
+        }
+        item.Attrs = append(item.Attrs,
+            torznab.Attr{Name: torznab.AttrSeeders, Value: strconv.Itoa(*result.Seeders)},
+            torznab.Attr{Name: torznab.AttrPeers, Value: strconv.Itoa(peers)},
+        )
+    }
+
+    return item
+}
+
+// filterTorznabCategories 按请求的分类过滤结果，无法识别分类（Other）的结果始终保留
+func filterTorznabCategories(results []models.SearchResult, cats string) []models.SearchResult {
+    wanted := make(map[int]bool)
+    for _, cat := range strings.Split(cats, ",") {
+        if id, err := strconv.Atoi(strings.TrimSpace(cat)); err == nil {
+            wanted[id] = true
+            wanted[id/1000*1000] = true
+        }
+    }
+    if len(wanted) == 0 {
+        return results
+    }
+
+    filtered := make([]models.SearchResult, 0, len(results))
+    for _, result := range results {
+        id := torznabCategory(result)
+        if id == 8000 || wanted[id] || wanted[id/1000*1000] {
+            filtered = append(filtered, result)
+        }
+    }
+    return filtered
+}
+
+// torznabCategory 根据来源和分类名称推断标准 Torznab 分类
+func torznabCategory(result models.SearchResult) int {
+    if strings.HasPrefix(result.Source, "sukebei") || strings.HasPrefix(result.Source, "htmlsukebei") {
+        return 6000
+    }
+
+    // apibay 的分类是数字编码
+    if code, err := strconv.Atoi(result.Category); err == nil {
+        switch {
+        case code >= 100 && code < 200:
+            return 3000
+        case code == 205 || code == 208 || code == 212:
+            return 5000
+        case code >= 200 && code < 300:
+            return 2000
+        case code >= 300 && code < 400:
+            return 4000
+        case code >= 400 && code < 500:
+            return 4050
+        case code >= 500 && code < 600:
+            return 6000
+        case code == 601:
+            return 7000
+        }
+        return 8000
+    }
+
+    category := strings.ToLower(result.Category)
+    switch {
+    case strings.Contains(category, "anime"):
+        return 5070
+    case strings.Contains(category, "tv"), strings.Contains(category, "剧"):
+        return 5000
+    case strings.Contains(category, "movie"), strings.Contains(category, "电影"):
+        return 2000
+    case strings.Contains(category, "audio"), strings.Contains(category, "music"), strings.Contains(category, "lossless"):
+        return 3000
+    case strings.Contains(category, "game"):
+        return 4050
+    case strings.Contains(category, "software"), strings.Contains(category, "application"):
+        return 4000
+    case strings.Contains(category, "literature"), strings.Contains(category, "book"), strings.Contains(category, "manga"):
+        return 7000
+    case strings.Contains(category, "xxx"), strings.Contains(category, "porn"):
+        return 6000
+    }
+    return 8000
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/seedmanage/backend/internal/models"
+	"github.com/seedmanage/backend/internal/torznab"
+	"github.com/seedmanage/backend/internal/utils"
+)
+
+func newTorznabTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	uploaded := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(t, &stubAdapter{id: "a", results: []models.SearchResult{
+		{
+			Title:    "Show S01E02 1080p",
+			Magnet:   "magnet:?xt=urn:btih:0123456789ABCDEF0123456789ABCDEF01234567&dn=Show",
+			InfoHash: "0123456789ABCDEF0123456789ABCDEF01234567",
+			Size:     utils.PtrInt64(1 << 30),
+			Seeders:  utils.PtrInt(12),
+			Leechers: utils.PtrInt(3),
+			Uploaded: &uploaded,
+			Category: "Anime - English-translated",
+		},
+		{
+			Title:    "Album FLAC",
+			Magnet:   "magnet:?xt=urn:btih:89ABCDEF0123456789ABCDEF0123456789ABCDEF&dn=Album",
+			InfoHash: "89ABCDEF0123456789ABCDEF0123456789ABCDEF",
+			Category: "Audio - Lossless",
+		},
+	}})
+	return svc.TorznabHandler("secret")
+}
+
+func TestTorznabCaps(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTorznabTestHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/torznab?t=caps&apikey=secret", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var caps torznab.Caps
+	if err := xml.Unmarshal(rec.Body.Bytes(), &caps); err != nil {
+		t.Fatalf("decode caps: %v", err)
+	}
+	if caps.Searching.TVSearch.Available != "yes" || caps.Searching.TVSearch.SupportedParams != "q,season,ep" {
+		t.Errorf("tv-search = %+v", caps.Searching.TVSearch)
+	}
+	if len(caps.Categories) == 0 || caps.Categories[0].ID != 1000 {
+		t.Errorf("categories = %+v", caps.Categories)
+	}
+}
+
+func TestTorznabAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		url      string
+		wantCode int
+	}{
+		{"wrong key", newTorznabTestHandler(t), "/api/torznab?t=caps&apikey=nope", torznab.ErrIncorrectCredentials},
+		{"missing key", newTorznabTestHandler(t), "/api/torznab?t=caps", torznab.ErrIncorrectCredentials},
+		{"disabled", newTestService(t, &stubAdapter{id: "a"}).TorznabHandler(""), "/api/torznab?t=caps&apikey=", torznab.ErrAPIDisabled},
+		{"missing function", newTorznabTestHandler(t), "/api/torznab?apikey=secret", torznab.ErrMissingParameter},
+		{"unknown function", newTorznabTestHandler(t), "/api/torznab?t=music&apikey=secret", torznab.ErrNoSuchFunction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			_, err := torznab.ParseFeed(rec.Body)
+			var apiErr *torznab.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want torznab error", err)
+			}
+			if apiErr.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", apiErr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTorznabSearch(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTorznabTestHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/torznab?t=tvsearch&q=show&season=1&ep=2&apikey=secret", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	feed, err := torznab.ParseFeed(rec.Body)
+	if err != nil {
+		t.Fatalf("parse feed: %v", err)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Channel.Items))
+	}
+
+	item := feed.Channel.Items[0]
+	checks := map[string]string{
+		torznab.AttrSeeders:   "12",
+		torznab.AttrPeers:     "15",
+		torznab.AttrSize:      "1073741824",
+		torznab.AttrInfoHash:  "0123456789ABCDEF0123456789ABCDEF01234567",
+		torznab.AttrCategory:  "5070",
+		torznab.AttrMagnetURL: "magnet:?xt=urn:btih:0123456789ABCDEF0123456789ABCDEF01234567&dn=Show",
+	}
+	for name, want := range checks {
+		if got := item.Attr(name); got != want {
+			t.Errorf("attr %s = %q, want %q", name, got, want)
+		}
+	}
+	if published, ok := item.Published(); !ok || !published.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("pubDate = %q", item.PubDate)
+	}
+	if item.Enclosure == nil || item.Enclosure.Length != 1<<30 {
+		t.Errorf("enclosure = %+v", item.Enclosure)
+	}
+
+	rec = httptest.NewRecorder()
+	newTorznabTestHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/torznab?t=search&q=album&cat=3000&apikey=secret", nil))
+	feed, err = torznab.ParseFeed(rec.Body)
+	if err != nil {
+		t.Fatalf("parse filtered feed: %v", err)
+	}
+	if len(feed.Channel.Items) != 1 || feed.Channel.Items[0].Title != "Album FLAC" {
+		t.Errorf("cat filter returned %+v", feed.Channel.Items)
+	}
+}
+
+// sequenceStub 按页大小分页返回 total 个编号结果，记录请求的页码和最后一次的搜索选项
+type sequenceStub struct {
+	capableStub
+	total int
+	pages []int
+	last  models.SearchOptions
+}
+
+func (a *sequenceStub) SearchPage(ctx context.Context, options models.SearchOptions) (models.ResultPage, error) {
+	a.pages = append(a.pages, options.Page)
+	a.last = options
+	var results []models.SearchResult
+	for i := (options.Page - 1) * a.caps.PageSize; i < min(options.Page*a.caps.PageSize, a.total); i++ {
+		results = append(results, models.SearchResult{Title: fmt.Sprintf("item %d", i), InfoHash: fmt.Sprintf("%040d", i)})
+	}
+	return models.ResultPage{Results: results, Total: a.total}, nil
+}
+
+func TestTorznabSearchPaging(t *testing.T) {
+	tests := []struct {
+		offset, limit int
+		wantFirst     int
+		wantCount     int
+		wantPages     []int
+	}{
+		{0, 5, 0, 5, []int{1, 2}},
+		{4, 5, 4, 5, []int{2, 3}},
+		{8, 5, 8, 2, []int{3, 4}},
+		{12, 5, 0, 0, []int{5}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("offset=%d", tt.offset), func(t *testing.T) {
+			stub := &sequenceStub{capableStub: capableStub{stubAdapter{id: "p"}, models.Capabilities{Pagination: true, PageSize: 3}}, total: 10}
+			handler := newTestService(t, stub).TorznabHandler("secret")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/torznab?t=search&q=x&apikey=secret&offset=%d&limit=%d", tt.offset, tt.limit), nil))
+			feed, err := torznab.ParseFeed(rec.Body)
+			if err != nil {
+				t.Fatalf("parse feed: %v", err)
+			}
+			if len(feed.Channel.Items) != tt.wantCount {
+				t.Fatalf("got %d items, want %d", len(feed.Channel.Items), tt.wantCount)
+			}
+			for i, item := range feed.Channel.Items {
+				if want := fmt.Sprintf("item %d", tt.wantFirst+i); item.Title != want {
+					t.Errorf("item %d = %q, want %q", i, item.Title, want)
+				}
+			}
+			if response := feed.Channel.Response; response == nil || response.Total != 10 || response.Offset != tt.offset {
+				t.Errorf("response = %+v, want total 10", response)
+			}
+			if fmt.Sprint(stub.pages) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("requested pages %v, want %v", stub.pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestTorznabSearchWithoutQuery(t *testing.T) {
+	stub := &sequenceStub{capableStub: capableStub{stubAdapter{id: "p"}, models.Capabilities{Pagination: true, PageSize: 3}}, total: 10}
+	handler := newTestService(t, stub).TorznabHandler("secret")
+
+	// Sonarr/Radarr 的 RSS 同步和索引器测试不带 q，应返回最新发布的结果而不是空列表
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/torznab?t=search&apikey=secret&limit=3", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	feed, err := torznab.ParseFeed(rec.Body)
+	if err != nil {
+		t.Fatalf("parse feed: %v", err)
+	}
+	if len(feed.Channel.Items) != 3 || feed.Channel.Items[0].Title != "item 0" {
+		t.Fatalf("items = %+v, want the first page", feed.Channel.Items)
+	}
+	if response := feed.Channel.Response; response == nil || response.Total != 10 {
+		t.Errorf("response = %+v, want total 10", response)
+	}
+	if stub.last.Query != "" || stub.last.Sort != models.SortDate || stub.last.Order != models.OrderDesc {
+		t.Errorf("options = %+v, want empty query sorted by date", stub.last)
+	}
+}
@@ -0,0 +1,135 @@
+package torznab
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// 标准 Torznab 错误码
+const (
+	ErrIncorrectCredentials = 100
+	ErrMissingParameter     = 200
+	ErrIncorrectParameter   = 201
+	ErrNoSuchFunction       = 202
+	ErrFunctionUnavailable  = 203
+	ErrUnknown              = 900
+	ErrAPIDisabled          = 910
+)
+
+// Caps 描述 t=caps 返回的索引器能力
+type Caps struct {
+	XMLName    xml.Name      `xml:"caps"`
+	Server     CapsServer    `xml:"server"`
+	Limits     CapsLimits    `xml:"limits"`
+	Searching  CapsSearching `xml:"searching"`
+	Categories []Category    `xml:"categories>category"`
+}
+
+// CapsServer 描述服务端信息
+type CapsServer struct {
+	Version string `xml:"version,attr"`
+	Title   string `xml:"title,attr"`
+}
+
+// CapsLimits 描述单次返回结果数量的限制
+type CapsLimits struct {
+	Max     int `xml:"max,attr"`
+	Default int `xml:"default,attr"`
+}
+
+// CapsSearching 描述支持的搜索函数
+type CapsSearching struct {
+	Search      CapsFunction `xml:"search"`
+	TVSearch    CapsFunction `xml:"tv-search"`
+	MovieSearch CapsFunction `xml:"movie-search"`
+}
+
+// CapsFunction 描述单个搜索函数是否可用以及支持的参数
+type CapsFunction struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+// Category 是 caps 中的分类及其子分类
+type Category struct {
+	ID      int        `xml:"id,attr"`
+	Name    string     `xml:"name,attr"`
+	Subcats []Category `xml:"subcat,omitempty"`
+}
+
+// rssOut 及其子结构用于输出带命名空间前缀的 Torznab RSS
+type rssOut struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	TorznabNS string     `xml:"xmlns:torznab,attr"`
+	NewznabNS string     `xml:"xmlns:newznab,attr"`
+	Channel   channelOut `xml:"channel"`
+}
+
+type channelOut struct {
+	Title       string    `xml:"title"`
+	Description string    `xml:"description"`
+	Link        string    `xml:"link,omitempty"`
+	Response    *Response `xml:"newznab:response,omitempty"`
+	Items       []itemOut `xml:"item"`
+}
+
+type itemOut struct {
+	Title      string     `xml:"title"`
+	GUID       string     `xml:"guid"`
+	Link       string     `xml:"link"`
+	Comments   string     `xml:"comments,omitempty"`
+	PubDate    string     `xml:"pubDate,omitempty"`
+	Size       int64      `xml:"size,omitempty"`
+	Categories []string   `xml:"category"`
+	Enclosure  *Enclosure `xml:"enclosure,omitempty"`
+	Attrs      []Attr     `xml:"torznab:attr"`
+}
+
+// WriteFeed 以 Torznab RSS 格式输出搜索结果
+func WriteFeed(w io.Writer, title string, response *Response, items []Item) error {
+	out := rssOut{
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		TorznabNS: Namespace,
+		NewznabNS: NewznabNamespace,
+		Channel: channelOut{
+			Title:       title,
+			Description: title,
+			Response:    response,
+			Items:       make([]itemOut, 0, len(items)),
+		},
+	}
+	for _, item := range items {
+		out.Channel.Items = append(out.Channel.Items, itemOut(item))
+	}
+	return encode(w, out)
+}
+
+// WriteCaps 输出 t=caps 的响应
+func WriteCaps(w io.Writer, caps Caps) error {
+	return encode(w, caps)
+}
+
+// WriteError 输出 Torznab 错误文档
+func WriteError(w io.Writer, code int, description string) error {
+	return encode(w, struct {
+		XMLName     xml.Name `xml:"error"`
+		Code        string   `xml:"code,attr"`
+		Description string   `xml:"description,attr"`
+	}{Code: strconv.Itoa(code), Description: description})
+}
+
+func encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
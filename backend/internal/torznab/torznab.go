@@ -0,0 +1,229 @@
+// Package torznab 定义 Torznab（Newznab 的种子扩展）XML API 的数据结构和解析逻辑
+package torznab
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Namespace 是 torznab:attr 等扩展元素所在的 XML 命名空间
+	Namespace = "http://torznab.com/schemas/2015/feed"
+	// NewznabNamespace 是 newznab:response 所在的 XML 命名空间
+	NewznabNamespace = "http://www.newznab.com/DTD/2010/feeds/attributes/"
+)
+
+// 常用的 torznab:attr 名称
+const (
+	AttrSeeders    = "seeders"
+	AttrPeers      = "peers"
+	AttrSize       = "size"
+	AttrInfoHash   = "infohash"
+	AttrMagnetURL  = "magneturl"
+	AttrCategory   = "category"
+	AttrFiles      = "files"
+	AttrGrabs      = "grabs"
+	AttrDownloadVF = "downloadvolumefactor"
+	AttrUploadVF   = "uploadvolumefactor"
+)
+
+// Feed 是 Torznab 搜索结果的 RSS 文档
+type Feed struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel Channel  `xml:"channel"`
+}
+
+// Channel 是 RSS 频道
+type Channel struct {
+	Title    string    `xml:"title"`
+	Response *Response `xml:"response"`
+	Items    []Item    `xml:"item"`
+}
+
+// Response 对应 newznab:response，描述分页信息
+type Response struct {
+	Offset int `xml:"offset,attr"`
+	Total  int `xml:"total,attr"`
+}
+
+// Item 是单个搜索结果
+type Item struct {
+	Title      string     `xml:"title"`
+	GUID       string     `xml:"guid"`
+	Link       string     `xml:"link"`
+	Comments   string     `xml:"comments"`
+	PubDate    string     `xml:"pubDate"`
+	Size       int64      `xml:"size"`
+	Categories []string   `xml:"category"`
+	Enclosure  *Enclosure `xml:"enclosure"`
+	Attrs      []Attr     `xml:"attr"`
+}
+
+// Enclosure 是 RSS enclosure 元素
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// Attr 对应 torznab:attr 元素
+type Attr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Error 是 Torznab API 返回的错误文档
+type Error struct {
+	Code        int    `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("torznab error %d: %s", e.Code, e.Description)
+}
+
+// ParseFeed 解析 Torznab 搜索响应，响应为 <error> 文档时返回 *Error
+func ParseFeed(r io.Reader) (*Feed, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("torznab: invalid xml: %w", err)
+	}
+
+	switch probe.XMLName.Local {
+	case "error":
+		var apiErr Error
+		if err := xml.Unmarshal(data, &apiErr); err != nil {
+			return nil, fmt.Errorf("torznab: invalid error document: %w", err)
+		}
+		return nil, &apiErr
+	case "rss":
+		var feed Feed
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("torznab: invalid feed: %w", err)
+		}
+		return &feed, nil
+	default:
+		return nil, fmt.Errorf("torznab: unexpected root element <%s>", probe.XMLName.Local)
+	}
+}
+
+// Attr 返回指定名称的第一个 torznab:attr 值
+func (it Item) Attr(name string) string {
+	for _, attr := range it.Attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// AttrValues 返回指定名称的所有 torznab:attr 值
+func (it Item) AttrValues(name string) []string {
+	var values []string
+	for _, attr := range it.Attrs {
+		if strings.EqualFold(attr.Name, name) {
+			values = append(values, attr.Value)
+		}
+	}
+	return values
+}
+
+// AttrInt 以整数形式返回 torznab:attr 值
+func (it Item) AttrInt(name string) (int64, bool) {
+	value := it.Attr(name)
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// Published 解析 pubDate
+func (it Item) Published() (time.Time, bool) {
+	if it.PubDate == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339} {
+		if t, err := time.Parse(layout, strings.TrimSpace(it.PubDate)); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// 标准 Newznab/Torznab 分类
+var categoryNames = map[int]string{
+	1000: "Console",
+	2000: "Movies",
+	2010: "Movies/Foreign",
+	2020: "Movies/Other",
+	2030: "Movies/SD",
+	2040: "Movies/HD",
+	2045: "Movies/UHD",
+	2050: "Movies/BluRay",
+	2060: "Movies/3D",
+	3000: "Audio",
+	3010: "Audio/MP3",
+	3030: "Audio/Audiobook",
+	3040: "Audio/Lossless",
+	4000: "PC",
+	4050: "PC/Games",
+	5000: "TV",
+	5030: "TV/SD",
+	5040: "TV/HD",
+	5045: "TV/UHD",
+	5070: "TV/Anime",
+	6000: "XXX",
+	7000: "Books",
+	7020: "Books/EBook",
+	7030: "Books/Comics",
+	8000: "Other",
+}
+
+// Categories 返回 caps 中使用的标准分类树
+func Categories() []Category {
+	var categories []Category
+	for id, name := range categoryNames {
+		if id%1000 == 0 {
+			categories = append(categories, Category{ID: id, Name: name})
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+
+	for i := range categories {
+		for id, name := range categoryNames {
+			if id/1000*1000 == categories[i].ID && id != categories[i].ID {
+				categories[i].Subcats = append(categories[i].Subcats, Category{ID: id, Name: name})
+			}
+		}
+		subcats := categories[i].Subcats
+		sort.Slice(subcats, func(a, b int) bool { return subcats[a].ID < subcats[b].ID })
+	}
+	return categories
+}
+
+// CategoryName 返回标准分类 ID 对应的名称，未知子分类回退到父分类
+func CategoryName(id int) string {
+	if name, ok := categoryNames[id]; ok {
+		return name
+	}
+	if name, ok := categoryNames[id/1000*1000]; ok {
+		return name
+	}
+	return ""
+}
@@ -42,6 +42,17 @@ func Coalesce(values ...string) string {
 	return ""
 }
 
+// SplitList 按逗号拆分字符串，去除空白和空项
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Getenv 获取环境变量，如果为空则返回默认值
 func Getenv(key, defaultValue string) string {
 	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
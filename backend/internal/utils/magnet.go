@@ -2,73 +2,58 @@ package utils
 
 import (
 	"fmt"
-	"net/url"
 	"strings"
 
+	"github.com/seedmanage/backend/internal/magnet"
 	"github.com/seedmanage/backend/internal/models"
 )
 
-// ParseMagnetLink 解析磁力链接并提取信息
-func ParseMagnetLink(raw string) (models.SearchResult, error) {
-	u, err := url.Parse(raw)
+// ParseMagnetLink 解析磁力链接并提取信息，同时返回完整的 magnet.Magnet
+// InfoHash 为规范化的十六进制 v1 hash（纯 v2 链接为截断的 v2 hash），xl 参数填入 Size
+// 搜索结果不携带 web seed、来源和 so 等参数，需要重新构建链接时把返回的 magnet.Magnet 交给 BuildMagnetLink
+// 解析是宽松的（magnet.ParseLenient），只有不是磁力链接时才返回错误：没有有效 btih/btmh 时 InfoHash 取第一个 xt
+// 的最后一段，无效的 xl、so 等参数被忽略，不会因此拒绝用户保存或搜索的链接
+func ParseMagnetLink(raw string) (models.SearchResult, magnet.Magnet, error) {
+	m, err := magnet.ParseLenient(raw)
 	if err != nil {
-		return models.SearchResult{}, fmt.Errorf("无效的磁力链接: %w", err)
-	}
-	if u.Scheme != "magnet" {
-		return models.SearchResult{}, fmt.Errorf("仅支持磁力链接")
-	}
-
-	params := u.Query()
-	title := Coalesce(decodeLabel(params.Get("dn")), "磁力链接")
-	xt := params.Get("xt")
-	infoHash := ""
-	if xt != "" {
-		parts := strings.Split(xt, ":")
-		infoHash = strings.ToUpper(parts[len(parts)-1])
-	}
-
-	trackers := make([]string, 0, len(params["tr"]))
-	for _, tr := range params["tr"] {
-		trackers = append(trackers, decodeLabel(tr))
+		return models.SearchResult{}, magnet.Magnet{}, fmt.Errorf("无效的磁力链接: %w", err)
 	}
 
-	return models.SearchResult{
-		Title:    title,
+	result := models.SearchResult{
+		Title:    Coalesce(m.Name, "磁力链接"),
 		Magnet:   raw,
-		InfoHash: infoHash,
-		Trackers: trackers,
-		Seeders:  nil,
-		Leechers: nil,
-		Size:     nil,
+		InfoHash: Coalesce(m.Hash(), legacyInfoHash(m.Topics)),
+		Trackers: m.Trackers,
 		Category: "Direct Magnet",
 		Source:   "magnet-link",
-	}, nil
+	}
+	if m.Size > 0 {
+		result.Size = PtrInt64(m.Size)
+		result.SizeLabel = FormatSize(m.Size)
+	}
+	return result, m, nil
 }
 
-// BuildMagnetLink 根据 info hash、标题和 tracker 列表构建磁力链接
-func BuildMagnetLink(infoHash, title string, trackers []string) string {
-	var builder strings.Builder
-	builder.WriteString("magnet:?xt=urn:btih:")
-	builder.WriteString(strings.ToUpper(infoHash))
-	builder.WriteString("&dn=")
-	builder.WriteString(url.QueryEscape(title))
-	for _, tracker := range trackers {
-		builder.WriteString("&tr=")
-		builder.WriteString(url.QueryEscape(tracker))
+// legacyInfoHash 返回第一个 xt 最后一个冒号之后的部分（大写），用于无法识别的 xt
+func legacyInfoHash(topics []string) string {
+	if len(topics) == 0 {
+		return ""
 	}
-	return builder.String()
+	return strings.ToUpper(topics[0][strings.LastIndex(topics[0], ":")+1:])
 }
 
-// decodeLabel 解码 URL 编码的标签
-func decodeLabel(v string) string {
-	if v == "" {
-		return v
+// BuildMagnetLink 构建磁力链接，保留 xl、ws/as/xs、so 等全部参数，与 ParseMagnetLink 可以往返
+// InfoHash 会被规范化（base32 转换为十六进制）；64 位十六进制的 InfoHash 视为 v2 info hash
+func BuildMagnetLink(m magnet.Magnet) string {
+	if hash := NormalizeInfoHash(m.InfoHash); len(hash) == 64 && m.InfoHashV2 == "" {
+		m.InfoHash, m.InfoHashV2 = "", hash
+	} else {
+		m.InfoHash = hash
 	}
-	replaced := strings.ReplaceAll(v, "+", " ")
-	decoded, err := url.QueryUnescape(replaced)
-	if err != nil {
-		return replaced
-	}
-	return decoded
+	return m.String()
 }
 
+// NormalizeInfoHash 将 info hash 规范化为大写十六进制，支持 base32 编码的 btih
+func NormalizeInfoHash(hash string) string {
+	return magnet.NormalizeHash(hash)
+}
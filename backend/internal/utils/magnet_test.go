@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/seedmanage/backend/internal/magnet"
+)
+
+func TestMagnetLinkRoundTrip(t *testing.T) {
+	raw := "magnet:?xt=urn:btih:F257AF31A6204CD734D2BAECB8331637850B7B44&dn=Demo&xl=1024" +
+		"&tr=" + url.QueryEscape("udp://tracker.example:1337/announce") +
+		"&ws=" + url.QueryEscape("https://seed.example/demo/") +
+		"&as=" + url.QueryEscape("https://mirror.example/demo.zip") +
+		"&xs=" + url.QueryEscape("https://site.example/demo.torrent") +
+		"&so=0,2-3"
+
+	result, m, err := ParseMagnetLink(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.InfoHash != "F257AF31A6204CD734D2BAECB8331637850B7B44" || result.Size == nil || *result.Size != 1024 {
+		t.Errorf("result = %+v", result)
+	}
+
+	rebuilt, err := magnet.Parse(BuildMagnetLink(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := magnet.Parse(raw)
+	if rebuilt.String() != want.String() {
+		t.Errorf("BuildMagnetLink lost fields:\n%s\nwant\n%s", rebuilt.String(), want.String())
+	}
+}
+
+func TestParseMagnetLinkLenient(t *testing.T) {
+	// 基线版本接受的链接不能被拒绝：非标准的 btih 沿用基线的 InfoHash，无效的 xl 被忽略
+	for raw, want := range map[string]string{
+		"magnet:?dn=no+hash":                                                "",
+		"magnet:?xt=urn:btih:abc123&dn=short":                               "ABC123",
+		"magnet:?xt=urn:btih:F257AF31A6204CD734D2BAECB8331637850B7B44&xl=x": "F257AF31A6204CD734D2BAECB8331637850B7B44",
+	} {
+		result, _, err := ParseMagnetLink(raw)
+		if err != nil {
+			t.Errorf("ParseMagnetLink(%q): %v", raw, err)
+			continue
+		}
+		if result.InfoHash != want || result.Size != nil {
+			t.Errorf("ParseMagnetLink(%q) = %+v, want info hash %q", raw, result, want)
+		}
+	}
+	if _, _, err := ParseMagnetLink("https://example.com/"); err == nil {
+		t.Error("ParseMagnetLink accepted a non-magnet URL")
+	}
+}
+
+func TestBuildMagnetLinkNormalizesHash(t *testing.T) {
+	v2 := "cb7a6f1c8d4e2b0a9f3e5d7c1b2a39485766a5b4c3d2e1f00fedcba987654321"
+	for hash, want := range map[string]string{
+		"6jl26mngebgnongsxlwlqmywg6cqw62e": "magnet:?xt=urn:btih:F257AF31A6204CD734D2BAECB8331637850B7B44",
+		v2:                                 "magnet:?xt=urn:btmh:1220" + v2,
+	} {
+		if got := BuildMagnetLink(magnet.Magnet{InfoHash: hash}); got != want {
+			t.Errorf("BuildMagnetLink(%s) = %s, want %s", hash, got, want)
+		}
+	}
+}